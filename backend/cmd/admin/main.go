@@ -0,0 +1,97 @@
+// Команда admin — служебные операции над базой, которые не нужны API и worker'у
+// в обычной работе.
+//
+//	admin rebuild-segments -task <id> [-force]
+//	admin rebuild-segments -all [-provider yandex_speechkit] [-force]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"loopa/backend/internal/config"
+	"loopa/backend/internal/db"
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/worker"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "rebuild-segments":
+		rebuildSegments(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin rebuild-segments (-task <id> | -all [-provider <name>]) [-force]")
+	os.Exit(2)
+}
+
+// rebuildSegments перестраивает сегменты по сохранённым ответам провайдеров,
+// например после исправления алгоритма выравнивания слов по спикерам.
+func rebuildSegments(args []string) {
+	fs := flag.NewFlagSet("rebuild-segments", flag.ExitOnError)
+	taskID := fs.String("task", "", "ID задачи")
+	all := fs.Bool("all", false, "все завершённые задачи с сохранёнными ответами")
+	provider := fs.String("provider", "", "только задачи провайдера (faster_whisper, yandex_speechkit, whisper_cpp, openai, mock)")
+	force := fs.Bool("force", false, "перезаписать и исправленные вручную сегменты")
+	fs.Parse(args)
+
+	if (*taskID == "") == !*all {
+		usage()
+	}
+
+	cfg := config.Load()
+	conn, err := db.Open(cfg.DBDSN)
+	if err != nil {
+		log.Fatalf("db open failed: %v", err)
+	}
+	defer conn.Close()
+
+	// ML-сервис нужен только для поиска слов-паразитов в новых сегментах
+	w := worker.New(conn, worker.Config{
+		MLServiceURL: cfg.MLServiceURL,
+		MLTimeouts: mlclient.Timeouts{
+			ProcessText: cfg.MLProcessTextTimeout,
+		},
+	})
+
+	ctx := context.Background()
+	ids := []string{*taskID}
+	if *all {
+		ids, err = w.RebuildableTasks(ctx, *provider)
+		if err != nil {
+			log.Fatalf("list tasks failed: %v", err)
+		}
+	}
+
+	var rebuilt, skipped, failed int
+	for _, id := range ids {
+		_, err := w.RebuildSegments(ctx, id, *force)
+		switch {
+		case err == nil:
+			rebuilt++
+		case errors.Is(err, worker.ErrHasCorrections), errors.Is(err, worker.ErrNoResponses), errors.Is(err, worker.ErrTaskNotReady):
+			log.Printf("task %s: skipped: %v", id, err)
+			skipped++
+		default:
+			log.Printf("task %s: rebuild failed: %v", id, err)
+			failed++
+		}
+	}
+
+	log.Printf("rebuilt %d, skipped %d, failed %d", rebuilt, skipped, failed)
+	if failed > 0 || (*taskID != "" && rebuilt == 0) {
+		os.Exit(1)
+	}
+}
@@ -4,18 +4,25 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"loopa/backend/internal/config"
 	"loopa/backend/internal/db"
+	"loopa/backend/internal/media"
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/openaistt"
+	"loopa/backend/internal/speechkit"
+	"loopa/backend/internal/whispercpp"
 	"loopa/backend/internal/worker"
 )
 
 func main() {
 	cfg := config.Load()
 
-	// SpeechKit ключи обязательны только при provider=speechkit
-	if cfg.TranscriptionProvider == "speechkit" {
+	// SpeechKit ключи обязательны, только если SpeechKit есть в цепочке провайдеров
+	chain := cfg.ProviderChain()
+	if cfg.UsesProvider("speechkit") {
 		if cfg.YandexSpeechKitAPIKey == "" {
 			log.Fatal("YANDEX_SPEECHKIT_API_KEY is required for speechkit provider")
 		}
@@ -30,7 +37,11 @@ func main() {
 		log.Printf("Using Folder ID: %s", cfg.YandexFolderId)
 	}
 
-	log.Printf("Transcription provider: %s", cfg.TranscriptionProvider)
+	if cfg.UsesProvider("whispercpp") && cfg.WhisperCppModel == "" {
+		log.Fatal("WHISPER_CPP_MODEL is required for whispercpp provider")
+	}
+
+	log.Printf("Transcription provider: %s", strings.Join(chain, " → "))
 
 	conn, err := db.Open(cfg.DBDSN)
 	if err != nil {
@@ -49,18 +60,86 @@ func main() {
 			AccessKey: cfg.YandexStorageAccessKey,
 			SecretKey: cfg.YandexStorageSecretKey,
 			Bucket:    cfg.YandexStorageBucket,
+			Endpoint:  cfg.YandexStorageEndpoint,
 		}
 	}
 
-	w := worker.New(conn, cfg.TranscriptionProvider, cfg.YandexSpeechKitAPIKey, cfg.YandexFolderId, cfg.UploadDir, cfg.MLServiceURL, s3cfg)
+	preprocessing, err := media.ParsePreprocess(cfg.PreprocessingDefault)
+	if err != nil {
+		log.Fatalf("invalid PREPROCESSING_DEFAULT: %v", err)
+	}
+
+	w := worker.New(conn, worker.Config{
+		Provider:             cfg.TranscriptionProvider,
+		Fallback:             chain[1:],
+		SpeechKitAPIKey:      cfg.YandexSpeechKitAPIKey,
+		SpeechKitFolderID:    cfg.YandexFolderId,
+		UploadDir:            cfg.UploadDir,
+		MLServiceURL:         cfg.MLServiceURL,
+		S3:                   s3cfg,
+		KeepOriginalVideo:    cfg.KeepOriginalVideo,
+		Preprocessing:        preprocessing,
+		SpeechKitConcurrency: cfg.SpeechKitConcurrency,
+		SpeechKitRPS:         float64(cfg.SpeechKitRPS),
+		SpeechKitAPIVersion:  cfg.SpeechKitAPIVersion,
+		SpeechKitOptions: speechkit.RecognitionOptions{
+			Model:           cfg.SpeechKitModel,
+			Language:        "ru-RU",
+			Normalize:       cfg.SpeechKitTextNormalization,
+			LiteratureText:  cfg.SpeechKitLiteratureText,
+			ProfanityFilter: cfg.SpeechKitProfanityFilter,
+		},
+		MLTimeouts: mlclient.Timeouts{
+			Diarize:           cfg.MLDiarizeTimeout,
+			TranscribeFull:    cfg.MLTranscribeTimeout,
+			ProcessText:       cfg.MLProcessTextTimeout,
+			SpeakerEmbeddings: cfg.MLEmbeddingsTimeout,
+		},
+		SpeechKitEndpoints: speechkit.Endpoints{
+			STT:        cfg.SpeechKitSTTURL,
+			Transcribe: cfg.SpeechKitTranscribeURL,
+			Operation:  cfg.SpeechKitOperationURL,
+		},
+		ProviderFailureThreshold: cfg.ProviderFailureThreshold,
+		ProviderCooldown:         cfg.ProviderCooldown,
+		HealthCheckInterval:      cfg.HealthCheckInterval,
+		WhisperCpp: whispercpp.Options{
+			Binary:   cfg.WhisperCppBinary,
+			Model:    cfg.WhisperCppModel,
+			Threads:  cfg.WhisperCppThreads,
+			Language: cfg.WhisperCppLanguage,
+		},
+		OpenAI: openaistt.Options{
+			BaseURL:  cfg.OpenAISTTURL,
+			APIKey:   cfg.OpenAISTTAPIKey,
+			Model:    cfg.OpenAISTTModel,
+			Language: cfg.OpenAISTTLanguage,
+		},
+		MockDelay:       cfg.MockDelay,
+		MockFixturesDir: cfg.MockFixturesDir,
+		MockFail:        cfg.MockFail,
+
+		SpeakerIdentification: cfg.SpeakerIdentification,
+		SpeakerMatchThreshold: cfg.SpeakerMatchThreshold,
+	})
 
 	stop := make(chan struct{})
-	go w.Run(stop)
+	done := make(chan struct{})
+	go func() {
+		w.Run(stop)
+		close(done)
+	}()
 
 	if cfg.TranscriptionProvider == "whisper" {
 		log.Println("Worker started with Faster-Whisper (ML-сервис)")
+	} else if cfg.TranscriptionProvider == "mock" {
+		log.Println("Worker started with mock provider (deterministic results, no ML)")
+	} else if cfg.TranscriptionProvider == "openai" {
+		log.Println("Worker started with OpenAI-compatible API (" + cfg.OpenAISTTURL + ", model " + cfg.OpenAISTTModel + ")")
+	} else if cfg.TranscriptionProvider == "whispercpp" {
+		log.Println("Worker started with whisper.cpp (model " + cfg.WhisperCppModel + ")")
 	} else if s3cfg != nil {
-		log.Println("Worker started with Yandex SpeechKit (async mode for long audio)")
+		log.Println("Worker started with Yandex SpeechKit (async " + cfg.SpeechKitAPIVersion + " mode for long audio)")
 	} else {
 		log.Println("Worker started with Yandex SpeechKit (chunked mode for long audio)")
 	}
@@ -69,6 +148,8 @@ func main() {
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	<-sig
 	close(stop)
+	// Дожидаемся, пока worker прервёт ожидание SpeechKit и сохранит состояние задачи
+	<-done
 }
 
 func getEnv(key, fallback string) string {
@@ -0,0 +1,246 @@
+// Package analytics считает статистику разговора по сегментам транскрипции:
+// время речи, реплики, темп, перебивания и слова-паразиты по спикерам.
+package analytics
+
+import (
+	"sort"
+	"strings"
+)
+
+// Segment — сегмент транскрипции; время в миллисекундах.
+type Segment struct {
+	SpeakerID   string
+	SpeakerName string
+	Start       int
+	End         int
+	Text        string
+	// Fillers — слова-паразиты сегмента с числом повторов.
+	Fillers map[string]int
+}
+
+// FillerCount — слово-паразит и число его повторов.
+type FillerCount struct {
+	Filler string `json:"filler"`
+	Count  int    `json:"count"`
+}
+
+// SpeakerStats — статистика одного спикера.
+//
+// Реплика (turn) — подряд идущие сегменты спикера. TalkShare — доля времени
+// речи спикера в процентах от речи всех спикеров. Interruptions — сколько раз
+// спикер начал говорить, пока другой ещё не закончил. FillerRate — паразитов
+// на 100 слов, FillersPerMinute — на минуту речи спикера.
+type SpeakerStats struct {
+	SpeakerID          string        `json:"speakerId"`
+	SpeakerName        string        `json:"speakerName,omitempty"`
+	TalkTimeMs         int           `json:"talkTimeMs"`
+	TalkShare          float64       `json:"talkShare"`
+	Turns              int           `json:"turns"`
+	AvgTurnMs          int           `json:"avgTurnMs"`
+	LongestMonologueMs int           `json:"longestMonologueMs"`
+	Words              int           `json:"words"`
+	WordsPerMinute     float64       `json:"wordsPerMinute"`
+	Interruptions      int           `json:"interruptions"`
+	Fillers            int           `json:"fillers"`
+	FillerRate         float64       `json:"fillerRate"`
+	FillersPerMinute   float64       `json:"fillersPerMinute"`
+	TopFillers         []FillerCount `json:"topFillers"`
+}
+
+// Report — статистика задачи или проекта: итоги и спикеры в порядке первой реплики.
+// DurationMs — от начала первого до конца последнего сегмента (для проекта — сумма по задачам).
+type Report struct {
+	DurationMs    int            `json:"durationMs"`
+	TalkTimeMs    int            `json:"talkTimeMs"`
+	Turns         int            `json:"turns"`
+	Interruptions int            `json:"interruptions"`
+	Words         int            `json:"words"`
+	Fillers       int            `json:"fillers"`
+	FillerRate    float64        `json:"fillerRate"`
+	TopFillers    []FillerCount  `json:"topFillers"`
+	Speakers      []SpeakerStats `json:"speakers"`
+}
+
+// topFillersLimit — сколько самых частых паразитов показывать.
+const topFillersLimit = 5
+
+type speakerAcc struct {
+	stats       SpeakerStats
+	turnTotalMs int
+	fillers     map[string]int
+}
+
+type accumulator struct {
+	report   Report
+	order    []string
+	speakers map[string]*speakerAcc
+	fillers  map[string]int
+}
+
+// Compute считает статистику одной задачи. Сегменты без спикера
+// учитываются под пустым SpeakerID.
+func Compute(segments []Segment) Report {
+	acc := newAccumulator()
+	acc.addTask(segments, func(seg Segment) string { return seg.SpeakerID })
+	return acc.finish()
+}
+
+// ComputeProject сводит статистику нескольких задач. ID спикеров (SPEAKER_00)
+// действуют только внутри задачи, поэтому спикеры объединяются по имени,
+// а безымянные — по ID. Реплики и перебивания считаются внутри каждой задачи.
+func ComputeProject(tasks [][]Segment) Report {
+	acc := newAccumulator()
+	for _, segments := range tasks {
+		acc.addTask(segments, func(seg Segment) string {
+			if seg.SpeakerName != "" {
+				return "name:" + seg.SpeakerName
+			}
+			return "id:" + seg.SpeakerID
+		})
+	}
+	return acc.finish()
+}
+
+func newAccumulator() *accumulator {
+	return &accumulator{speakers: map[string]*speakerAcc{}, fillers: map[string]int{}}
+}
+
+func (a *accumulator) speaker(key string, seg Segment) *speakerAcc {
+	acc, ok := a.speakers[key]
+	if !ok {
+		acc = &speakerAcc{
+			stats:   SpeakerStats{SpeakerID: seg.SpeakerID},
+			fillers: map[string]int{},
+		}
+		a.speakers[key] = acc
+		a.order = append(a.order, key)
+	}
+	if acc.stats.SpeakerName == "" {
+		acc.stats.SpeakerName = seg.SpeakerName
+	}
+	return acc
+}
+
+// addTask учитывает сегменты одной задачи; сегменты сортируются по началу.
+func (a *accumulator) addTask(segments []Segment, key func(Segment) string) {
+	if len(segments) == 0 {
+		return
+	}
+	sorted := make([]Segment, len(segments))
+	copy(sorted, segments)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	first, last := sorted[0].Start, sorted[0].End
+	// Конец последнего сегмента каждого спикера — для поиска перебиваний
+	speakingUntil := map[string]int{}
+	var current *speakerAcc
+	turnStart, turnEnd := 0, 0
+	closeTurn := func() {
+		if current == nil {
+			return
+		}
+		length := turnEnd - turnStart
+		current.turnTotalMs += length
+		current.stats.LongestMonologueMs = max(current.stats.LongestMonologueMs, length)
+	}
+
+	for _, seg := range sorted {
+		k := key(seg)
+		acc := a.speaker(k, seg)
+		last = max(last, seg.End)
+		duration := max(seg.End-seg.Start, 0)
+
+		for other, until := range speakingUntil {
+			if other != k && until > seg.Start {
+				acc.stats.Interruptions++
+				a.report.Interruptions++
+				break
+			}
+		}
+		speakingUntil[k] = max(speakingUntil[k], seg.End)
+
+		if acc != current {
+			closeTurn()
+			current = acc
+			turnStart, turnEnd = seg.Start, seg.End
+			acc.stats.Turns++
+			a.report.Turns++
+		} else {
+			turnEnd = max(turnEnd, seg.End)
+		}
+
+		acc.stats.TalkTimeMs += duration
+		a.report.TalkTimeMs += duration
+
+		words := len(strings.Fields(seg.Text))
+		acc.stats.Words += words
+		a.report.Words += words
+		for filler, count := range seg.Fillers {
+			acc.fillers[filler] += count
+			acc.stats.Fillers += count
+			a.fillers[filler] += count
+			a.report.Fillers += count
+		}
+	}
+	closeTurn()
+	a.report.DurationMs += last - first
+}
+
+func (a *accumulator) finish() Report {
+	report := a.report
+	report.FillerRate = per100(report.Fillers, report.Words)
+	report.TopFillers = topFillers(a.fillers)
+	report.Speakers = make([]SpeakerStats, 0, len(a.order))
+	for _, key := range a.order {
+		acc := a.speakers[key]
+		stats := acc.stats
+		if report.TalkTimeMs > 0 {
+			stats.TalkShare = round2(float64(stats.TalkTimeMs) * 100 / float64(report.TalkTimeMs))
+		}
+		if stats.Turns > 0 {
+			stats.AvgTurnMs = acc.turnTotalMs / stats.Turns
+		}
+		stats.WordsPerMinute = perMinute(stats.Words, stats.TalkTimeMs)
+		stats.FillerRate = per100(stats.Fillers, stats.Words)
+		stats.FillersPerMinute = perMinute(stats.Fillers, stats.TalkTimeMs)
+		stats.TopFillers = topFillers(acc.fillers)
+		report.Speakers = append(report.Speakers, stats)
+	}
+	return report
+}
+
+func per100(count, words int) float64 {
+	if words == 0 {
+		return 0
+	}
+	return round2(float64(count) * 100 / float64(words))
+}
+
+func perMinute(count, durationMs int) float64 {
+	if durationMs <= 0 {
+		return 0
+	}
+	return round2(float64(count) * 60000 / float64(durationMs))
+}
+
+func round2(v float64) float64 {
+	return float64(int64(v*100+0.5)) / 100
+}
+
+// topFillers сортирует паразитов по убыванию частоты, при равенстве — по алфавиту.
+func topFillers(counts map[string]int) []FillerCount {
+	list := make([]FillerCount, 0, len(counts))
+	for filler, count := range counts {
+		list = append(list, FillerCount{Filler: filler, Count: count})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Filler < list[j].Filler
+	})
+	if len(list) > topFillersLimit {
+		list = list[:topFillersLimit]
+	}
+	return list
+}
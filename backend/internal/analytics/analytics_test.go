@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompute_FillersBySpeaker(t *testing.T) {
+	report := Compute([]Segment{
+		{SpeakerID: "SPEAKER_00", SpeakerName: "Анна", Start: 0, End: 30000,
+			Text: "ну добрый день ну начнём", Fillers: map[string]int{"ну": 2}},
+		{SpeakerID: "SPEAKER_01", Start: 30000, End: 40000,
+			Text: "здравствуйте", Fillers: nil},
+		{SpeakerID: "SPEAKER_00", Start: 40000, End: 60000,
+			Text: "как бы так вот", Fillers: map[string]int{"как бы": 1, "вот": 1}},
+	})
+
+	assert.Equal(t, 10, report.Words)
+	assert.Equal(t, 4, report.Fillers)
+	assert.Equal(t, 40.0, report.FillerRate)
+	require.NotEmpty(t, report.TopFillers)
+	assert.Equal(t, FillerCount{Filler: "ну", Count: 2}, report.TopFillers[0])
+
+	require.Len(t, report.Speakers, 2)
+	anna := report.Speakers[0]
+	assert.Equal(t, "SPEAKER_00", anna.SpeakerID)
+	assert.Equal(t, "Анна", anna.SpeakerName)
+	assert.Equal(t, 9, anna.Words)
+	assert.Equal(t, 4, anna.Fillers)
+	assert.Equal(t, 44.44, anna.FillerRate)
+	// 4 паразита за 50 секунд речи
+	assert.Equal(t, 4.8, anna.FillersPerMinute)
+	assert.Equal(t, []FillerCount{{"ну", 2}, {"вот", 1}, {"как бы", 1}}, anna.TopFillers)
+
+	assert.Equal(t, 0, report.Speakers[1].Fillers)
+	assert.Equal(t, 0.0, report.Speakers[1].FillerRate)
+	assert.Empty(t, report.Speakers[1].TopFillers)
+}
+
+func TestCompute_Empty(t *testing.T) {
+	report := Compute(nil)
+	assert.Equal(t, 0, report.Words)
+	assert.Equal(t, 0.0, report.FillerRate)
+	assert.Empty(t, report.Speakers)
+}
+
+func TestCompute_TalkTimeTurnsAndInterruptions(t *testing.T) {
+	report := Compute([]Segment{
+		{SpeakerID: "A", Start: 0, End: 10000, Text: "раз два три"},
+		{SpeakerID: "A", Start: 10000, End: 30000, Text: "четыре пять"},
+		// B перебивает A, пока тот ещё говорит
+		{SpeakerID: "B", Start: 28000, End: 40000, Text: "шесть"},
+		{SpeakerID: "A", Start: 40000, End: 50000, Text: "семь"},
+	})
+
+	assert.Equal(t, 50000, report.DurationMs)
+	assert.Equal(t, 52000, report.TalkTimeMs)
+	assert.Equal(t, 3, report.Turns)
+	assert.Equal(t, 1, report.Interruptions)
+
+	require.Len(t, report.Speakers, 2)
+	a, b := report.Speakers[0], report.Speakers[1]
+	assert.Equal(t, 40000, a.TalkTimeMs)
+	assert.Equal(t, 76.92, a.TalkShare)
+	assert.Equal(t, 2, a.Turns)
+	assert.Equal(t, 20000, a.AvgTurnMs)
+	assert.Equal(t, 30000, a.LongestMonologueMs)
+	// 6 слов за 40 секунд
+	assert.Equal(t, 9.0, a.WordsPerMinute)
+	assert.Equal(t, 0, a.Interruptions)
+
+	assert.Equal(t, 12000, b.TalkTimeMs)
+	assert.Equal(t, 1, b.Turns)
+	assert.Equal(t, 1, b.Interruptions)
+	assert.Equal(t, 5.0, b.WordsPerMinute)
+}
+
+func TestComputeProject_GroupsSpeakersByName(t *testing.T) {
+	report := ComputeProject([][]Segment{
+		{
+			{SpeakerID: "SPEAKER_00", SpeakerName: "Анна", Start: 0, End: 60000, Text: "a b c"},
+			{SpeakerID: "SPEAKER_01", Start: 60000, End: 90000, Text: "d"},
+		},
+		{
+			// В другой записи Анна распознана как SPEAKER_01
+			{SpeakerID: "SPEAKER_01", SpeakerName: "Анна", Start: 0, End: 30000, Text: "e f"},
+			{SpeakerID: "SPEAKER_00", Start: 30000, End: 40000, Text: "g"},
+		},
+	})
+
+	assert.Equal(t, 130000, report.DurationMs)
+	assert.Equal(t, 4, report.Turns)
+	require.Len(t, report.Speakers, 3)
+	anna := report.Speakers[0]
+	assert.Equal(t, "Анна", anna.SpeakerName)
+	assert.Equal(t, 90000, anna.TalkTimeMs)
+	assert.Equal(t, 2, anna.Turns)
+	assert.Equal(t, 5, anna.Words)
+	assert.Equal(t, 60000, anna.LongestMonologueMs)
+	assert.Equal(t, "SPEAKER_01", report.Speakers[1].SpeakerID)
+	assert.Equal(t, "SPEAKER_00", report.Speakers[2].SpeakerID)
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"loopa/backend/internal/analytics"
+	"loopa/backend/internal/session"
+)
+
+// handleGetAnalytics отдаёт статистику разговора задачи: время речи, реплики,
+// темп, перебивания и слова-паразиты по спикерам.
+func (s *Server) handleGetAnalytics(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var status string
+	err := s.db.QueryRow(
+		`SELECT t.status FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 WHERE t.id = ? AND f.user_session_id = ?`,
+		taskID, sessionID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load task")
+		return
+	}
+	if status != "готово" {
+		writeError(w, http.StatusConflict, "transcript not ready")
+		return
+	}
+
+	segments, err := s.loadAnalyticsSegments(taskID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load segments")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TaskAnalyticsResponse{
+		TaskID: taskID,
+		Report: analytics.Compute(segments),
+	})
+}
+
+// handleGetProjectAnalytics сводит статистику завершённых задач проекта.
+// Спикеры разных записей объединяются по имени.
+func (s *Server) handleGetProjectAnalytics(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var exists int
+	err := s.db.QueryRow(
+		`SELECT 1 FROM projects WHERE id = ? AND user_session_id = ?`,
+		projectID, sessionID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check project")
+		return
+	}
+
+	rows, err := s.db.Query(
+		`SELECT t.id FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 WHERE f.project_id = ? AND t.status = 'готово'
+		 ORDER BY t.created_at`,
+		projectID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load tasks")
+		return
+	}
+	var taskIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "failed to load tasks")
+			return
+		}
+		taskIDs = append(taskIDs, id)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load tasks")
+		return
+	}
+
+	byTask, err := s.queryAnalyticsSegments(analyticsProjectScope, projectID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load segments")
+		return
+	}
+	tasks := make([][]analytics.Segment, 0, len(taskIDs))
+	for _, id := range taskIDs {
+		tasks = append(tasks, byTask[id])
+	}
+
+	writeJSON(w, http.StatusOK, ProjectAnalyticsResponse{
+		ProjectID: projectID,
+		Tasks:     len(taskIDs),
+		Report:    analytics.ComputeProject(tasks),
+	})
+}
+
+// Условия выборки сегментов для статистики. Запросы соединяют
+// transcription_tasks t и files f, чтобы выбрать задачи проекта одним запросом.
+const (
+	analyticsTaskScope    = `t.id = ?`
+	analyticsProjectScope = `f.project_id = ? AND t.status = 'готово'`
+)
+
+// loadAnalyticsSegments читает сегменты задачи вместе с найденными словами-паразитами.
+func (s *Server) loadAnalyticsSegments(taskID string) ([]analytics.Segment, error) {
+	byTask, err := s.queryAnalyticsSegments(analyticsTaskScope, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return byTask[taskID], nil
+}
+
+// queryAnalyticsSegments читает сегменты и слова-паразиты всех задач, подходящих
+// под scope, двумя запросами и раскладывает их по ID задачи.
+func (s *Server) queryAnalyticsSegments(scope string, arg interface{}) (map[string][]analytics.Segment, error) {
+	rows, err := s.db.Query(
+		`SELECT s.task_id, s.id, s.speaker_id, s.speaker_name, s.start_time, s.end_time, s.text
+		 FROM transcription_segments s
+		 JOIN transcription_tasks t ON t.id = s.task_id
+		 JOIN files f ON f.id = t.file_id
+		 WHERE `+scope+`
+		 ORDER BY s.task_id, s.start_time`,
+		arg,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type segmentRef struct {
+		taskID string
+		index  int
+	}
+	byTask := map[string][]analytics.Segment{}
+	index := map[string]segmentRef{}
+	for rows.Next() {
+		var taskID, id string
+		var speakerID, speakerName sql.NullString
+		var seg analytics.Segment
+		if err := rows.Scan(&taskID, &id, &speakerID, &speakerName, &seg.Start, &seg.End, &seg.Text); err != nil {
+			return nil, err
+		}
+		seg.SpeakerID = speakerID.String
+		seg.SpeakerName = speakerName.String
+		index[id] = segmentRef{taskID: taskID, index: len(byTask[taskID])}
+		byTask[taskID] = append(byTask[taskID], seg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fillerRows, err := s.db.Query(
+		`SELECT sf.segment_id, sf.filler, sf.occurrences
+		 FROM segment_fillers sf
+		 JOIN transcription_tasks t ON t.id = sf.task_id
+		 JOIN files f ON f.id = t.file_id
+		 WHERE `+scope,
+		arg,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer fillerRows.Close()
+
+	for fillerRows.Next() {
+		var segmentID, filler string
+		var count int
+		if err := fillerRows.Scan(&segmentID, &filler, &count); err != nil {
+			return nil, err
+		}
+		ref, ok := index[segmentID]
+		if !ok {
+			continue
+		}
+		seg := &byTask[ref.taskID][ref.index]
+		if seg.Fillers == nil {
+			seg.Fillers = map[string]int{}
+		}
+		seg.Fillers[filler] += count
+	}
+	return byTask, fillerRows.Err()
+}
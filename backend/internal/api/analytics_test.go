@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/analytics"
+)
+
+// withURLParam добавляет параметр маршрута chi в запрос, вызываемый без роутера.
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	return withURLParams(r, key, value)
+}
+
+// withURLParams добавляет параметры маршрута парами «ключ, значение».
+func withURLParams(r *http.Request, params ...string) *http.Request {
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandleGetAnalytics(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT t.status FROM transcription_tasks").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("готово"))
+	mock.ExpectQuery("FROM transcription_segments").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "id", "speaker_id", "speaker_name", "start_time", "end_time", "text"}).
+			AddRow("task-1", "seg-1", "SPEAKER_00", "Анна", 0, 60000, "ну вот добрый день").
+			AddRow("task-1", "seg-2", "SPEAKER_01", nil, 60000, 70000, "здравствуйте"))
+	mock.ExpectQuery("FROM segment_fillers").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"segment_id", "filler", "occurrences"}).
+			AddRow("seg-1", "ну", 1).
+			AddRow("seg-1", "вот", 1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/tasks/task-1/analytics", nil), "id", "task-1")
+	server.handleGetAnalytics(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp TaskAnalyticsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "task-1", resp.TaskID)
+	assert.Equal(t, 5, resp.Words)
+	assert.Equal(t, 2, resp.Fillers)
+	require.Len(t, resp.Speakers, 2)
+	assert.Equal(t, "Анна", resp.Speakers[0].SpeakerName)
+	assert.Equal(t, 2, resp.Speakers[0].Fillers)
+	assert.Equal(t, 50.0, resp.Speakers[0].FillerRate)
+	assert.Equal(t, 2.0, resp.Speakers[0].FillersPerMinute)
+	assert.Equal(t, 0, resp.Speakers[1].Fillers)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleGetAnalytics_NotReady(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT t.status FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("в процессе"))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/tasks/task-1/analytics", nil), "id", "task-1")
+	server.handleGetAnalytics(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCleanExportSegments(t *testing.T) {
+	segments := cleanExportSegments([]exportSegment{
+		{Text: "ну добрый день", CleanedText: sql.NullString{String: "добрый день", Valid: true}},
+		{Text: "ну", CleanedText: sql.NullString{String: "", Valid: true}},
+		// Исправленный вручную сегмент остаётся как есть
+		{Text: "здравствуйте, вот"},
+	})
+
+	require.Len(t, segments, 2)
+	assert.Equal(t, "добрый день", segments[0].Text)
+	assert.Equal(t, "здравствуйте, вот", segments[1].Text)
+}
+
+func TestHandleGetProjectAnalytics(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM projects").
+		WithArgs("proj-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT t.id FROM transcription_tasks").
+		WithArgs("proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("task-1").AddRow("task-2"))
+	// Сегменты и паразиты всех задач проекта читаются одним запросом каждые
+	mock.ExpectQuery("FROM transcription_segments s").
+		WithArgs("proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "id", "speaker_id", "speaker_name", "start_time", "end_time", "text"}).
+			AddRow("task-1", "seg-1", "SPEAKER_00", "Анна", 0, 30000, "добрый день").
+			AddRow("task-1", "seg-2", "SPEAKER_01", nil, 30000, 40000, "здравствуйте").
+			AddRow("task-2", "seg-3", "SPEAKER_01", "Анна", 0, 20000, "ну продолжим"))
+	mock.ExpectQuery("FROM segment_fillers sf").
+		WithArgs("proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"segment_id", "filler", "occurrences"}).AddRow("seg-3", "ну", 1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/projects/proj-1/analytics", nil), "id", "proj-1")
+	server.handleGetProjectAnalytics(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp ProjectAnalyticsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "proj-1", resp.ProjectID)
+	assert.Equal(t, 2, resp.Tasks)
+	assert.Equal(t, 60000, resp.TalkTimeMs)
+	require.Len(t, resp.Speakers, 2)
+	assert.Equal(t, "Анна", resp.Speakers[0].SpeakerName)
+	assert.Equal(t, 50000, resp.Speakers[0].TalkTimeMs)
+	assert.Equal(t, 83.33, resp.Speakers[0].TalkShare)
+	assert.Equal(t, 1, resp.Speakers[0].Fillers)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleGetProjectAnalytics_NotFound(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM projects").WillReturnError(sql.ErrNoRows)
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/projects/proj-1/analytics", nil), "id", "proj-1")
+	server.handleGetProjectAnalytics(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleExport_JSONWithAnalytics(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT t.status, f.original_name, t.transcript_text").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "original_name", "transcript_text"}).
+			AddRow("готово", "встреча.mp3", "ну добрый день здравствуйте"))
+	mock.ExpectQuery("SELECT speaker_id, speaker_name, start_time, end_time, text, cleaned_text").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"speaker_id", "speaker_name", "start_time", "end_time", "text", "cleaned_text"}).
+			AddRow("SPEAKER_00", "Анна", 0, 20000, "ну добрый день", "добрый день").
+			AddRow("SPEAKER_01", nil, 20000, 30000, "здравствуйте", "здравствуйте"))
+	mock.ExpectQuery("SELECT s.task_id, s.id, s.speaker_id, s.speaker_name, s.start_time, s.end_time, s.text").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "id", "speaker_id", "speaker_name", "start_time", "end_time", "text"}).
+			AddRow("task-1", "seg-1", "SPEAKER_00", "Анна", 0, 20000, "ну добрый день").
+			AddRow("task-1", "seg-2", "SPEAKER_01", nil, 20000, 30000, "здравствуйте"))
+	mock.ExpectQuery("FROM segment_fillers").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"segment_id", "filler", "occurrences"}).AddRow("seg-1", "ну", 1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/tasks/task-1/export?format=json&removeFillers=true", nil), "id", "task-1")
+	server.handleExport(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), ".json")
+	var resp ExportJSONResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "добрый день здравствуйте", resp.Transcript)
+	require.Len(t, resp.Segments, 2)
+	require.NotNil(t, resp.Segments[0].SpeakerName)
+	assert.Equal(t, "Анна", *resp.Segments[0].SpeakerName)
+	assert.Nil(t, resp.Segments[1].SpeakerName)
+	// Статистика считается по исходному тексту, вместе с паразитами
+	assert.Equal(t, 1, resp.Analytics.Fillers)
+	require.Len(t, resp.Analytics.Speakers, 2)
+	assert.Equal(t, 20000, resp.Analytics.Speakers[0].TalkTimeMs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleExport_RemoveFillersWithoutSegments(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT t.status, f.original_name, t.transcript_text").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "original_name", "transcript_text"}).
+			AddRow("готово", "встреча.mp3", "ну добрый день"))
+	mock.ExpectQuery("SELECT speaker_id, speaker_name, start_time, end_time, text, cleaned_text").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"speaker_id", "speaker_name", "start_time", "end_time", "text", "cleaned_text"}))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/tasks/task-1/export?format=txt&removeFillers=true", nil), "id", "task-1")
+	server.handleExport(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "cleaned transcript not available")
+	assert.NotContains(t, rec.Body.String(), "ну добрый день")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBuildExportJSON_RemoveFillersOnlyFillers(t *testing.T) {
+	resp := buildExportJSON("task-1", "встреча.mp3", "ну", nil, true, analytics.Report{})
+
+	assert.Empty(t, resp.Transcript)
+	assert.Empty(t, resp.Segments)
+}
+
+func TestBuildAnalyticsText(t *testing.T) {
+	text := buildAnalyticsText(analytics.Report{
+		DurationMs: 90000, Turns: 3, Interruptions: 1,
+		Speakers: []analytics.SpeakerStats{
+			{SpeakerID: "SPEAKER_00", SpeakerName: "Анна", TalkTimeMs: 60000, TalkShare: 66.67, Turns: 2},
+			{SpeakerID: "SPEAKER_01", TalkTimeMs: 30000, TalkShare: 33.33, Turns: 1},
+		},
+	})
+
+	assert.Contains(t, text, "Статистика разговора")
+	assert.Contains(t, text, "Длительность: "+formatMs(90000))
+	assert.Contains(t, text, "Анна: "+formatMs(60000)+" (66.7%)")
+	assert.Contains(t, text, "SPEAKER_01: ")
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"os"
 
 	"github.com/go-chi/chi/v5"
 
+	"loopa/backend/internal/mlclient"
 	"loopa/backend/internal/session"
 )
 
@@ -25,14 +28,18 @@ func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 	}
 	defer tx.Rollback()
 
-	var storagePath string
+	var (
+		storagePath  string
+		originalPath sql.NullString
+		mlJobID      sql.NullString
+	)
 	err = tx.QueryRow(
-		`SELECT f.storage_path
+		`SELECT f.storage_path, f.original_path, t.ml_job_id
 		 FROM transcription_tasks t
 		 JOIN files f ON f.id = t.file_id
 		 WHERE t.id = ? AND f.user_session_id = ?`,
 		taskID, sessionID,
-	).Scan(&storagePath)
+	).Scan(&storagePath, &originalPath, &mlJobID)
 	if err == sql.ErrNoRows {
 		writeError(w, http.StatusNotFound, "task not found")
 		return
@@ -62,6 +69,16 @@ func (s *Server) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if mlJobID.Valid && s.ml != nil {
+		// Распознавание удалённой задачи не нужно: освобождаем GPU
+		if err := s.ml.CancelJob(r.Context(), mlJobID.String); err != nil && !errors.Is(err, mlclient.ErrJobNotFound) {
+			log.Printf("task %s: failed to cancel ML job %s: %v", taskID, mlJobID.String, err)
+		}
+	}
+
 	_ = os.Remove(storagePath)
+	if originalPath.Valid {
+		_ = os.Remove(originalPath.String)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
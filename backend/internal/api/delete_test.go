@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/mlclient"
+)
+
+func TestHandleDeleteTask_CancelsMLJob(t *testing.T) {
+	var cancels int32
+	ml := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete && r.URL.Path == "/jobs/job-1" {
+			atomic.AddInt32(&cancels, 1)
+		}
+		w.Write([]byte(`{"job_id":"job-1","status":"cancelled"}`))
+	}))
+	defer ml.Close()
+
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+	server.ml = mlclient.New(ml.URL)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT f.storage_path, f.original_path, t.ml_job_id").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_path", "original_path", "ml_job_id"}).
+			AddRow("/nonexistent/a.ogg", nil, "job-1"))
+	mock.ExpectExec("DELETE FROM transcription_tasks WHERE id = \\?").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE f FROM files f").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := withURLParam(httptest.NewRequest(http.MethodDelete, "/api/tasks/task-1", nil), "id", "task-1")
+	w := httptest.NewRecorder()
+	server.handleDeleteTask(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cancels))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,24 +9,30 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"loopa/backend/internal/analytics"
 	"loopa/backend/internal/exporter"
 	"loopa/backend/internal/session"
 )
 
 type exportSegment struct {
+	SpeakerID   sql.NullString
 	SpeakerName sql.NullString
 	StartTime   int
 	EndTime     int
 	Text        string
+	// CleanedText — текст без слов-паразитов; NULL, если не определён
+	// или сегмент исправлен вручную.
+	CleanedText sql.NullString
 }
 
 func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	taskID := chi.URLParam(r, "id")
 	format := strings.ToLower(r.URL.Query().Get("format"))
-	if format != "txt" && format != "docx" {
+	if format != "txt" && format != "docx" && format != "json" {
 		writeError(w, http.StatusBadRequest, "invalid format")
 		return
 	}
+	removeFillers := r.URL.Query().Get("removeFillers") == "true"
 
 	sessionID := session.GetSessionID(r)
 	var (
@@ -58,8 +64,28 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 	segments := s.loadExportSegments(taskID)
 
 	// Формируем текст с учётом спикеров и таймкодов
+	if removeFillers {
+		// Текст без паразитов хранится только в сегментах: без них
+		// очистить транскрипцию нечем
+		if len(segments) == 0 {
+			writeError(w, http.StatusConflict, "cleaned transcript not available")
+			return
+		}
+		segments = cleanExportSegments(segments)
+	}
 	exportText := buildExportText(segments, transcript.String)
 
+	// В DOCX и JSON добавляется статистика разговора
+	var report analytics.Report
+	if format != "txt" {
+		analyticsSegments, err := s.loadAnalyticsSegments(taskID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to load segments")
+			return
+		}
+		report = analytics.Compute(analyticsSegments)
+	}
+
 	filename := sanitizeDownloadName(originalName) + "." + format
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
 
@@ -69,6 +95,14 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format == "json" {
+		writeJSON(w, http.StatusOK, buildExportJSON(taskID, originalName, transcript.String, segments, removeFillers, report))
+		return
+	}
+
+	if len(report.Speakers) > 0 {
+		exportText += "\n\n" + buildAnalyticsText(report)
+	}
 	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
 	if err := exporter.WriteDocx(w, exportText); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to generate docx")
@@ -78,7 +112,7 @@ func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) loadExportSegments(taskID string) []exportSegment {
 	rows, err := s.db.Query(
-		`SELECT speaker_name, start_time, end_time, text
+		`SELECT speaker_id, speaker_name, start_time, end_time, text, cleaned_text
 		 FROM transcription_segments
 		 WHERE task_id = ?
 		 ORDER BY start_time`,
@@ -92,7 +126,7 @@ func (s *Server) loadExportSegments(taskID string) []exportSegment {
 	var segments []exportSegment
 	for rows.Next() {
 		var seg exportSegment
-		if err := rows.Scan(&seg.SpeakerName, &seg.StartTime, &seg.EndTime, &seg.Text); err != nil {
+		if err := rows.Scan(&seg.SpeakerID, &seg.SpeakerName, &seg.StartTime, &seg.EndTime, &seg.Text, &seg.CleanedText); err != nil {
 			return nil
 		}
 		segments = append(segments, seg)
@@ -100,6 +134,22 @@ func (s *Server) loadExportSegments(taskID string) []exportSegment {
 	return segments
 }
 
+// cleanExportSegments заменяет текст сегментов текстом без слов-паразитов.
+// Сегменты, состоявшие только из паразитов, пропускаются.
+func cleanExportSegments(segments []exportSegment) []exportSegment {
+	cleaned := make([]exportSegment, 0, len(segments))
+	for _, seg := range segments {
+		if seg.CleanedText.Valid {
+			if strings.TrimSpace(seg.CleanedText.String) == "" {
+				continue
+			}
+			seg.Text = seg.CleanedText.String
+		}
+		cleaned = append(cleaned, seg)
+	}
+	return cleaned
+}
+
 func buildExportText(segments []exportSegment, fallbackText string) string {
 	if len(segments) == 0 {
 		return fallbackText
@@ -118,6 +168,60 @@ func buildExportText(segments []exportSegment, fallbackText string) string {
 	return strings.TrimSpace(sb.String())
 }
 
+// buildExportJSON собирает экспорт в JSON: сегменты и статистику разговора.
+// Без сегментов в segments пусто, текст — в transcript.
+func buildExportJSON(taskID, originalName, transcript string, segments []exportSegment,
+	removeFillers bool, report analytics.Report) ExportJSONResponse {
+	resp := ExportJSONResponse{
+		TaskID:       taskID,
+		OriginalName: originalName,
+		Transcript:   transcript,
+		Segments:     make([]ExportJSONSegment, 0, len(segments)),
+		Analytics:    report,
+	}
+	texts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		item := ExportJSONSegment{StartTime: seg.StartTime, EndTime: seg.EndTime, Text: seg.Text}
+		if seg.SpeakerID.Valid {
+			speakerID := seg.SpeakerID.String
+			item.SpeakerID = &speakerID
+		}
+		if seg.SpeakerName.Valid {
+			speakerName := seg.SpeakerName.String
+			item.SpeakerName = &speakerName
+		}
+		resp.Segments = append(resp.Segments, item)
+		texts = append(texts, seg.Text)
+	}
+	if removeFillers {
+		resp.Transcript = strings.Join(texts, " ")
+	}
+	return resp
+}
+
+// buildAnalyticsText описывает статистику разговора для DOCX.
+func buildAnalyticsText(report analytics.Report) string {
+	var sb strings.Builder
+	sb.WriteString("Статистика разговора\n")
+	sb.WriteString(fmt.Sprintf("Длительность: %s, реплик: %d, перебиваний: %d\n",
+		formatMs(report.DurationMs), report.Turns, report.Interruptions))
+	for _, sp := range report.Speakers {
+		name := sp.SpeakerName
+		if name == "" {
+			name = sp.SpeakerID
+		}
+		if name == "" {
+			name = "Спикер"
+		}
+		sb.WriteString(fmt.Sprintf(
+			"\n%s: %s (%.1f%%), реплик %d, в среднем %s, самый длинный монолог %s, %.0f слов/мин, перебиваний %d, слов-паразитов %d (%.1f на 100 слов)",
+			name, formatMs(sp.TalkTimeMs), sp.TalkShare, sp.Turns, formatMs(sp.AvgTurnMs),
+			formatMs(sp.LongestMonologueMs), sp.WordsPerMinute, sp.Interruptions, sp.Fillers, sp.FillerRate,
+		))
+	}
+	return sb.String()
+}
+
 func formatTimeRange(startMs, endMs int) string {
 	return fmt.Sprintf("%s — %s", formatMs(startMs), formatMs(endMs))
 }
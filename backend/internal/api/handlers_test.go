@@ -70,60 +70,6 @@ func TestWriteJSON_EmptySlice(t *testing.T) {
 	assert.Equal(t, "[]\n", w.Body.String())
 }
 
-func TestIsAllowedFile(t *testing.T) {
-	tests := []struct {
-		name     string
-		filename string
-		mimeType string
-		expected bool
-	}{
-		{"MP3 extension", "audio.mp3", "", true},
-		{"WAV extension", "audio.wav", "", true},
-		{"MP4 extension", "video.mp4", "", true},
-		{"MOV extension", "video.mov", "", true},
-		{"MP3 uppercase", "audio.MP3", "", true},
-		{"WAV uppercase", "audio.WAV", "", true},
-		{"audio/* MIME type", "unknown", "audio/ogg", true},
-		{"video/* MIME type", "unknown", "video/webm", true},
-		{"PDF not allowed", "document.pdf", "", false},
-		{"TXT not allowed", "text.txt", "", false},
-		{"Image not allowed", "photo.jpg", "image/jpeg", false},
-		{"Empty filename", "", "", false},
-		{"No extension with audio MIME", "noext", "audio/mp3", true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := isAllowedFile(tt.filename, tt.mimeType)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
-func TestIsVideoFile(t *testing.T) {
-	tests := []struct {
-		name     string
-		filename string
-		mimeType string
-		expected bool
-	}{
-		{"MP4 extension", "video.mp4", "", true},
-		{"MOV extension", "video.mov", "", true},
-		{"MP4 uppercase", "video.MP4", "", true},
-		{"video/* MIME type", "unknown", "video/webm", true},
-		{"audio/mp3 is not video", "audio.mp3", "audio/mp3", false},
-		{"WAV is not video", "audio.wav", "", false},
-		{"MP3 is not video", "audio.mp3", "", false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := isVideoFile(tt.filename, tt.mimeType)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
-
 func TestSanitizeDownloadName(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// providerHealthTTL — после этого срока без проверок состояние провайдера
+// неизвестно: скорее всего, worker остановлен.
+const providerHealthTTL = 5 * time.Minute
+
+// handleHealth возвращает состояние базы данных и провайдеров распознавания,
+// которое записывает worker. Код 503 — только если недоступна база.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	resp := HealthResponse{Status: "ok", Database: "ok", Providers: []ProviderHealth{}}
+	if err := s.db.PingContext(ctx); err != nil {
+		resp.Status = "down"
+		resp.Database = "down"
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT provider, status, consecutive_failures, last_error, checked_at, last_success_at
+		 FROM provider_health
+		 ORDER BY provider`,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load provider health")
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	for rows.Next() {
+		var p ProviderHealth
+		var lastError sql.NullString
+		var checkedAt time.Time
+		var lastSuccess sql.NullTime
+		if err := rows.Scan(&p.Name, &p.Status, &p.ConsecutiveFailures, &lastError, &checkedAt, &lastSuccess); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to parse provider health")
+			return
+		}
+		if now.Sub(checkedAt) > providerHealthTTL {
+			p.Status = "unknown"
+		}
+		if lastError.Valid {
+			p.LastError = &lastError.String
+		}
+		p.CheckedAt = checkedAt.UTC().Format(time.RFC3339)
+		if lastSuccess.Valid {
+			formatted := lastSuccess.Time.UTC().Format(time.RFC3339)
+			p.LastSuccessAt = &formatted
+		}
+		if p.Status != "ok" {
+			resp.Status = "degraded"
+		}
+		resp.Providers = append(resp.Providers, p)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/config"
+)
+
+func TestHandleHealth(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewServer(db, config.Config{})
+
+	now := time.Now()
+	mock.ExpectPing()
+	mock.ExpectQuery("FROM provider_health").WillReturnRows(
+		sqlmock.NewRows([]string{"provider", "status", "consecutive_failures", "last_error", "checked_at", "last_success_at"}).
+			AddRow("ml_service", "down", 3, "ml service unhealthy: status 503", now, now.Add(-time.Hour)).
+			AddRow("yandex_speechkit", "ok", 0, nil, now.Add(-time.Hour), now.Add(-time.Hour)),
+	)
+
+	rec := httptest.NewRecorder()
+	server.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "degraded", resp.Status)
+	assert.Equal(t, "ok", resp.Database)
+	require.Len(t, resp.Providers, 2)
+	assert.Equal(t, "down", resp.Providers[0].Status)
+	assert.Equal(t, 3, resp.Providers[0].ConsecutiveFailures)
+	require.NotNil(t, resp.Providers[0].LastError)
+	// Давно не проверявшийся провайдер — состояние неизвестно
+	assert.Equal(t, "unknown", resp.Providers[1].Status)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleHealth_DatabaseDown(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	server := NewServer(db, config.Config{})
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+	rec := httptest.NewRecorder()
+	server.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "down", resp.Database)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"database/sql"
 	"net/http"
 	"time"
 
@@ -10,7 +11,7 @@ import (
 func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	sessionID := session.GetSessionID(r)
 	rows, err := s.db.Query(
-		`SELECT t.id, f.original_name, t.status, f.uploaded_at
+		`SELECT t.id, f.original_name, t.status, f.uploaded_at, f.duration_ms
 		 FROM transcription_tasks t
 		 JOIN files f ON f.id = t.file_id
 		 WHERE f.user_session_id = ?
@@ -28,11 +29,16 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var item HistoryItem
 		var uploaded time.Time
-		if err := rows.Scan(&item.ID, &item.OriginalName, &item.Status, &uploaded); err != nil {
+		var durationMs sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.OriginalName, &item.Status, &uploaded, &durationMs); err != nil {
 			writeError(w, http.StatusInternalServerError, "failed to parse history")
 			return
 		}
 		item.UploadedAt = uploaded.UTC().Format(time.RFC3339)
+		if durationMs.Valid {
+			seconds := float64(durationMs.Int64) / 1000
+			item.DurationSeconds = &seconds
+		}
 		items = append(items, item)
 	}
 	writeJSON(w, http.StatusOK, items)
@@ -9,6 +9,8 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"loopa/backend/internal/glossary"
+	"loopa/backend/internal/media"
 	"loopa/backend/internal/session"
 )
 
@@ -23,14 +25,34 @@ func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var preprocessing interface{}
+	if req.Preprocessing != nil {
+		if err := req.Preprocessing.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(req.Preprocessing)
+		preprocessing = string(data)
+	}
+
+	var vocabulary interface{}
+	if req.Glossary != nil {
+		if err := req.Glossary.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		data, _ := json.Marshal(req.Glossary)
+		vocabulary = string(data)
+	}
+
 	sessionID := session.GetSessionID(r)
 	projectID := uuid.New().String()
 	now := time.Now().UTC()
 
 	_, err := s.db.Exec(
-		`INSERT INTO projects (id, name, description, status, user_session_id, created_at)
-		 VALUES (?, ?, ?, 'active', ?, ?)`,
-		projectID, req.Name, req.Description, sessionID, now,
+		`INSERT INTO projects (id, name, description, status, preprocessing, glossary, user_session_id, created_at)
+		 VALUES (?, ?, ?, 'active', ?, ?, ?, ?)`,
+		projectID, req.Name, req.Description, preprocessing, vocabulary, sessionID, now,
 	)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create project")
@@ -38,12 +60,14 @@ func (s *Server) handleCreateProject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := ProjectResponse{
-		ID:          projectID,
-		Name:        req.Name,
-		Description: req.Description,
-		Status:      "active",
-		CreatedAt:   now.Format(time.RFC3339),
-		FileCount:   0,
+		ID:            projectID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Status:        "active",
+		CreatedAt:     now.Format(time.RFC3339),
+		FileCount:     0,
+		Preprocessing: req.Preprocessing,
+		Glossary:      req.Glossary,
 	}
 	writeJSON(w, http.StatusCreated, resp)
 }
@@ -52,7 +76,7 @@ func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
 	sessionID := session.GetSessionID(r)
 
 	rows, err := s.db.Query(
-		`SELECT p.id, p.name, p.description, p.status, p.created_at,
+		`SELECT p.id, p.name, p.description, p.status, p.created_at, p.preprocessing, p.glossary,
 		        (SELECT COUNT(*) FROM files f WHERE f.project_id = p.id) as file_count
 		 FROM projects p
 		 WHERE p.user_session_id = ?
@@ -68,15 +92,17 @@ func (s *Server) handleListProjects(w http.ResponseWriter, r *http.Request) {
 	items := []ProjectResponse{}
 	for rows.Next() {
 		var item ProjectResponse
-		var desc sql.NullString
+		var desc, preprocessing, vocabulary sql.NullString
 		var createdAt time.Time
-		if err := rows.Scan(&item.ID, &item.Name, &desc, &item.Status, &createdAt, &item.FileCount); err != nil {
+		if err := rows.Scan(&item.ID, &item.Name, &desc, &item.Status, &createdAt, &preprocessing, &vocabulary, &item.FileCount); err != nil {
 			writeError(w, http.StatusInternalServerError, "failed to parse projects")
 			return
 		}
 		if desc.Valid {
 			item.Description = &desc.String
 		}
+		item.Preprocessing = parsePreprocessing(preprocessing)
+		item.Glossary = parseGlossary(vocabulary)
 		item.CreatedAt = createdAt.UTC().Format(time.RFC3339)
 		items = append(items, item)
 	}
@@ -88,16 +114,16 @@ func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
 	sessionID := session.GetSessionID(r)
 
 	var item ProjectResponse
-	var desc sql.NullString
+	var desc, preprocessing, vocabulary sql.NullString
 	var createdAt time.Time
 
 	err := s.db.QueryRow(
-		`SELECT p.id, p.name, p.description, p.status, p.created_at,
+		`SELECT p.id, p.name, p.description, p.status, p.created_at, p.preprocessing, p.glossary,
 		        (SELECT COUNT(*) FROM files f WHERE f.project_id = p.id) as file_count
 		 FROM projects p
 		 WHERE p.id = ? AND p.user_session_id = ?`,
 		projectID, sessionID,
-	).Scan(&item.ID, &item.Name, &desc, &item.Status, &createdAt, &item.FileCount)
+	).Scan(&item.ID, &item.Name, &desc, &item.Status, &createdAt, &preprocessing, &vocabulary, &item.FileCount)
 	if err == sql.ErrNoRows {
 		writeError(w, http.StatusNotFound, "project not found")
 		return
@@ -110,10 +136,111 @@ func (s *Server) handleGetProject(w http.ResponseWriter, r *http.Request) {
 	if desc.Valid {
 		item.Description = &desc.String
 	}
+	item.Preprocessing = parsePreprocessing(preprocessing)
+	item.Glossary = parseGlossary(vocabulary)
 	item.CreatedAt = createdAt.UTC().Format(time.RFC3339)
 	writeJSON(w, http.StatusOK, item)
 }
 
+// handleUpdateProjectPreprocessing задаёт предобработку аудио для новых задач проекта.
+// Пустое тело ({}) отключает фильтры.
+func (s *Server) handleUpdateProjectPreprocessing(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var req media.PreprocessOptions
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	data, _ := json.Marshal(req)
+
+	res, err := s.db.Exec(
+		`UPDATE projects SET preprocessing = ?, updated_at = ?
+		 WHERE id = ? AND user_session_id = ?`,
+		string(data), time.Now().UTC(), projectID, sessionID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update project")
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
+
+// parsePreprocessing разбирает JSON-колонку preprocessing.
+func parsePreprocessing(value sql.NullString) *media.PreprocessOptions {
+	if !value.Valid {
+		return nil
+	}
+	var opts media.PreprocessOptions
+	if err := json.Unmarshal([]byte(value.String), &opts); err != nil {
+		return nil
+	}
+	return &opts
+}
+
+// handleUpdateProjectGlossary задаёт словарь проекта для новых задач:
+// термины подсказываются провайдеру, замены применяются к сегментам.
+// Пустое тело ({}) удаляет словарь.
+func (s *Server) handleUpdateProjectGlossary(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var req glossary.Glossary
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var vocabulary interface{}
+	if !req.IsZero() {
+		data, _ := json.Marshal(req)
+		vocabulary = string(data)
+	}
+
+	res, err := s.db.Exec(
+		`UPDATE projects SET glossary = ?, updated_at = ?
+		 WHERE id = ? AND user_session_id = ?`,
+		vocabulary, time.Now().UTC(), projectID, sessionID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update project")
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
+
+// parseGlossary разбирает JSON-колонку glossary.
+func parseGlossary(value sql.NullString) *glossary.Glossary {
+	if !value.Valid {
+		return nil
+	}
+	var g glossary.Glossary
+	if err := json.Unmarshal([]byte(value.String), &g); err != nil {
+		return nil
+	}
+	return &g
+}
+
 // handleListProjectFiles возвращает файлы проекта с их задачами.
 func (s *Server) handleListProjectFiles(w http.ResponseWriter, r *http.Request) {
 	projectID := chi.URLParam(r, "id")
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/glossary"
+)
+
+func TestHandleUpdateProjectGlossary(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE projects SET glossary = \\?").
+		WithArgs(`{"terms":["Loopa"],"replacements":[{"from":"лупа","to":"Loopa"}]}`, sqlmock.AnyArg(), "proj-1", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/projects/proj-1/glossary",
+		strings.NewReader(`{"terms":["Loopa"],"replacements":[{"from":"лупа","to":"Loopa"}]}`)), "id", "proj-1")
+	server.handleUpdateProjectGlossary(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp glossary.Glossary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []string{"Loopa"}, resp.Terms)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleUpdateProjectGlossary_EmptyRemovesGlossary(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE projects SET glossary = \\?").
+		WithArgs(nil, sqlmock.AnyArg(), "proj-1", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/projects/proj-1/glossary",
+		strings.NewReader(`{}`)), "id", "proj-1")
+	server.handleUpdateProjectGlossary(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleUpdateProjectGlossary_Invalid(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/projects/proj-1/glossary",
+		strings.NewReader(`{"replacements":[{"from":" ","to":"Loopa"}]}`)), "id", "proj-1")
+	server.handleUpdateProjectGlossary(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "replacement from must not be empty")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"database/sql"
 	"encoding/json"
+	"log"
 	"net/http"
 	"time"
 
@@ -97,9 +98,18 @@ func (s *Server) handleUpdateSegment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := s.db.Exec(
+	tx, err := s.db.Begin()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update segment")
+		return
+	}
+	defer tx.Rollback()
+
+	// Найденные паразиты относятся к прежнему тексту: после ручной правки
+	// сегмент не учитывается в статистике паразитов
+	res, err := tx.Exec(
 		`UPDATE transcription_segments
-		 SET text = ?, is_corrected = 1
+		 SET text = ?, is_corrected = 1, cleaned_text = NULL, has_fillers = 0
 		 WHERE id = ? AND task_id = ?`,
 		req.Text, segmentID, taskID,
 	)
@@ -112,6 +122,17 @@ func (s *Server) handleUpdateSegment(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "segment not found")
 		return
 	}
+	if _, err := tx.Exec(
+		`DELETE FROM segment_fillers WHERE segment_id = ? AND task_id = ?`,
+		segmentID, taskID,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update segment")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update segment")
+		return
+	}
 
 	// Обновляем общий текст транскрипции
 	s.rebuildTranscriptText(taskID)
@@ -151,7 +172,7 @@ func (s *Server) handleUpdateSpeaker(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = s.db.Exec(
+	res, err := s.db.Exec(
 		`UPDATE transcription_segments
 		 SET speaker_name = ?
 		 WHERE task_id = ? AND speaker_id = ?`,
@@ -162,6 +183,14 @@ func (s *Server) handleUpdateSpeaker(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Спикер попадает в справочник проекта, а его голос — в профиль:
+	// в следующих записях спикер будет назван сам
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		if err := s.assignSpeakerProfile(taskID, speakerID, req.Name); err != nil {
+			log.Printf("task %s: failed to update speaker profile of %s: %v", taskID, speakerID, err)
+		}
+	}
+
 	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
@@ -190,6 +219,35 @@ func (s *Server) handleGetAudio(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, storagePath)
 }
 
+// handleGetVideo отдаёт исходное видео, если оно сохранено после извлечения аудио.
+func (s *Server) handleGetVideo(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var originalPath sql.NullString
+	err := s.db.QueryRow(
+		`SELECT f.original_path FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 WHERE t.id = ? AND f.user_session_id = ?`,
+		taskID, sessionID,
+	).Scan(&originalPath)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load task")
+		return
+	}
+	if !originalPath.Valid {
+		writeError(w, http.StatusNotFound, "video not available")
+		return
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	http.ServeFile(w, r, originalPath.String)
+}
+
 // rebuildTranscriptText пересобирает полный текст транскрипции из сегментов.
 func (s *Server) rebuildTranscriptText(taskID string) {
 	rows, err := s.db.Query(
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleUpdateSegment_DropsFillers(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM transcription_tasks").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("SET text = \\?, is_corrected = 1, cleaned_text = NULL, has_fillers = 0").
+		WithArgs("Добрый день", "seg-1", "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM segment_fillers WHERE segment_id = \\? AND task_id = \\?").
+		WithArgs("seg-1", "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT text FROM transcription_segments").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"text"}).AddRow("Добрый день"))
+	mock.ExpectExec("UPDATE transcription_tasks SET transcript_text = \\?").
+		WithArgs("Добрый день", "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transcription_tasks SET completed_at = \\?").
+		WithArgs(sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodPut, "/api/tasks/task-1/segments/seg-1",
+		strings.NewReader(`{"text":"Добрый день"}`)), "id", "task-1", "segId", "seg-1")
+	server.handleUpdateSegment(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleUpdateSegment_NotFound(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_segments").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodPut, "/api/tasks/task-1/segments/missing",
+		strings.NewReader(`{"text":"x"}`)), "id", "task-1", "segId", "missing")
+	server.handleUpdateSegment(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -8,16 +8,23 @@ import (
 	"github.com/rs/cors"
 
 	"loopa/backend/internal/config"
+	"loopa/backend/internal/mlclient"
 	"loopa/backend/internal/session"
 )
 
 type Server struct {
 	db     *sql.DB
 	config config.Config
+	// ml отменяет задачи ML-сервиса удаляемых задач; nil — ML-сервис не настроен
+	ml *mlclient.Client
 }
 
 func NewServer(db *sql.DB, cfg config.Config) *Server {
-	return &Server{db: db, config: cfg}
+	s := &Server{db: db, config: cfg}
+	if cfg.MLServiceURL != "" {
+		s.ml = mlclient.New(cfg.MLServiceURL)
+	}
+	return s
 }
 
 func (s *Server) Router() http.Handler {
@@ -32,13 +39,17 @@ func (s *Server) Router() http.Handler {
 	})
 
 	router.Route("/api", func(r chi.Router) {
+		r.Get("/health", s.handleHealth)
 		r.Post("/uploads", s.handleUpload)
 		r.Get("/tasks/{id}", s.handleGetTask)
 		r.Get("/tasks/{id}/export", s.handleExport)
+		r.Get("/tasks/{id}/analytics", s.handleGetAnalytics)
 		r.Get("/tasks/{id}/segments", s.handleGetSegments)
 		r.Put("/tasks/{id}/segments/{segId}", s.handleUpdateSegment)
 		r.Put("/tasks/{id}/speakers/{speakerId}", s.handleUpdateSpeaker)
+		r.Post("/tasks/{id}/speakers/merge", s.handleMergeSpeakers)
 		r.Get("/tasks/{id}/audio", s.handleGetAudio)
+		r.Get("/tasks/{id}/video", s.handleGetVideo)
 		r.Get("/history", s.handleHistory)
 		r.Delete("/tasks/{id}", s.handleDeleteTask)
 
@@ -46,6 +57,14 @@ func (s *Server) Router() http.Handler {
 		r.Get("/projects", s.handleListProjects)
 		r.Get("/projects/{id}", s.handleGetProject)
 		r.Get("/projects/{id}/files", s.handleListProjectFiles)
+		r.Get("/projects/{id}/analytics", s.handleGetProjectAnalytics)
+		r.Put("/projects/{id}/preprocessing", s.handleUpdateProjectPreprocessing)
+		r.Put("/projects/{id}/glossary", s.handleUpdateProjectGlossary)
+		r.Get("/projects/{id}/speakers", s.handleListProjectSpeakers)
+		r.Put("/projects/{id}/speakers/rename", s.handleRenameProjectSpeaker)
+		r.Get("/projects/{id}/speaker-profiles", s.handleListSpeakerProfiles)
+		r.Put("/projects/{id}/speaker-profiles/{profileId}", s.handleRenameSpeakerProfile)
+		r.Delete("/projects/{id}/speaker-profiles/{profileId}", s.handleDeleteSpeakerProfile)
 		r.Delete("/projects/{id}", s.handleDeleteProject)
 	})
 
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"loopa/backend/internal/session"
+	"loopa/backend/internal/voiceprint"
+)
+
+// handleListProjectSpeakers возвращает справочник спикеров проекта: спикеров
+// всех задач проекта и людей (профили), к которым они отнесены.
+func (s *Server) handleListProjectSpeakers(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var exists int
+	err := s.db.QueryRow(
+		`SELECT 1 FROM projects WHERE id = ? AND user_session_id = ?`,
+		projectID, sessionID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check project")
+		return
+	}
+
+	rows, err := s.db.Query(
+		`SELECT t.id, f.original_name, s.speaker_id, MAX(s.speaker_name),
+		        COUNT(*), SUM(s.end_time - s.start_time),
+		        ts.profile_id, sp.name, ts.similarity
+		 FROM transcription_segments s
+		 JOIN transcription_tasks t ON t.id = s.task_id
+		 JOIN files f ON f.id = t.file_id
+		 LEFT JOIN task_speakers ts ON ts.task_id = s.task_id AND ts.speaker_id = s.speaker_id
+		 LEFT JOIN speaker_profiles sp ON sp.id = ts.profile_id
+		 WHERE f.project_id = ? AND s.speaker_id IS NOT NULL
+		 GROUP BY t.id, f.original_name, s.speaker_id, ts.profile_id, sp.name, ts.similarity
+		 ORDER BY t.created_at, s.speaker_id`,
+		projectID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load speakers")
+		return
+	}
+	defer rows.Close()
+
+	items := []ProjectSpeakerResponse{}
+	for rows.Next() {
+		var item ProjectSpeakerResponse
+		var speakerName, profileID, profileName sql.NullString
+		var similarity sql.NullFloat64
+		if err := rows.Scan(&item.TaskID, &item.OriginalName, &item.SpeakerID, &speakerName,
+			&item.Segments, &item.TalkTimeMs, &profileID, &profileName, &similarity); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to parse speakers")
+			return
+		}
+		if speakerName.Valid {
+			item.SpeakerName = &speakerName.String
+		}
+		if profileID.Valid {
+			item.ProfileID = &profileID.String
+			item.ProfileName = &profileName.String
+		}
+		if similarity.Valid {
+			item.Similarity = &similarity.Float64
+		}
+		items = append(items, item)
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// handleMergeSpeakers объединяет спикеров задачи, которых диаризация ошибочно
+// разделила: сегменты from относятся к into. Имя into сохраняется, а если его
+// нет — берётся имя from. Объединение запоминается и применяется заново при
+// перестроении сегментов.
+func (s *Server) handleMergeSpeakers(w http.ResponseWriter, r *http.Request) {
+	taskID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var req MergeSpeakersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.From == "" || req.Into == "" {
+		writeError(w, http.StatusBadRequest, "from and into are required")
+		return
+	}
+	if req.From == req.Into {
+		writeError(w, http.StatusBadRequest, "cannot merge speaker into itself")
+		return
+	}
+
+	var exists int
+	err := s.db.QueryRow(
+		`SELECT 1 FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 WHERE t.id = ? AND f.user_session_id = ?`,
+		taskID, sessionID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify task")
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	defer tx.Rollback()
+
+	fromName, fromFound, err := segmentSpeakerName(tx, taskID, req.From)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	intoName, intoFound, err := segmentSpeakerName(tx, taskID, req.Into)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	if !fromFound || !intoFound {
+		writeError(w, http.StatusNotFound, "speaker not found")
+		return
+	}
+	name := intoName
+	if !name.Valid {
+		name = fromName
+	}
+
+	res, err := tx.Exec(
+		`UPDATE transcription_segments SET speaker_id = ?, speaker_name = ?
+		 WHERE task_id = ? AND speaker_id IN (?, ?)`,
+		req.Into, name, taskID, req.From, req.Into,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	if err := mergeTaskSpeakers(tx, taskID, req.From, req.Into); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	// Спикеры, объединённые с from раньше, теперь тоже относятся к into
+	if _, err := tx.Exec(
+		`UPDATE speaker_merges SET into_speaker = ? WHERE task_id = ? AND into_speaker = ?`,
+		req.Into, taskID, req.From,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO speaker_merges (task_id, from_speaker, into_speaker, created_at) VALUES (?, ?, ?, ?)`,
+		taskID, req.From, req.Into, time.Now().UTC(),
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to merge speakers")
+		return
+	}
+
+	affected, _ := res.RowsAffected()
+	writeJSON(w, http.StatusOK, SpeakerSegmentsResponse{Segments: affected})
+}
+
+// segmentSpeakerName возвращает имя спикера задачи и есть ли у него сегменты.
+func segmentSpeakerName(tx *sql.Tx, taskID, speakerID string) (sql.NullString, bool, error) {
+	var name sql.NullString
+	var segments int
+	err := tx.QueryRow(
+		`SELECT MAX(speaker_name), COUNT(*) FROM transcription_segments
+		 WHERE task_id = ? AND speaker_id = ?`,
+		taskID, speakerID,
+	).Scan(&name, &segments)
+	return name, segments > 0, err
+}
+
+// mergeTaskSpeakers переносит запись справочника from в into: эмбеддинги
+// усредняются с весом по длительности речи, человек into сохраняется.
+// Голоса профилей обоих спикеров пересчитываются с новым эмбеддингом.
+func mergeTaskSpeakers(tx *sql.Tx, taskID, from, into string) error {
+	type speakerRow struct {
+		found      bool
+		embedding  sql.NullString
+		durationMs int
+		profileID  sql.NullString
+		similarity sql.NullFloat64
+	}
+	load := func(speakerID string) (speakerRow, error) {
+		var row speakerRow
+		err := tx.QueryRow(
+			`SELECT embedding, duration_ms, profile_id, similarity FROM task_speakers
+			 WHERE task_id = ? AND speaker_id = ? FOR UPDATE`,
+			taskID, speakerID,
+		).Scan(&row.embedding, &row.durationMs, &row.profileID, &row.similarity)
+		if err == sql.ErrNoRows {
+			return row, nil
+		}
+		row.found = err == nil
+		return row, err
+	}
+
+	fromRow, err := load(from)
+	if err != nil || !fromRow.found {
+		return err
+	}
+	intoRow, err := load(into)
+	if err != nil {
+		return err
+	}
+	if !intoRow.found {
+		_, err := tx.Exec(
+			`UPDATE task_speakers SET speaker_id = ? WHERE task_id = ? AND speaker_id = ?`,
+			into, taskID, from,
+		)
+		return err
+	}
+
+	embedding := intoRow.embedding
+	if fromRow.embedding.Valid {
+		var a, b []float64
+		if intoRow.embedding.Valid {
+			if a, err = voiceprint.Decode(intoRow.embedding.String); err != nil {
+				return err
+			}
+		}
+		if b, err = voiceprint.Decode(fromRow.embedding.String); err != nil {
+			return err
+		}
+		combined := voiceprint.Combine(a, float64(intoRow.durationMs), b, float64(fromRow.durationMs))
+		embedding = sql.NullString{String: voiceprint.Encode(combined), Valid: true}
+	}
+	profileID, similarity := intoRow.profileID, intoRow.similarity
+	if !profileID.Valid {
+		profileID, similarity = fromRow.profileID, fromRow.similarity
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE task_speakers SET embedding = ?, duration_ms = ?, profile_id = ?, similarity = ?
+		 WHERE task_id = ? AND speaker_id = ?`,
+		embedding, intoRow.durationMs+fromRow.durationMs, profileID, similarity, taskID, into,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM task_speakers WHERE task_id = ? AND speaker_id = ?`, taskID, from); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if intoRow.profileID.Valid {
+		if err := rebuildProfileVoice(tx, intoRow.profileID.String, now); err != nil {
+			return err
+		}
+	}
+	if fromRow.profileID.Valid && fromRow.profileID != intoRow.profileID {
+		return rebuildProfileVoice(tx, fromRow.profileID.String, now)
+	}
+	return nil
+}
+
+// handleRenameProjectSpeaker переименовывает спикера во всех задачах проекта.
+// Профиль переименовывается вместе с ним; если человек с новым именем уже есть,
+// профили объединяются.
+func (s *Server) handleRenameProjectSpeaker(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	var req RenameProjectSpeakerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.To = strings.TrimSpace(req.To)
+	if req.From == "" || req.To == "" {
+		writeError(w, http.StatusBadRequest, "from and to are required")
+		return
+	}
+	if req.From == req.To {
+		writeError(w, http.StatusBadRequest, "new name is the same")
+		return
+	}
+
+	var exists int
+	err := s.db.QueryRow(
+		`SELECT 1 FROM projects WHERE id = ? AND user_session_id = ?`,
+		projectID, sessionID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check project")
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to rename speaker")
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE transcription_segments s
+		 JOIN transcription_tasks t ON t.id = s.task_id
+		 JOIN files f ON f.id = t.file_id
+		 SET s.speaker_name = ?
+		 WHERE f.project_id = ? AND s.speaker_name = ?`,
+		req.To, projectID, req.From,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to rename speaker")
+		return
+	}
+	affected, _ := res.RowsAffected()
+
+	renamed, err := renameSpeakerProfile(tx, projectID, req.From, req.To)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to rename speaker")
+		return
+	}
+	if affected == 0 && !renamed {
+		writeError(w, http.StatusNotFound, "speaker not found")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to rename speaker")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SpeakerSegmentsResponse{Segments: affected})
+}
+
+// renameSpeakerProfile переименовывает профиль from в to, а если профиль to уже
+// есть — переносит в него голос и спикеров from и удаляет from.
+// Возвращает false, если профиля from нет.
+func renameSpeakerProfile(tx *sql.Tx, projectID, from, to string) (bool, error) {
+	type profileRow struct {
+		id        string
+		embedding sql.NullString
+		samples   int
+	}
+	load := func(name string) (*profileRow, error) {
+		var p profileRow
+		err := tx.QueryRow(
+			`SELECT id, embedding, samples FROM speaker_profiles
+			 WHERE project_id = ? AND name = ? FOR UPDATE`,
+			projectID, name,
+		).Scan(&p.id, &p.embedding, &p.samples)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &p, nil
+	}
+
+	source, err := load(from)
+	if err != nil || source == nil {
+		return false, err
+	}
+	target, err := load(to)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now().UTC()
+	if target == nil {
+		_, err := tx.Exec(
+			`UPDATE speaker_profiles SET name = ?, updated_at = ? WHERE id = ?`,
+			to, now, source.id,
+		)
+		return err == nil, err
+	}
+
+	embedding := target.embedding
+	if source.embedding.Valid {
+		var a, b []float64
+		if target.embedding.Valid {
+			if a, err = voiceprint.Decode(target.embedding.String); err != nil {
+				return false, err
+			}
+		}
+		if b, err = voiceprint.Decode(source.embedding.String); err != nil {
+			return false, err
+		}
+		combined := voiceprint.Combine(a, float64(target.samples), b, float64(source.samples))
+		embedding = sql.NullString{String: voiceprint.Encode(combined), Valid: true}
+	}
+	if _, err := tx.Exec(
+		`UPDATE speaker_profiles SET embedding = ?, samples = ?, updated_at = ? WHERE id = ?`,
+		embedding, target.samples+source.samples, now, target.id,
+	); err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(
+		`UPDATE task_speakers SET profile_id = ? WHERE profile_id = ?`,
+		target.id, source.id,
+	); err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(`DELETE FROM speaker_profiles WHERE id = ?`, source.id)
+	return err == nil, err
+}
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleListProjectSpeakers(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM projects").
+		WithArgs("proj-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("FROM transcription_segments s").
+		WithArgs("proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "original_name", "speaker_id", "speaker_name",
+			"segments", "talk_time", "profile_id", "profile_name", "similarity"}).
+			AddRow("task-1", "standup.mp3", "SPEAKER_00", "Анна", 12, 300000, "p-anna", "Анна", 0.91).
+			AddRow("task-1", "standup.mp3", "SPEAKER_01", nil, 3, 20000, nil, nil, nil))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/projects/proj-1/speakers", nil), "id", "proj-1")
+	server.handleListProjectSpeakers(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp []ProjectSpeakerResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp, 2)
+	require.NotNil(t, resp[0].ProfileName)
+	assert.Equal(t, "Анна", *resp[0].ProfileName)
+	assert.Equal(t, 300000, resp[0].TalkTimeMs)
+	require.NotNil(t, resp[0].Similarity)
+	assert.Nil(t, resp[1].SpeakerName)
+	assert.Nil(t, resp[1].ProfileID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleMergeSpeakers(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM transcription_tasks").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT MAX\\(speaker_name\\), COUNT\\(\\*\\)").
+		WithArgs("task-1", "SPEAKER_02").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("Анна", 2))
+	mock.ExpectQuery("SELECT MAX\\(speaker_name\\), COUNT\\(\\*\\)").
+		WithArgs("task-1", "SPEAKER_00").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow(nil, 5))
+	// У SPEAKER_00 имени нет — берётся имя SPEAKER_02
+	mock.ExpectExec("UPDATE transcription_segments SET speaker_id = \\?, speaker_name = \\?").
+		WithArgs("SPEAKER_00", "Анна", "task-1", "SPEAKER_02", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 7))
+	mock.ExpectQuery("SELECT embedding, duration_ms, profile_id, similarity FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_02").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "duration_ms", "profile_id", "similarity"}).AddRow("[0,1]", 1000, "p-anna", nil))
+	mock.ExpectQuery("SELECT embedding, duration_ms, profile_id, similarity FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_00").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "duration_ms", "profile_id", "similarity"}).AddRow("[1,0]", 3000, nil, nil))
+	mock.ExpectExec("UPDATE task_speakers SET embedding = \\?, duration_ms = \\?, profile_id = \\?, similarity = \\?").
+		WithArgs(sqlmock.AnyArg(), 4000, "p-anna", nil, "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_02").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Голос Анны пересчитывается по объединённому эмбеддингу
+	mock.ExpectQuery("SELECT embedding FROM task_speakers").
+		WithArgs("p-anna").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding"}).AddRow("[0.6,0.8]"))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs("[0.6,0.8]", 1, sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE speaker_merges SET into_speaker = \\?").
+		WithArgs("SPEAKER_00", "task-1", "SPEAKER_02").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO speaker_merges").
+		WithArgs("task-1", "SPEAKER_02", "SPEAKER_00", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPost, "/api/tasks/task-1/speakers/merge",
+		strings.NewReader(`{"from":"SPEAKER_02","into":"SPEAKER_00"}`)), "id", "task-1")
+	server.handleMergeSpeakers(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp SpeakerSegmentsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, int64(7), resp.Segments)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeTaskSpeakers_RebuildsBothProfiles(t *testing.T) {
+	_, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, duration_ms, profile_id, similarity FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_02").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "duration_ms", "profile_id", "similarity"}).AddRow("[0,1]", 1000, "p-anya", nil))
+	mock.ExpectQuery("SELECT embedding, duration_ms, profile_id, similarity FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_00").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "duration_ms", "profile_id", "similarity"}).AddRow("[1,0]", 3000, "p-anna", 0.9))
+	mock.ExpectExec("UPDATE task_speakers SET embedding = \\?, duration_ms = \\?, profile_id = \\?, similarity = \\?").
+		WithArgs(sqlmock.AnyArg(), 4000, "p-anna", 0.9, "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_02").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT embedding FROM task_speakers").
+		WithArgs("p-anna").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding"}).AddRow("[1,0]"))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs("[1,0]", 1, sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// У Ани голос из этой записи больше не учитывается
+	mock.ExpectQuery("SELECT embedding FROM task_speakers").
+		WithArgs("p-anya").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding"}))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs(nil, 0, sqlmock.AnyArg(), "p-anya").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, mergeTaskSpeakers(tx, "task-1", "SPEAKER_02", "SPEAKER_00"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleMergeSpeakers_UnknownSpeaker(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT MAX\\(speaker_name\\), COUNT\\(\\*\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow(nil, 0))
+	mock.ExpectQuery("SELECT MAX\\(speaker_name\\), COUNT\\(\\*\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow(nil, 5))
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPost, "/api/tasks/task-1/speakers/merge",
+		strings.NewReader(`{"from":"SPEAKER_09","into":"SPEAKER_00"}`)), "id", "task-1")
+	server.handleMergeSpeakers(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleMergeSpeakers_SameSpeaker(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPost, "/api/tasks/task-1/speakers/merge",
+		strings.NewReader(`{"from":"SPEAKER_00","into":"SPEAKER_00"}`)), "id", "task-1")
+	server.handleMergeSpeakers(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleRenameProjectSpeaker_MergesProfiles(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM projects").
+		WithArgs("proj-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_segments s").
+		WithArgs("Анна Петрова", "proj-1", "Аня").
+		WillReturnResult(sqlmock.NewResult(0, 9))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WithArgs("proj-1", "Аня").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-anya", "[0,1]", 1))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WithArgs("proj-1", "Анна Петрова").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-anna", "[1,0]", 3))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs(sqlmock.AnyArg(), 4, sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE task_speakers SET profile_id = \\? WHERE profile_id = \\?").
+		WithArgs("p-anna", "p-anya").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM speaker_profiles WHERE id = \\?").
+		WithArgs("p-anya").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/projects/proj-1/speakers/rename",
+		strings.NewReader(`{"from":"Аня","to":"Анна Петрова"}`)), "id", "proj-1")
+	server.handleRenameProjectSpeaker(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp SpeakerSegmentsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, int64(9), resp.Segments)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleRenameProjectSpeaker_NotFound(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM projects").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_segments s").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}))
+	mock.ExpectRollback()
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/projects/proj-1/speakers/rename",
+		strings.NewReader(`{"from":"Никто","to":"Анна"}`)), "id", "proj-1")
+	server.handleRenameProjectSpeaker(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
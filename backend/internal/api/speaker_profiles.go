@@ -0,0 +1,295 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"loopa/backend/internal/session"
+	"loopa/backend/internal/voiceprint"
+)
+
+// handleListSpeakerProfiles возвращает голосовые профили проекта.
+func (s *Server) handleListSpeakerProfiles(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	sessionID := session.GetSessionID(r)
+
+	rows, err := s.db.Query(
+		`SELECT sp.id, sp.name, sp.samples, sp.created_at, sp.updated_at
+		 FROM speaker_profiles sp
+		 JOIN projects p ON p.id = sp.project_id
+		 WHERE sp.project_id = ? AND p.user_session_id = ?
+		 ORDER BY sp.name`,
+		projectID, sessionID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load speaker profiles")
+		return
+	}
+	defer rows.Close()
+
+	items := []SpeakerProfileResponse{}
+	for rows.Next() {
+		var item SpeakerProfileResponse
+		var createdAt, updatedAt time.Time
+		if err := rows.Scan(&item.ID, &item.Name, &item.Samples, &createdAt, &updatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to parse speaker profiles")
+			return
+		}
+		item.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		item.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+		items = append(items, item)
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// handleRenameSpeakerProfile переименовывает профиль. Имена спикеров
+// в уже обработанных задачах не меняются.
+func (s *Server) handleRenameSpeakerProfile(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	profileID := chi.URLParam(r, "profileId")
+	sessionID := session.GetSessionID(r)
+
+	var req UpdateSpeakerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	var exists int
+	err := s.db.QueryRow(
+		`SELECT 1 FROM speaker_profiles sp
+		 JOIN projects p ON p.id = sp.project_id
+		 WHERE sp.id = ? AND sp.project_id = ? AND p.user_session_id = ?`,
+		profileID, projectID, sessionID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "speaker profile not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load speaker profile")
+		return
+	}
+
+	err = s.db.QueryRow(
+		`SELECT 1 FROM speaker_profiles WHERE project_id = ? AND name = ? AND id <> ?`,
+		projectID, req.Name, profileID,
+	).Scan(&exists)
+	if err == nil {
+		writeError(w, http.StatusConflict, "speaker profile with this name already exists")
+		return
+	}
+	if err != sql.ErrNoRows {
+		writeError(w, http.StatusInternalServerError, "failed to load speaker profile")
+		return
+	}
+
+	if _, err := s.db.Exec(
+		`UPDATE speaker_profiles SET name = ?, updated_at = ? WHERE id = ?`,
+		req.Name, time.Now().UTC(), profileID,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update speaker profile")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// handleDeleteSpeakerProfile удаляет профиль: спикер перестаёт узнаваться
+// в новых записях проекта.
+func (s *Server) handleDeleteSpeakerProfile(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	profileID := chi.URLParam(r, "profileId")
+	sessionID := session.GetSessionID(r)
+
+	res, err := s.db.Exec(
+		`DELETE sp FROM speaker_profiles sp
+		 JOIN projects p ON p.id = sp.project_id
+		 WHERE sp.id = ? AND sp.project_id = ? AND p.user_session_id = ?`,
+		profileID, projectID, sessionID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete speaker profile")
+		return
+	}
+	affected, _ := res.RowsAffected()
+	if affected == 0 {
+		writeError(w, http.StatusNotFound, "speaker profile not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// assignSpeakerProfile относит спикера задачи к человеку проекта с именем name:
+// создаёт профиль, если его нет, и запоминает связь в справочнике спикеров.
+// Если для спикера посчитан эмбеддинг, голос добавляется к профилю — в следующих
+// записях спикер будет узнан. Если спикер был отнесён к другому профилю, голос
+// того профиля пересчитывается без него, а если спикер был узнан автоматически,
+// подтверждённый голос добавляется к профилю. Задачи вне проекта пропускаются.
+func (s *Server) assignSpeakerProfile(taskID, speakerID, name string) error {
+	var projectID sql.NullString
+	err := s.db.QueryRow(
+		`SELECT f.project_id FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 WHERE t.id = ?`,
+		taskID,
+	).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !projectID.Valid {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var embeddingJSON, currentProfile sql.NullString
+	var similarity sql.NullFloat64
+	err = tx.QueryRow(
+		`SELECT embedding, profile_id, similarity FROM task_speakers
+		 WHERE task_id = ? AND speaker_id = ? FOR UPDATE`,
+		taskID, speakerID,
+	).Scan(&embeddingJSON, &currentProfile, &similarity)
+	hasSpeaker := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	var embedding []float64
+	if embeddingJSON.Valid {
+		if embedding, err = voiceprint.Decode(embeddingJSON.String); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	var profileID string
+	var profileJSON sql.NullString
+	var samples int
+	err = tx.QueryRow(
+		`SELECT id, embedding, samples FROM speaker_profiles
+		 WHERE project_id = ? AND name = ? FOR UPDATE`,
+		projectID.String, name,
+	).Scan(&profileID, &profileJSON, &samples)
+	switch {
+	case err == sql.ErrNoRows:
+		profileID = uuid.New().String()
+		var encoded interface{}
+		if embedding != nil {
+			encoded = voiceprint.Encode(voiceprint.Merge(nil, 0, embedding))
+			samples = 1
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO speaker_profiles (id, project_id, name, embedding, samples, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			profileID, projectID.String, name, encoded, samples, now, now,
+		); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case currentProfile.Valid && currentProfile.String == profileID:
+		if !similarity.Valid {
+			// Спикер уже отнесён к этому человеку вручную, голос учтён
+			return nil
+		}
+		// Автоматическое совпадение подтверждено: голос профиля
+		// пересчитывается ниже уже с этим спикером
+	case embedding != nil:
+		var profile []float64
+		if profileJSON.Valid {
+			if profile, err = voiceprint.Decode(profileJSON.String); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(
+			`UPDATE speaker_profiles SET embedding = ?, samples = samples + 1, updated_at = ? WHERE id = ?`,
+			voiceprint.Encode(voiceprint.Merge(profile, samples, embedding)), now, profileID,
+		); err != nil {
+			return err
+		}
+	}
+
+	if hasSpeaker {
+		_, err = tx.Exec(
+			`UPDATE task_speakers SET profile_id = ?, similarity = NULL WHERE task_id = ? AND speaker_id = ?`,
+			profileID, taskID, speakerID,
+		)
+	} else {
+		_, err = tx.Exec(
+			`INSERT INTO task_speakers (task_id, speaker_id, embedding, duration_ms, profile_id, similarity)
+			 VALUES (?, ?, NULL, 0, ?, NULL)`,
+			taskID, speakerID, profileID,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if currentProfile.Valid {
+		if err := rebuildProfileVoice(tx, currentProfile.String, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// rebuildProfileVoice заново считает голос профиля по спикерам задач, которые
+// отнесены к нему вручную (similarity IS NULL): они и добавили свои голоса
+// в профиль. Если таких спикеров не осталось, эмбеддинг профиля очищается.
+func rebuildProfileVoice(tx *sql.Tx, profileID string, now time.Time) error {
+	rows, err := tx.Query(
+		`SELECT embedding FROM task_speakers
+		 WHERE profile_id = ? AND similarity IS NULL AND embedding IS NOT NULL`,
+		profileID,
+	)
+	if err != nil {
+		return err
+	}
+	var embeddings []string
+	for rows.Next() {
+		var embedding string
+		if err := rows.Scan(&embedding); err != nil {
+			rows.Close()
+			return err
+		}
+		embeddings = append(embeddings, embedding)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	var profile []float64
+	for i, raw := range embeddings {
+		embedding, err := voiceprint.Decode(raw)
+		if err != nil {
+			return err
+		}
+		profile = voiceprint.Merge(profile, i, embedding)
+	}
+	var encoded interface{}
+	if profile != nil {
+		encoded = voiceprint.Encode(profile)
+	}
+	_, err = tx.Exec(
+		`UPDATE speaker_profiles SET embedding = ?, samples = ?, updated_at = ? WHERE id = ?`,
+		encoded, len(embeddings), now, profileID,
+	)
+	return err
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleListSpeakerProfiles(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	now := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM speaker_profiles sp").
+		WithArgs("proj-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "samples", "created_at", "updated_at"}).
+			AddRow("p-1", "Анна", 3, now, now))
+
+	rec := httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/projects/proj-1/speaker-profiles", nil), "id", "proj-1")
+	server.handleListSpeakerProfiles(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp []SpeakerProfileResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp, 1)
+	assert.Equal(t, "Анна", resp[0].Name)
+	assert.Equal(t, 3, resp[0].Samples)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleRenameSpeakerProfile_NameTaken(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM speaker_profiles sp").
+		WithArgs("p-1", "proj-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectQuery("SELECT 1 FROM speaker_profiles WHERE project_id = \\? AND name = \\?").
+		WithArgs("proj-1", "Борис", "p-1").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodPut, "/api/projects/proj-1/speaker-profiles/p-1",
+		strings.NewReader(`{"name":" Борис "}`)), "id", "proj-1", "profileId", "p-1")
+	server.handleRenameSpeakerProfile(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleDeleteSpeakerProfile_NotFound(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectExec("DELETE sp FROM speaker_profiles sp").
+		WithArgs("p-1", "proj-1", "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodDelete, "/api/projects/proj-1/speaker-profiles/p-1", nil),
+		"id", "proj-1", "profileId", "p-1")
+	server.handleDeleteSpeakerProfile(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleUpdateSpeaker_LearnsVoiceProfile(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT 1 FROM transcription_tasks").
+		WithArgs("task-1", "").
+		WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("UPDATE transcription_segments").
+		WithArgs("Анна", "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_00").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}).AddRow("[0,1]", nil, nil))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WithArgs("proj-1", "Анна").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-anna", "[1,0]", 1))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = samples \\+ 1").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE task_speakers SET profile_id = \\?").
+		WithArgs("p-anna", "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := httptest.NewRecorder()
+	req := withURLParams(httptest.NewRequest(http.MethodPut, "/api/tasks/task-1/speakers/SPEAKER_00",
+		strings.NewReader(`{"name":"Анна"}`)), "id", "task-1", "speakerId", "SPEAKER_00")
+	server.handleUpdateSpeaker(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_CreatesProfile(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WithArgs("task-1", "SPEAKER_01").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}).AddRow("[3,4]", nil, nil))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}))
+	mock.ExpectExec("INSERT INTO speaker_profiles").
+		WithArgs(sqlmock.AnyArg(), "proj-1", "Борис", "[0.6,0.8]", 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE task_speakers SET profile_id = \\?").
+		WithArgs(sqlmock.AnyArg(), "task-1", "SPEAKER_01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_01", "Борис"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_RemovesVoiceFromPreviousProfile(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}).AddRow("[0,1]", "p-anna", nil))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-boris", "[1,0]", 1))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = samples \\+ 1").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "p-boris").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE task_speakers SET profile_id = \\?").
+		WithArgs("p-boris", "task-1", "SPEAKER_01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// У Анны остался голос из другой записи
+	mock.ExpectQuery("SELECT embedding FROM task_speakers").
+		WithArgs("p-anna").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding"}).AddRow("[3,4]"))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs("[0.6,0.8]", 1, sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_01", "Борис"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_ClearsVoiceOfEmptiedProfile(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}).AddRow(nil, "p-anna", nil))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-boris", nil, 0))
+	mock.ExpectExec("UPDATE task_speakers SET profile_id = \\?").
+		WithArgs("p-boris", "task-1", "SPEAKER_01").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT embedding FROM task_speakers").
+		WithArgs("p-anna").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding"}))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs(nil, 0, sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_01", "Борис"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_ConfirmsAutoMatch(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}).AddRow("[3,4]", "p-anna", 0.91))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-anna", "[1,0]", 1))
+	mock.ExpectExec("UPDATE task_speakers SET profile_id = \\?, similarity = NULL").
+		WithArgs("p-anna", "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Подтверждённый голос теперь учитывается в профиле
+	mock.ExpectQuery("SELECT embedding FROM task_speakers").
+		WithArgs("p-anna").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding"}).AddRow("[1,0]").AddRow("[3,4]"))
+	mock.ExpectExec("UPDATE speaker_profiles SET embedding = \\?, samples = \\?").
+		WithArgs(sqlmock.AnyArg(), 2, sqlmock.AnyArg(), "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_00", "Анна"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_AlreadyConfirmed(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}).AddRow("[3,4]", "p-anna", nil))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-anna", "[0.6,0.8]", 1))
+	mock.ExpectRollback()
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_00", "Анна"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_WithoutVoiceAddsToDirectory(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow("proj-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT embedding, profile_id, similarity FROM task_speakers").
+		WillReturnRows(sqlmock.NewRows([]string{"embedding", "profile_id", "similarity"}))
+	mock.ExpectQuery("SELECT id, embedding, samples FROM speaker_profiles").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embedding", "samples"}).AddRow("p-anna", nil, 0))
+	mock.ExpectExec("INSERT INTO task_speakers").
+		WithArgs("task-1", "SPEAKER_00", "p-anna").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_00", "Анна"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignSpeakerProfile_SkipsTaskOutsideProject(t *testing.T) {
+	server, mock, db := setupTestServer(t)
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT f.project_id FROM transcription_tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"project_id"}).AddRow(nil))
+
+	require.NoError(t, server.assignSpeakerProfile("task-1", "SPEAKER_00", "Анна"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
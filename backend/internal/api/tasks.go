@@ -2,6 +2,7 @@ package api
 
 import (
 	"database/sql"
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -25,15 +26,29 @@ func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
 		errorMsg     sql.NullString
 		createdAt    time.Time
 		completedAt  sql.NullTime
+		durationMs   sql.NullInt64
+		formatName   sql.NullString
+		audioCodec   sql.NullString
+		sampleRate   sql.NullInt64
+		channels     sql.NullInt64
+		bitRate      sql.NullInt64
+		hasVideo     bool
+		progress     sql.NullInt64
+		provider     string
+		attempts     sql.NullString
 	)
 
 	err := s.db.QueryRow(
-		`SELECT t.status, f.original_name, t.transcript_text, t.error_message, t.created_at, t.completed_at
+		`SELECT t.status, f.original_name, t.transcript_text, t.error_message, t.created_at, t.completed_at,
+		        f.duration_ms, f.format_name, f.audio_codec, f.sample_rate, f.channels, f.bit_rate, f.has_video,
+		        t.progress, t.provider, t.provider_attempts
 		 FROM transcription_tasks t
 		 JOIN files f ON f.id = t.file_id
 		 WHERE t.id = ? AND f.user_session_id = ?`,
 		taskID, sessionID,
-	).Scan(&status, &originalName, &transcript, &errorMsg, &createdAt, &completedAt)
+	).Scan(&status, &originalName, &transcript, &errorMsg, &createdAt, &completedAt,
+		&durationMs, &formatName, &audioCodec, &sampleRate, &channels, &bitRate, &hasVideo, &progress,
+		&provider, &attempts)
 	if err == sql.ErrNoRows {
 		writeError(w, http.StatusNotFound, "task not found")
 		return
@@ -52,13 +67,41 @@ func (s *Server) handleGetTask(w http.ResponseWriter, r *http.Request) {
 	if transcript.Valid {
 		resp.TranscriptText = &transcript.String
 	}
+	if progress.Valid && status == "в процессе" {
+		value := int(progress.Int64)
+		resp.Progress = &value
+	}
 	if errorMsg.Valid {
 		resp.ErrorMessage = &errorMsg.String
 	}
+	if status == "готово" {
+		resp.Provider = provider
+	}
+	if attempts.Valid {
+		_ = json.Unmarshal([]byte(attempts.String), &resp.ProviderAttempts)
+	}
 	if completedAt.Valid {
 		value := completedAt.Time.UTC().Format(time.RFC3339)
 		resp.CompletedAt = &value
 	}
+	if durationMs.Valid {
+		resp.Media = &MediaInfoResponse{
+			DurationSeconds: float64(durationMs.Int64) / 1000,
+			BillableMinutes: billableMinutes(durationMs.Int64),
+			Format:          formatName.String,
+			AudioCodec:      audioCodec.String,
+			SampleRate:      int(sampleRate.Int64),
+			Channels:        int(channels.Int64),
+			BitRate:         bitRate.Int64,
+			HasVideo:        hasVideo,
+		}
+	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
+
+// billableMinutes округляет длительность вверх до целых минут.
+func billableMinutes(durationMs int64) int {
+	const minuteMs = 60 * 1000
+	return int((durationMs + minuteMs - 1) / minuteMs)
+}
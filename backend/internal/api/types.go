@@ -1,36 +1,71 @@
 package api
 
+import (
+	"loopa/backend/internal/analytics"
+	"loopa/backend/internal/glossary"
+	"loopa/backend/internal/media"
+)
+
 type TaskResponse struct {
-	ID             string            `json:"id"`
-	Status         string            `json:"status"`
-	OriginalName   string            `json:"originalName"`
-	TranscriptText *string           `json:"transcriptText,omitempty"`
-	ErrorMessage   *string           `json:"errorMessage,omitempty"`
-	CreatedAt      string            `json:"createdAt"`
-	CompletedAt    *string           `json:"completedAt,omitempty"`
-	Segments       []SegmentResponse `json:"segments,omitempty"`
-	NumSpeakers    int               `json:"numSpeakers,omitempty"`
+	ID             string             `json:"id"`
+	Status         string             `json:"status"`
+	OriginalName   string             `json:"originalName"`
+	TranscriptText *string            `json:"transcriptText,omitempty"`
+	ErrorMessage   *string            `json:"errorMessage,omitempty"`
+	CreatedAt      string             `json:"createdAt"`
+	CompletedAt    *string            `json:"completedAt,omitempty"`
+	Segments       []SegmentResponse  `json:"segments,omitempty"`
+	NumSpeakers    int                `json:"numSpeakers,omitempty"`
+	Media          *MediaInfoResponse `json:"media,omitempty"`
+	// Progress — выполнение распознавания в процентах, пока задача «в процессе».
+	Progress *int `json:"progress,omitempty"`
+	// Provider — провайдер, распознавший запись; ProviderAttempts — неудачные
+	// попытки предыдущих провайдеров цепочки fallback.
+	Provider         string            `json:"provider,omitempty"`
+	ProviderAttempts []ProviderAttempt `json:"providerAttempts,omitempty"`
+}
+
+type ProviderAttempt struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+// MediaInfoResponse — характеристики загруженного файла.
+type MediaInfoResponse struct {
+	DurationSeconds float64 `json:"durationSeconds"`
+	BillableMinutes int     `json:"billableMinutes"`
+	Format          string  `json:"format,omitempty"`
+	AudioCodec      string  `json:"audioCodec,omitempty"`
+	SampleRate      int     `json:"sampleRate,omitempty"`
+	Channels        int     `json:"channels,omitempty"`
+	BitRate         int64   `json:"bitRate,omitempty"`
+	HasVideo        bool    `json:"hasVideo"`
 }
 
 type HistoryItem struct {
-	ID           string `json:"id"`
-	OriginalName string `json:"originalName"`
-	Status       string `json:"status"`
-	UploadedAt   string `json:"uploadedAt"`
+	ID              string   `json:"id"`
+	OriginalName    string   `json:"originalName"`
+	Status          string   `json:"status"`
+	UploadedAt      string   `json:"uploadedAt"`
+	DurationSeconds *float64 `json:"durationSeconds,omitempty"`
 }
 
 type ProjectResponse struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
-	Status      string  `json:"status"`
-	CreatedAt   string  `json:"createdAt"`
-	FileCount   int     `json:"fileCount"`
+	ID            string                   `json:"id"`
+	Name          string                   `json:"name"`
+	Description   *string                  `json:"description,omitempty"`
+	Status        string                   `json:"status"`
+	CreatedAt     string                   `json:"createdAt"`
+	FileCount     int                      `json:"fileCount"`
+	Preprocessing *media.PreprocessOptions `json:"preprocessing,omitempty"`
+	Glossary      *glossary.Glossary       `json:"glossary,omitempty"`
 }
 
 type CreateProjectRequest struct {
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
+	Name          string                   `json:"name"`
+	Description   *string                  `json:"description,omitempty"`
+	Preprocessing *media.PreprocessOptions `json:"preprocessing,omitempty"`
+	Glossary      *glossary.Glossary       `json:"glossary,omitempty"`
 }
 
 type SegmentResponse struct {
@@ -44,6 +79,36 @@ type SegmentResponse struct {
 	IsCorrected bool    `json:"isCorrected"`
 }
 
+// TaskAnalyticsResponse — статистика разговора задачи (GET /api/tasks/{id}/analytics).
+type TaskAnalyticsResponse struct {
+	TaskID string `json:"taskId"`
+	analytics.Report
+}
+
+// ProjectAnalyticsResponse — статистика завершённых задач проекта.
+type ProjectAnalyticsResponse struct {
+	ProjectID string `json:"projectId"`
+	Tasks     int    `json:"tasks"`
+	analytics.Report
+}
+
+// ExportJSONResponse — экспорт задачи в формате json.
+type ExportJSONResponse struct {
+	TaskID       string              `json:"taskId"`
+	OriginalName string              `json:"originalName"`
+	Transcript   string              `json:"transcript"`
+	Segments     []ExportJSONSegment `json:"segments"`
+	Analytics    analytics.Report    `json:"analytics"`
+}
+
+type ExportJSONSegment struct {
+	SpeakerID   *string `json:"speakerId,omitempty"`
+	SpeakerName *string `json:"speakerName,omitempty"`
+	StartTime   int     `json:"startTime"`
+	EndTime     int     `json:"endTime"`
+	Text        string  `json:"text"`
+}
+
 type UpdateSegmentRequest struct {
 	Text string `json:"text"`
 }
@@ -51,3 +116,60 @@ type UpdateSegmentRequest struct {
 type UpdateSpeakerRequest struct {
 	Name string `json:"name"`
 }
+
+// SpeakerProfileResponse — голосовой профиль спикера проекта.
+// Samples — по скольким записям построен профиль.
+type SpeakerProfileResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Samples   int    `json:"samples"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// ProjectSpeakerResponse — спикер задачи в справочнике спикеров проекта.
+// ProfileID/ProfileName — человек, к которому отнесён спикер; Similarity —
+// близость голоса, если спикер узнан автоматически.
+type ProjectSpeakerResponse struct {
+	TaskID       string   `json:"taskId"`
+	OriginalName string   `json:"originalName"`
+	SpeakerID    string   `json:"speakerId"`
+	SpeakerName  *string  `json:"speakerName,omitempty"`
+	Segments     int      `json:"segments"`
+	TalkTimeMs   int      `json:"talkTimeMs"`
+	ProfileID    *string  `json:"profileId,omitempty"`
+	ProfileName  *string  `json:"profileName,omitempty"`
+	Similarity   *float64 `json:"similarity,omitempty"`
+}
+
+// MergeSpeakersRequest — сегменты спикера From относятся к Into.
+type MergeSpeakersRequest struct {
+	From string `json:"from"`
+	Into string `json:"into"`
+}
+
+// RenameProjectSpeakerRequest — переименование спикера во всех задачах проекта.
+type RenameProjectSpeakerRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SpeakerSegmentsResponse — сколько сегментов изменено.
+type SpeakerSegmentsResponse struct {
+	Segments int64 `json:"segments"`
+}
+
+type HealthResponse struct {
+	Status    string           `json:"status"` // ok, degraded или down
+	Database  string           `json:"database"`
+	Providers []ProviderHealth `json:"providers"`
+}
+
+type ProviderHealth struct {
+	Name                string  `json:"name"`
+	Status              string  `json:"status"` // ok, degraded, down или unknown
+	ConsecutiveFailures int     `json:"consecutiveFailures"`
+	LastError           *string `json:"lastError,omitempty"`
+	CheckedAt           string  `json:"checkedAt"`
+	LastSuccessAt       *string `json:"lastSuccessAt,omitempty"`
+}
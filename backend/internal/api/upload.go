@@ -1,11 +1,11 @@
 package api
 
 import (
+	"bufio"
+	"encoding/json"
 	"io"
-	"mime"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -26,20 +26,30 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var (
-		originalName string
-		mimeType     string
-		storagePath  string
-		fileID       string
-		fileSize     int64
-		projectID    string
+		originalName  string
+		container     media.Container
+		storagePath   string
+		fileID        string
+		fileSize      int64
+		projectID     string
+		preprocessing []byte
 	)
 
+	// Поля формы могут идти и после файла: сохранённый файл удаляется,
+	// если остаток формы оказался некорректным
+	discard := func() {
+		if storagePath != "" {
+			_ = os.Remove(storagePath)
+		}
+	}
+
 	for {
 		part, err := reader.NextPart()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
+			discard()
 			writeError(w, http.StatusBadRequest, "invalid multipart stream")
 			return
 		}
@@ -52,22 +62,38 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		if part.FormName() != "file" || part.FileName() == "" {
+		// Предобработка: имя пресета или JSON с параметрами фильтров
+		if part.FormName() == "preprocessing" {
+			data, _ := io.ReadAll(part)
 			_ = part.Close()
+			opts, err := media.ParsePreprocess(string(data))
+			if err != nil {
+				discard()
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			preprocessing, _ = json.Marshal(opts)
 			continue
 		}
 
-		originalName = part.FileName()
-		mimeType = part.Header.Get("Content-Type")
-		if mimeType == "" {
-			mimeType = mime.TypeByExtension(strings.ToLower(filepath.Ext(originalName)))
+		// Второй файл в форме пропускается
+		if part.FormName() != "file" || part.FileName() == "" || storagePath != "" {
+			_ = part.Close()
+			continue
 		}
-		if !isAllowedFile(originalName, mimeType) {
+
+		// Тип файла определяем по содержимому, а не по расширению или Content-Type
+		originalName = part.FileName()
+		buffered := bufio.NewReaderSize(part, media.SniffLen)
+		header, _ := buffered.Peek(media.SniffLen)
+		detected, ok := media.DetectContainer(header)
+		if !ok {
 			_ = part.Close()
 			writeError(w, http.StatusBadRequest, "unsupported file type")
 			return
 		}
-		path, id, size, err := storage.SaveUploadedFile(s.config.UploadDir, originalName, part)
+		container = detected
+		path, id, size, err := storage.SaveUploadedFile(s.config.UploadDir, originalName, buffered)
 		_ = part.Close()
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, "failed to save upload")
@@ -76,7 +102,6 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		storagePath = path
 		fileID = id
 		fileSize = size
-		break
 	}
 
 	if storagePath == "" {
@@ -84,15 +109,21 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if isVideoFile(originalName, mimeType) {
-		originalPath := storagePath
-		audioPath, err := media.ExtractAudio(originalPath, s.config.UploadDir)
-		if err != nil {
-			writeError(w, http.StatusInternalServerError, "failed to extract audio")
-			return
-		}
-		_ = os.Remove(originalPath)
-		storagePath = audioPath
+	info, err := media.Probe(storagePath)
+	if err != nil {
+		_ = os.Remove(storagePath)
+		writeError(w, http.StatusBadRequest, "failed to read media file")
+		return
+	}
+	if !container.MatchesProbe(info) {
+		_ = os.Remove(storagePath)
+		writeError(w, http.StatusBadRequest, "file content does not match its format")
+		return
+	}
+	if !info.HasAudio {
+		_ = os.Remove(storagePath)
+		writeError(w, http.StatusBadRequest, "file has no audio stream")
+		return
 	}
 
 	sessionID := session.GetSessionID(r)
@@ -110,20 +141,36 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, err = s.db.Exec(
-		`INSERT INTO files (id, original_name, storage_path, file_size, mime_type, uploaded_at, user_session_id, project_id)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		fileID, originalName, storagePath, fileSize, mimeType, now, sessionID, projectIDParam,
+		`INSERT INTO files (id, original_name, storage_path, file_size, mime_type,
+		                    duration_ms, format_name, audio_codec, sample_rate, channels, bit_rate, has_video,
+		                    uploaded_at, user_session_id, project_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		fileID, originalName, storagePath, fileSize, container.MimeType,
+		int64(info.Duration*1000), info.FormatName, info.AudioCodec, info.SampleRate, info.Channels, info.BitRate, info.HasVideo,
+		now, sessionID, projectIDParam,
 	)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to store file metadata")
 		return
 	}
 
+	// Аудио из видео извлекает worker, чтобы не блокировать запрос
+	status := "ожидает"
+	if info.HasVideo {
+		status = "извлечение аудио"
+	}
+
+	// preprocessing — NULL если не указан (берётся настройка проекта)
+	var preprocessingParam interface{}
+	if preprocessing != nil {
+		preprocessingParam = string(preprocessing)
+	}
+
 	taskID := uuid.New().String()
 	_, err = s.db.Exec(
-		`INSERT INTO transcription_tasks (id, file_id, status, provider, created_at)
-		 VALUES (?, ?, ?, ?, ?)`,
-		taskID, fileID, "ожидает", "mock", now,
+		`INSERT INTO transcription_tasks (id, file_id, status, provider, preprocessing, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		taskID, fileID, status, "mock", preprocessingParam, now,
 	)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create task")
@@ -132,20 +179,3 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusCreated, map[string]string{"taskId": taskID})
 }
-
-func isVideoFile(name, mimeType string) bool {
-	ext := strings.ToLower(filepath.Ext(name))
-	if ext == ".mp4" || ext == ".mov" {
-		return true
-	}
-	return strings.HasPrefix(mimeType, "video/")
-}
-
-func isAllowedFile(name, mimeType string) bool {
-	ext := strings.ToLower(filepath.Ext(name))
-	switch ext {
-	case ".mp3", ".wav", ".mp4", ".mov":
-		return true
-	}
-	return strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/")
-}
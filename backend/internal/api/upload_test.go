@@ -0,0 +1,39 @@
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleUpload_ReadsFieldsAfterFile(t *testing.T) {
+	server, _, db := setupTestServer(t)
+	defer db.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "a.ogg")
+	require.NoError(t, err)
+	file.Write([]byte("OggS fake audio"))
+	// Поле после файла не должно теряться: неверный пресет отклоняется
+	require.NoError(t, form.WriteField("preprocessing", "unknown"))
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/uploads", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	server.handleUpload(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown preprocessing preset")
+	// Сохранённый файл удалён
+	entries, err := os.ReadDir(server.config.UploadDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
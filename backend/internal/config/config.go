@@ -3,35 +3,118 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	DBDSN                  string
-	UploadDir              string
-	MaxUploadBytes         int64
-	TranscriptionProvider  string // "whisper" (default) или "speechkit"
-	YandexSpeechKitAPIKey  string
-	YandexFolderId         string
+	DBDSN                 string
+	UploadDir             string
+	MaxUploadBytes        int64
+	TranscriptionProvider string // "whisper" (default) или "speechkit"
+	// TranscriptionFallback — провайдеры через запятую, которые пробуются по порядку,
+	// если основной завершился постоянной ошибкой.
+	TranscriptionFallback string
+	YandexSpeechKitAPIKey string
+	YandexFolderId        string
+	// Параллельное распознавание частей длинного аудио
+	SpeechKitConcurrency int
+	SpeechKitRPS         int
+	// SpeechKitAPIVersion — API для длинных аудио через Object Storage: v2 или v3.
+	SpeechKitAPIVersion        string
+	SpeechKitModel             string
+	SpeechKitTextNormalization bool
+	SpeechKitLiteratureText    bool
+	SpeechKitProfanityFilter   bool
+	// Адреса API SpeechKit и Object Storage; пустые — адреса Yandex Cloud.
+	SpeechKitSTTURL        string
+	SpeechKitTranscribeURL string
+	SpeechKitOperationURL  string
+	YandexStorageEndpoint  string
 	// Yandex Object Storage для длинных аудио
 	YandexStorageAccessKey string
 	YandexStorageSecretKey string
 	YandexStorageBucket    string
 	// ML-сервис
 	MLServiceURL string
+	// Ограничения времени запросов к ML-сервису
+	MLDiarizeTimeout     time.Duration
+	MLTranscribeTimeout  time.Duration
+	MLProcessTextTimeout time.Duration
+	MLEmbeddingsTimeout  time.Duration
+	// Узнавание спикеров задач проекта по голосовым профилям
+	SpeakerIdentification bool
+	SpeakerMatchThreshold float64
+	// Circuit breaker провайдеров распознавания в worker'е
+	ProviderFailureThreshold int
+	ProviderCooldown         time.Duration
+	HealthCheckInterval      time.Duration
+	// Локальный whisper.cpp (провайдер whispercpp)
+	WhisperCppBinary   string
+	WhisperCppModel    string
+	WhisperCppThreads  int
+	WhisperCppLanguage string
+	// OpenAI-совместимый API распознавания (провайдер openai)
+	OpenAISTTURL      string
+	OpenAISTTAPIKey   string
+	OpenAISTTModel    string
+	OpenAISTTLanguage string
+	// Детерминированный провайдер mock для разработки и e2e-тестов
+	MockDelay       time.Duration
+	MockFixturesDir string
+	MockFail        string
+	// Сохранять исходное видео после извлечения аудио
+	KeepOriginalVideo bool
+	// Пресет или JSON предобработки аудио по умолчанию
+	PreprocessingDefault string
 }
 
 func Load() Config {
 	return Config{
-		DBDSN:                  getEnv("DB_DSN", "root:root@tcp(mysql:3306)/loopa?parseTime=true"),
-		UploadDir:              getEnv("UPLOAD_DIR", "/data/uploads"),
-		MaxUploadBytes:         getEnvInt64("MAX_UPLOAD_BYTES", 1073741824),
-		TranscriptionProvider:  getEnv("TRANSCRIPTION_PROVIDER", "whisper"),
-		YandexSpeechKitAPIKey:  getEnv("YANDEX_SPEECHKIT_API_KEY", ""),
-		YandexFolderId:         getEnv("YANDEX_FOLDER_ID", ""),
-		YandexStorageAccessKey: getEnv("YANDEX_STORAGE_ACCESS_KEY", ""),
-		YandexStorageSecretKey: getEnv("YANDEX_STORAGE_SECRET_KEY", ""),
-		YandexStorageBucket:    getEnv("YANDEX_STORAGE_BUCKET", ""),
-		MLServiceURL:           getEnv("ML_SERVICE_URL", "http://ml-service:8001"),
+		DBDSN:                      getEnv("DB_DSN", "root:root@tcp(mysql:3306)/loopa?parseTime=true"),
+		UploadDir:                  getEnv("UPLOAD_DIR", "/data/uploads"),
+		MaxUploadBytes:             getEnvInt64("MAX_UPLOAD_BYTES", 1073741824),
+		TranscriptionProvider:      getEnv("TRANSCRIPTION_PROVIDER", "whisper"),
+		TranscriptionFallback:      getEnv("TRANSCRIPTION_FALLBACK", ""),
+		YandexSpeechKitAPIKey:      getEnv("YANDEX_SPEECHKIT_API_KEY", ""),
+		YandexFolderId:             getEnv("YANDEX_FOLDER_ID", ""),
+		SpeechKitConcurrency:       int(getEnvInt64("SPEECHKIT_CONCURRENCY", 4)),
+		SpeechKitRPS:               int(getEnvInt64("SPEECHKIT_RPS", 10)),
+		SpeechKitAPIVersion:        getEnv("SPEECHKIT_API_VERSION", "v2"),
+		SpeechKitModel:             getEnv("SPEECHKIT_MODEL", "general"),
+		SpeechKitTextNormalization: getEnvBool("SPEECHKIT_TEXT_NORMALIZATION", false),
+		SpeechKitLiteratureText:    getEnvBool("SPEECHKIT_LITERATURE_TEXT", false),
+		SpeechKitProfanityFilter:   getEnvBool("SPEECHKIT_PROFANITY_FILTER", false),
+		SpeechKitSTTURL:            getEnv("SPEECHKIT_STT_URL", ""),
+		SpeechKitTranscribeURL:     getEnv("SPEECHKIT_TRANSCRIBE_URL", ""),
+		SpeechKitOperationURL:      getEnv("SPEECHKIT_OPERATION_URL", ""),
+		YandexStorageEndpoint:      getEnv("YANDEX_STORAGE_ENDPOINT", ""),
+		YandexStorageAccessKey:     getEnv("YANDEX_STORAGE_ACCESS_KEY", ""),
+		YandexStorageSecretKey:     getEnv("YANDEX_STORAGE_SECRET_KEY", ""),
+		YandexStorageBucket:        getEnv("YANDEX_STORAGE_BUCKET", ""),
+		MLServiceURL:               getEnv("ML_SERVICE_URL", "http://ml-service:8001"),
+		MLDiarizeTimeout:           getEnvDuration("ML_DIARIZE_TIMEOUT", 20*time.Minute),
+		MLTranscribeTimeout:        getEnvDuration("ML_TRANSCRIBE_TIMEOUT", 60*time.Minute),
+		MLProcessTextTimeout:       getEnvDuration("ML_PROCESS_TEXT_TIMEOUT", time.Minute),
+		MLEmbeddingsTimeout:        getEnvDuration("ML_EMBEDDINGS_TIMEOUT", 10*time.Minute),
+		SpeakerIdentification:      getEnvBool("SPEAKER_IDENTIFICATION", true),
+		SpeakerMatchThreshold:      getEnvFloat("SPEAKER_MATCH_THRESHOLD", 0.7),
+		ProviderFailureThreshold:   int(getEnvInt64("PROVIDER_FAILURE_THRESHOLD", 3)),
+		ProviderCooldown:           getEnvDuration("PROVIDER_COOLDOWN", time.Minute),
+		HealthCheckInterval:        getEnvDuration("HEALTH_CHECK_INTERVAL", 30*time.Second),
+		WhisperCppBinary:           getEnv("WHISPER_CPP_BIN", "whisper-cli"),
+		WhisperCppModel:            getEnv("WHISPER_CPP_MODEL", ""),
+		WhisperCppThreads:          int(getEnvInt64("WHISPER_CPP_THREADS", 0)),
+		WhisperCppLanguage:         getEnv("WHISPER_CPP_LANGUAGE", "ru"),
+		OpenAISTTURL:               getEnv("OPENAI_STT_URL", "https://api.openai.com/v1"),
+		OpenAISTTAPIKey:            getEnv("OPENAI_STT_API_KEY", ""),
+		OpenAISTTModel:             getEnv("OPENAI_STT_MODEL", "whisper-1"),
+		OpenAISTTLanguage:          getEnv("OPENAI_STT_LANGUAGE", "ru"),
+		MockDelay:                  time.Duration(getEnvInt64("MOCK_DELAY_MS", 2000)) * time.Millisecond,
+		MockFixturesDir:            getEnv("MOCK_FIXTURES_DIR", ""),
+		MockFail:                   getEnv("MOCK_FAIL", ""),
+		KeepOriginalVideo:          getEnvBool("KEEP_ORIGINAL_VIDEO", false),
+		PreprocessingDefault:       getEnv("PREPROCESSING_DEFAULT", "none"),
 	}
 }
 
@@ -42,6 +125,33 @@ func (c *Config) HasObjectStorage() bool {
 		c.YandexStorageBucket != ""
 }
 
+// ProviderChain возвращает основной провайдер и провайдеры fallback без повторов.
+func (c *Config) ProviderChain() []string {
+	chain := []string{c.TranscriptionProvider}
+	for _, name := range strings.Split(c.TranscriptionFallback, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || contains(chain, name) {
+			continue
+		}
+		chain = append(chain, name)
+	}
+	return chain
+}
+
+// UsesProvider проверяет, входит ли провайдер в цепочку распознавания.
+func (c *Config) UsesProvider(name string) bool {
+	return contains(c.ProviderChain(), name)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func getEnv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -57,3 +167,31 @@ func getEnvInt64(key string, fallback int64) int64 {
 	}
 	return fallback
 }
+
+func getEnvBool(key string, fallback bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if parsed, err := strconv.ParseBool(val); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// getEnvDuration читает длительность в формате time.ParseDuration (например, 90s, 20m).
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if val := os.Getenv(key); val != "" {
+		if parsed, err := time.ParseDuration(val); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return fallback
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -139,3 +140,49 @@ func TestGetEnvInt64_EmptyValue(t *testing.T) {
 	// Empty string should return fallback
 	assert.Equal(t, int64(200), getEnvInt64("TEST_EMPTY_INT", 200))
 }
+
+func TestGetEnvBool(t *testing.T) {
+	os.Setenv("TEST_BOOL", "true")
+	os.Setenv("TEST_INVALID_BOOL", "maybe")
+	defer func() {
+		os.Unsetenv("TEST_BOOL")
+		os.Unsetenv("TEST_INVALID_BOOL")
+	}()
+
+	assert.True(t, getEnvBool("TEST_BOOL", false))
+	assert.True(t, getEnvBool("TEST_INVALID_BOOL", true))
+	assert.False(t, getEnvBool("NONEXISTENT_BOOL", false))
+}
+
+func TestGetEnvDuration(t *testing.T) {
+	os.Setenv("TEST_DURATION", "90s")
+	os.Setenv("TEST_INVALID_DURATION", "soon")
+	defer func() {
+		os.Unsetenv("TEST_DURATION")
+		os.Unsetenv("TEST_INVALID_DURATION")
+	}()
+
+	assert.Equal(t, 90*time.Second, getEnvDuration("TEST_DURATION", time.Minute))
+	assert.Equal(t, time.Minute, getEnvDuration("TEST_INVALID_DURATION", time.Minute))
+	assert.Equal(t, time.Minute, getEnvDuration("NONEXISTENT_DURATION", time.Minute))
+}
+
+func TestProviderChain(t *testing.T) {
+	cfg := Config{TranscriptionProvider: "whisper", TranscriptionFallback: " speechkit, whisper,,speechkit"}
+	assert.Equal(t, []string{"whisper", "speechkit"}, cfg.ProviderChain())
+	assert.True(t, cfg.UsesProvider("speechkit"))
+
+	cfg.TranscriptionFallback = ""
+	assert.Equal(t, []string{"whisper"}, cfg.ProviderChain())
+	assert.False(t, cfg.UsesProvider("speechkit"))
+}
+
+func TestLoad_SpeakerMatchThreshold(t *testing.T) {
+	os.Setenv("SPEAKER_MATCH_THRESHOLD", "0.65")
+	defer os.Unsetenv("SPEAKER_MATCH_THRESHOLD")
+
+	cfg := Load()
+
+	assert.Equal(t, 0.65, cfg.SpeakerMatchThreshold)
+	assert.True(t, cfg.SpeakerIdentification)
+}
@@ -0,0 +1,227 @@
+// Package glossary — словарь проекта: термины, которые подсказываются
+// провайдерам распознавания, и замены, исправляющие распознанный текст.
+package glossary
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ограничения словаря: подсказка провайдерам всё равно обрезается,
+// а каждая замена — отдельный проход по тексту сегмента.
+const (
+	MaxTerms        = 500
+	MaxReplacements = 500
+	MaxLength       = 100 // символов в термине и в каждой части замены
+	// maxPromptLength — длина подсказки в символах: Whisper учитывает
+	// не больше 224 токенов промпта, остальное отбрасывается.
+	maxPromptLength = 600
+)
+
+// Replacement заменяет From на To в тексте сегментов.
+// По умолчанию регистр не учитывается, а From совпадает только с целым словом
+// (или фразой): «лупа» не заменяется внутри «лупами».
+type Replacement struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// CaseSensitive — From совпадает только в том же регистре.
+	CaseSensitive bool `json:"caseSensitive,omitempty"`
+	// PartialWords — From заменяется и внутри слов.
+	PartialWords bool `json:"partialWords,omitempty"`
+}
+
+// Glossary — словарь проекта. Нулевое значение — словаря нет.
+type Glossary struct {
+	// Terms — имена, названия продуктов, аббревиатуры в правильном написании.
+	Terms        []string      `json:"terms,omitempty"`
+	Replacements []Replacement `json:"replacements,omitempty"`
+}
+
+// Validate проверяет размер словаря и непустые термины и замены.
+func (g Glossary) Validate() error {
+	if len(g.Terms) > MaxTerms {
+		return fmt.Errorf("glossary may contain at most %d terms", MaxTerms)
+	}
+	if len(g.Replacements) > MaxReplacements {
+		return fmt.Errorf("glossary may contain at most %d replacements", MaxReplacements)
+	}
+	for _, term := range g.Terms {
+		if err := checkText("term", term); err != nil {
+			return err
+		}
+	}
+	for _, r := range g.Replacements {
+		if err := checkText("replacement from", r.From); err != nil {
+			return err
+		}
+		if utf8.RuneCountInString(r.To) > MaxLength {
+			return fmt.Errorf("replacement to must be at most %d characters", MaxLength)
+		}
+	}
+	return nil
+}
+
+func checkText(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	if utf8.RuneCountInString(value) > MaxLength {
+		return fmt.Errorf("%s must be at most %d characters", field, MaxLength)
+	}
+	return nil
+}
+
+// IsZero сообщает, что словарь пуст.
+func (g Glossary) IsZero() bool {
+	return len(g.Terms) == 0 && len(g.Replacements) == 0
+}
+
+// Hints возвращает слова для подсказки провайдеру: термины и правильные
+// написания из замен, без повторов (без учёта регистра) в исходном порядке.
+func (g Glossary) Hints() []string {
+	var hints []string
+	seen := map[string]bool{}
+	add := func(value string) {
+		value = strings.TrimSpace(value)
+		key := strings.ToLower(value)
+		if value == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		hints = append(hints, value)
+	}
+	for _, term := range g.Terms {
+		add(term)
+	}
+	for _, r := range g.Replacements {
+		add(r.To)
+	}
+	return hints
+}
+
+// Prompt склеивает подсказки через запятую, пока они помещаются
+// в промпт Whisper. Пустая строка — подсказывать нечего.
+func (g Glossary) Prompt() string {
+	var prompt strings.Builder
+	length := 0
+	for _, hint := range g.Hints() {
+		n := utf8.RuneCountInString(hint)
+		if length > 0 {
+			n += 2
+		}
+		if length+n > maxPromptLength {
+			break
+		}
+		if length > 0 {
+			prompt.WriteString(", ")
+		}
+		prompt.WriteString(hint)
+		length += n
+	}
+	return prompt.String()
+}
+
+// Replacer применяет замены словаря по порядку.
+type Replacer struct {
+	rules []rule
+}
+
+type rule struct {
+	re *regexp.Regexp
+	to string
+	// Границы слова проверяются только со стороны букв и цифр From:
+	// у «C++» правая граница не нужна.
+	wordStart, wordEnd bool
+}
+
+// NewReplacer подготавливает замены. Пробелы внутри From совпадают
+// с любым количеством пробельных символов.
+func NewReplacer(replacements []Replacement) *Replacer {
+	r := &Replacer{}
+	for _, repl := range replacements {
+		words := strings.Fields(repl.From)
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+		pattern := strings.Join(words, `\s+`)
+		if !repl.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		from := strings.TrimSpace(repl.From)
+		first, _ := utf8.DecodeRuneInString(from)
+		last, _ := utf8.DecodeLastRuneInString(from)
+		r.rules = append(r.rules, rule{
+			re:        regexp.MustCompile(pattern),
+			to:        repl.To,
+			wordStart: !repl.PartialWords && isWordRune(first),
+			wordEnd:   !repl.PartialWords && isWordRune(last),
+		})
+	}
+	return r
+}
+
+// Empty сообщает, что заменять нечего.
+func (r *Replacer) Empty() bool {
+	return r == nil || len(r.rules) == 0
+}
+
+// Replace возвращает text с применёнными заменами.
+func (r *Replacer) Replace(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, rl := range r.rules {
+		text = rl.apply(text)
+	}
+	return text
+}
+
+func (rl rule) apply(text string) string {
+	var out strings.Builder
+	written, pos := 0, 0
+	for pos <= len(text) {
+		loc := rl.re.FindStringIndex(text[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if rl.atBoundary(text, start, end) {
+			out.WriteString(text[written:start])
+			out.WriteString(rl.to)
+			written, pos = end, end
+			continue
+		}
+		// Совпадение внутри слова: ищем дальше со следующего символа
+		_, size := utf8.DecodeRuneInString(text[start:])
+		pos = start + max(size, 1)
+	}
+	if written == 0 {
+		return text
+	}
+	out.WriteString(text[written:])
+	return out.String()
+}
+
+func (rl rule) atBoundary(text string, start, end int) bool {
+	if rl.wordStart && start > 0 {
+		if before, _ := utf8.DecodeLastRuneInString(text[:start]); isWordRune(before) {
+			return false
+		}
+	}
+	if rl.wordEnd && end < len(text) {
+		if after, _ := utf8.DecodeRuneInString(text[end:]); isWordRune(after) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,70 @@
+package glossary
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplacer_WholeWordsIgnoringCase(t *testing.T) {
+	r := NewReplacer([]Replacement{{From: "лупа", To: "Loopa"}})
+
+	assert.Equal(t, "Loopa умеет, Loopa!", r.Replace("Лупа умеет, лупа!"))
+	// Внутри других слов не заменяется
+	assert.Equal(t, "Лупанов с лупами", r.Replace("Лупанов с лупами"))
+	assert.Equal(t, "лупалупа Loopa", r.Replace("лупалупа лупа"))
+}
+
+func TestReplacer_CaseSensitiveAndPartial(t *testing.T) {
+	r := NewReplacer([]Replacement{
+		{From: "ML", To: "ML-сервис", CaseSensitive: true},
+		{From: "кликхаус", To: "ClickHouse", PartialWords: true},
+	})
+
+	assert.Equal(t, "ML-сервис и ml", r.Replace("ML и ml"))
+	assert.Equal(t, "в ClickHouseе", r.Replace("в Кликхаусе"))
+}
+
+func TestReplacer_PhrasesAndSymbols(t *testing.T) {
+	r := NewReplacer([]Replacement{
+		{From: "спич кит", To: "SpeechKit"},
+		{From: "си++", To: "C++"},
+	})
+
+	assert.Equal(t, "через SpeechKit.", r.Replace("через Спич  кит."))
+	// Справа от «++» граница слова не проверяется
+	assert.Equal(t, "на C++, не на си", r.Replace("на си++, не на си"))
+}
+
+func TestReplacer_Empty(t *testing.T) {
+	var r *Replacer
+	assert.True(t, r.Empty())
+	assert.Equal(t, "текст", r.Replace("текст"))
+	assert.True(t, NewReplacer(nil).Empty())
+}
+
+func TestGlossary_Prompt(t *testing.T) {
+	g := Glossary{
+		Terms:        []string{"Loopa", " pyannote ", "loopa"},
+		Replacements: []Replacement{{From: "спич кит", To: "SpeechKit"}, {From: "лупа", To: "Loopa"}},
+	}
+	assert.Equal(t, []string{"Loopa", "pyannote", "SpeechKit"}, g.Hints())
+	assert.Equal(t, "Loopa, pyannote, SpeechKit", g.Prompt())
+
+	long := Glossary{}
+	for i := 0; i < 100; i++ {
+		long.Terms = append(long.Terms, strings.Repeat("я", 20)+string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	assert.LessOrEqual(t, len([]rune(long.Prompt())), maxPromptLength)
+	assert.False(t, strings.HasSuffix(long.Prompt(), ", "))
+}
+
+func TestGlossary_Validate(t *testing.T) {
+	assert.NoError(t, Glossary{}.Validate())
+	assert.NoError(t, Glossary{Replacements: []Replacement{{From: "эм", To: ""}}}.Validate())
+	assert.Error(t, Glossary{Terms: []string{" "}}.Validate())
+	assert.Error(t, Glossary{Replacements: []Replacement{{From: "", To: "x"}}}.Validate())
+	assert.Error(t, Glossary{Terms: []string{strings.Repeat("a", MaxLength+1)}}.Validate())
+	assert.Error(t, Glossary{Terms: make([]string, MaxTerms+1)}.Validate())
+}
@@ -0,0 +1,96 @@
+package media
+
+import (
+	"bytes"
+	"strings"
+)
+
+// SniffLen — сколько байт из начала файла нужно для DetectContainer.
+const SniffLen = 4096
+
+// Container — формат контейнера, определённый по содержимому файла.
+type Container struct {
+	Name     string // короткое имя: mp3, wav, m4a, ...
+	MimeType string
+	// probeName — подстрока format_name из ffprobe, подтверждающая формат.
+	probeName string
+}
+
+var (
+	containerMP3  = Container{Name: "mp3", MimeType: "audio/mpeg", probeName: "mp3"}
+	containerWAV  = Container{Name: "wav", MimeType: "audio/wav", probeName: "wav"}
+	containerM4A  = Container{Name: "m4a", MimeType: "audio/mp4", probeName: "m4a"}
+	containerMP4  = Container{Name: "mp4", MimeType: "video/mp4", probeName: "mp4"}
+	containerMOV  = Container{Name: "mov", MimeType: "video/quicktime", probeName: "mov"}
+	container3GP  = Container{Name: "3gp", MimeType: "video/3gpp", probeName: "3gp"}
+	containerOGG  = Container{Name: "ogg", MimeType: "audio/ogg", probeName: "ogg"}
+	containerOpus = Container{Name: "opus", MimeType: "audio/opus", probeName: "ogg"}
+	containerFLAC = Container{Name: "flac", MimeType: "audio/flac", probeName: "flac"}
+	containerWebM = Container{Name: "webm", MimeType: "video/webm", probeName: "webm"}
+	containerMKV  = Container{Name: "mkv", MimeType: "video/x-matroska", probeName: "matroska"}
+	containerAAC  = Container{Name: "aac", MimeType: "audio/aac", probeName: "aac"}
+	containerAMR  = Container{Name: "amr", MimeType: "audio/amr", probeName: "amr"}
+	containerAMRW = Container{Name: "amr-wb", MimeType: "audio/amr-wb", probeName: "amr"}
+)
+
+// DetectContainer определяет формат по сигнатуре в начале файла.
+// Расширение и Content-Type клиента не учитываются.
+func DetectContainer(header []byte) (Container, bool) {
+	switch {
+	case bytes.HasPrefix(header, []byte("#!AMR-WB\n")):
+		return containerAMRW, true
+	case bytes.HasPrefix(header, []byte("#!AMR\n")):
+		return containerAMR, true
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return containerFLAC, true
+	case bytes.HasPrefix(header, []byte("OggS")):
+		if bytes.Contains(header, []byte("OpusHead")) {
+			return containerOpus, true
+		}
+		return containerOGG, true
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return containerWAV, true
+	case len(header) >= 12 && bytes.Equal(header[4:8], []byte("ftyp")):
+		return detectISOBrand(string(header[8:12])), true
+	case bytes.HasPrefix(header, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		if bytes.Contains(header, []byte("webm")) {
+			return containerWebM, true
+		}
+		return containerMKV, true
+	case bytes.HasPrefix(header, []byte("ID3")):
+		return containerMP3, true
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xF6 == 0xF0:
+		// ADTS: sync word 0xFFF, layer = 00
+		return containerAAC, true
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0 && header[1]&0x06 != 0:
+		// MPEG audio frame sync с ненулевым layer
+		return containerMP3, true
+	}
+	return Container{}, false
+}
+
+// detectISOBrand различает контейнеры семейства ISO BMFF по major brand.
+func detectISOBrand(brand string) Container {
+	switch {
+	case strings.HasPrefix(brand, "M4A"), strings.HasPrefix(brand, "M4B"):
+		return containerM4A
+	case brand == "qt  ":
+		return containerMOV
+	case strings.HasPrefix(brand, "3g"):
+		return container3GP
+	}
+	return containerMP4
+}
+
+// MatchesProbe проверяет, что ffprobe прочитал файл как тот же формат.
+func (c Container) MatchesProbe(info *MediaInfo) bool {
+	if c.probeName == "" || info == nil {
+		return false
+	}
+	for _, name := range strings.Split(info.FormatName, ",") {
+		if name == c.probeName {
+			return true
+		}
+	}
+	return false
+}
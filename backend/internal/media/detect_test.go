@@ -0,0 +1,75 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectContainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		expected string
+	}{
+		{"MP3 with ID3", []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), "mp3"},
+		{"MP3 frame sync", []byte{0xFF, 0xFB, 0x90, 0x64}, "mp3"},
+		{"AAC ADTS", []byte{0xFF, 0xF1, 0x50, 0x80}, "aac"},
+		{"WAV", []byte("RIFF\x24\x08\x00\x00WAVEfmt "), "wav"},
+		{"M4A", []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"), "m4a"},
+		{"MP4", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), "mp4"},
+		{"MOV", []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00"), "mov"},
+		{"3GP", []byte("\x00\x00\x00\x18ftyp3gp4\x00\x00\x00\x00"), "3gp"},
+		{"Ogg Vorbis", []byte("OggS\x00\x02\x00\x00\x01vorbis"), "ogg"},
+		{"Ogg Opus", []byte("OggS\x00\x02\x00\x00OpusHead"), "opus"},
+		{"FLAC", []byte("fLaC\x00\x00\x00\x22"), "flac"},
+		{"WebM", []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm"), "webm"},
+		{"MKV", []byte("\x1A\x45\xDF\xA3\xA3\x42\x86\x81\x01\x42\x82\x88matroska"), "mkv"},
+		{"AMR", []byte("#!AMR\n\x3c"), "amr"},
+		{"AMR-WB", []byte("#!AMR-WB\n\x04"), "amr-wb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := DetectContainer(tt.header)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, c.Name)
+			assert.NotEmpty(t, c.MimeType)
+		})
+	}
+}
+
+func TestDetectContainer_Rejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+	}{
+		{"PDF", []byte("%PDF-1.7\n")},
+		{"PNG", []byte("\x89PNG\r\n\x1a\n")},
+		{"JPEG", []byte{0xFF, 0xD8, 0xFF, 0xE0}},
+		{"ZIP", []byte("PK\x03\x04")},
+		{"Text", []byte("hello world")},
+		{"Empty", []byte{}},
+		{"RIFF without WAVE", []byte("RIFF\x24\x08\x00\x00AVI LIST")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := DetectContainer(tt.header)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestContainer_MatchesProbe(t *testing.T) {
+	m4a, _ := DetectContainer([]byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"))
+	webm, _ := DetectContainer([]byte("\x1A\x45\xDF\xA3webm"))
+	mp3, _ := DetectContainer([]byte("ID3"))
+
+	assert.True(t, m4a.MatchesProbe(&MediaInfo{FormatName: "mov,mp4,m4a,3gp,3g2,mj2"}))
+	assert.True(t, webm.MatchesProbe(&MediaInfo{FormatName: "matroska,webm"}))
+	assert.True(t, mp3.MatchesProbe(&MediaInfo{FormatName: "mp3"}))
+	assert.False(t, mp3.MatchesProbe(&MediaInfo{FormatName: "wav"}))
+	assert.False(t, mp3.MatchesProbe(nil))
+	assert.False(t, Container{}.MatchesProbe(&MediaInfo{FormatName: "mp3"}))
+}
@@ -14,24 +14,57 @@ import (
 // ExtractAudio извлекает аудио из медиафайла и конвертирует в OGG Opus.
 // Формат OGG Opus оптимален для Yandex SpeechKit.
 func ExtractAudio(inputPath, outputDir string) (string, error) {
-	outputName := fmt.Sprintf("%s.ogg", uuid.New().String())
-	outputPath := filepath.Join(outputDir, outputName)
+	return Preprocess(inputPath, outputDir, PreprocessOptions{})
+}
 
-	cmd := exec.Command(
-		"ffmpeg",
-		"-y",
-		"-i", inputPath,
-		"-vn",
+// encodeOpus перекодирует аудиодорожку в OGG Opus 48 кГц моно.
+// filter — необязательная цепочка аудиофильтров ffmpeg (-af),
+// limit — длительность результата в секундах (0 — без ограничения).
+func encodeOpus(inputPath, outputPath, filter string, limit float64) error {
+	args := []string{"-y", "-i", inputPath, "-vn"}
+	if filter != "" {
+		args = append(args, "-af", filter)
+	}
+	if limit > 0 {
+		args = append(args, "-t", strconv.FormatFloat(limit, 'f', 3, 64))
+	}
+	args = append(args,
 		"-acodec", "libopus",
 		"-ar", "48000",
 		"-ac", "1",
 		"-b:a", "64k",
 		outputPath,
 	)
+
+	cmd := exec.Command("ffmpeg", args...)
 	if out, err := cmd.CombinedOutput(); err != nil {
-		return "", fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
 	}
-	return outputPath, nil
+	return nil
+}
+
+// encodePCM перекодирует аудиодорожку в WAV PCM 16 кГц моно — формат,
+// который принимает whisper.cpp.
+func encodePCM(inputPath, outputPath, filter string, limit float64) error {
+	args := []string{"-y", "-i", inputPath, "-vn"}
+	if filter != "" {
+		args = append(args, "-af", filter)
+	}
+	if limit > 0 {
+		args = append(args, "-t", strconv.FormatFloat(limit, 'f', 3, 64))
+	}
+	args = append(args,
+		"-acodec", "pcm_s16le",
+		"-ar", "16000",
+		"-ac", "1",
+		outputPath,
+	)
+
+	cmd := exec.Command("ffmpeg", args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
 }
 
 // GetDuration возвращает длительность медиафайла в секундах.
@@ -56,41 +89,58 @@ func GetDuration(inputPath string) (float64, error) {
 	return duration, nil
 }
 
-// SplitAudio разбивает аудио на части указанной длительности (секунды).
-// Возвращает список путей к частям.
-func SplitAudio(inputPath, outputDir string, chunkDuration int) ([]string, error) {
-	duration, err := GetDuration(inputPath)
-	if err != nil {
-		return nil, err
+// SplitAudio нарезает аудио на части по заданным границам (см. PlanChunks)
+// за один проход ffmpeg через segment muxer.
+// Возвращает пути к частям в том же порядке.
+func SplitAudio(inputPath, outputDir string, chunks []Chunk) ([]string, error) {
+	if len(chunks) == 0 {
+		return nil, nil
 	}
 
-	var chunks []string
-	for start := 0.0; start < duration; start += float64(chunkDuration) {
-		chunkName := fmt.Sprintf("%s_chunk_%d.ogg", uuid.New().String(), int(start))
-		chunkPath := filepath.Join(outputDir, chunkName)
-
-		cmd := exec.Command(
-			"ffmpeg",
-			"-y",
-			"-i", inputPath,
-			"-ss", fmt.Sprintf("%.2f", start),
-			"-t", fmt.Sprintf("%d", chunkDuration),
-			"-vn",
-			"-acodec", "libopus",
-			"-ar", "48000",
-			"-ac", "1",
-			"-b:a", "64k",
-			chunkPath,
-		)
-		if out, err := cmd.CombinedOutput(); err != nil {
-			// Cleanup created chunks on error
-			for _, c := range chunks {
-				os.Remove(c)
-			}
-			return nil, fmt.Errorf("ffmpeg split failed: %w: %s", err, strings.TrimSpace(string(out)))
+	cutPoints := make([]string, 0, len(chunks)-1)
+	for _, chunk := range chunks[1:] {
+		cutPoints = append(cutPoints, fmt.Sprintf("%.3f", chunk.Start))
+	}
+
+	prefix := uuid.New().String()
+	pattern := filepath.Join(outputDir, prefix+"_chunk_%04d.ogg")
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-vn",
+		"-acodec", "libopus",
+		"-ar", "48000",
+		"-ac", "1",
+		"-b:a", "64k",
+		"-f", "segment",
+		"-segment_format", "ogg",
+		"-reset_timestamps", "1",
+	}
+	if len(cutPoints) > 0 {
+		args = append(args, "-segment_times", strings.Join(cutPoints, ","))
+	}
+	args = append(args, pattern)
+
+	cmd := exec.Command("ffmpeg", args...)
+	out, err := cmd.CombinedOutput()
+
+	var paths []string
+	for i := range chunks {
+		path := filepath.Join(outputDir, fmt.Sprintf("%s_chunk_%04d.ogg", prefix, i))
+		if _, statErr := os.Stat(path); statErr != nil {
+			break
+		}
+		paths = append(paths, path)
+	}
+
+	if err != nil {
+		// Cleanup created chunks on error
+		for _, c := range paths {
+			os.Remove(c)
 		}
-		chunks = append(chunks, chunkPath)
+		return nil, fmt.Errorf("ffmpeg split failed: %w: %s", err, strings.TrimSpace(string(out)))
 	}
 
-	return chunks, nil
+	return paths, nil
 }
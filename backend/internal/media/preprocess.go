@@ -0,0 +1,137 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PreprocessOptions — цепочка фильтров ffmpeg, применяемая к аудио перед распознаванием.
+// Нулевое значение означает простую конвертацию без фильтров.
+type PreprocessOptions struct {
+	// Channel — номер канала (с 1), который берётся вместо downmix всех каналов.
+	Channel    int  `json:"channel,omitempty"`
+	HighpassHz int  `json:"highpassHz,omitempty"`
+	LowpassHz  int  `json:"lowpassHz,omitempty"`
+	Denoise    bool `json:"denoise,omitempty"`
+	// TrimSilence обрезает тишину в конце записи. Начало не трогаем,
+	// чтобы таймкоды сегментов совпадали с исходным файлом.
+	TrimSilence bool `json:"trimSilence,omitempty"`
+	Loudnorm    bool `json:"loudnorm,omitempty"`
+}
+
+// Presets — готовые наборы фильтров, доступные по имени.
+var Presets = map[string]PreprocessOptions{
+	"none":      {},
+	"normalize": {Loudnorm: true},
+	"phone":     {HighpassHz: 200, LowpassHz: 3400, Denoise: true, Loudnorm: true},
+	"noisy":     {HighpassHz: 80, Denoise: true, TrimSilence: true, Loudnorm: true},
+}
+
+// ParsePreprocess принимает имя пресета или JSON-объект PreprocessOptions.
+func ParsePreprocess(value string) (PreprocessOptions, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return PreprocessOptions{}, nil
+	}
+	if strings.HasPrefix(value, "{") {
+		var opts PreprocessOptions
+		if err := json.Unmarshal([]byte(value), &opts); err != nil {
+			return PreprocessOptions{}, fmt.Errorf("invalid preprocessing options: %w", err)
+		}
+		return opts, opts.Validate()
+	}
+	opts, ok := Presets[value]
+	if !ok {
+		return PreprocessOptions{}, fmt.Errorf("unknown preprocessing preset %q", value)
+	}
+	return opts, nil
+}
+
+// Validate проверяет диапазоны параметров фильтров.
+func (o PreprocessOptions) Validate() error {
+	if o.Channel < 0 || o.Channel > 8 {
+		return fmt.Errorf("channel must be between 1 and 8")
+	}
+	if o.HighpassHz < 0 || o.HighpassHz > 4000 {
+		return fmt.Errorf("highpassHz must be between 0 and 4000")
+	}
+	if o.LowpassHz < 0 || (o.LowpassHz > 0 && o.LowpassHz < 1000) || o.LowpassHz > 24000 {
+		return fmt.Errorf("lowpassHz must be between 1000 and 24000")
+	}
+	if o.HighpassHz > 0 && o.LowpassHz > 0 && o.HighpassHz >= o.LowpassHz {
+		return fmt.Errorf("highpassHz must be below lowpassHz")
+	}
+	return nil
+}
+
+// IsZero сообщает, что фильтры не заданы.
+func (o PreprocessOptions) IsZero() bool {
+	return o == PreprocessOptions{}
+}
+
+// filterChain собирает значение -af для ffmpeg.
+func (o PreprocessOptions) filterChain() string {
+	var filters []string
+	if o.Channel > 0 {
+		filters = append(filters, fmt.Sprintf("pan=mono|c0=c%d", o.Channel-1))
+	}
+	if o.HighpassHz > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", o.HighpassHz))
+	}
+	if o.LowpassHz > 0 {
+		filters = append(filters, fmt.Sprintf("lowpass=f=%d", o.LowpassHz))
+	}
+	if o.Denoise {
+		filters = append(filters, "afftdn=nf=-25")
+	}
+	if o.Loudnorm {
+		filters = append(filters, "loudnorm=I=-16:TP=-1.5:LRA=11")
+	}
+	return strings.Join(filters, ",")
+}
+
+// Тишина в конце записи для TrimSilence.
+const (
+	trimThresholdDB   = -50
+	trimMinSilenceSec = 0.5
+)
+
+// outputLimit возвращает длительность результата для TrimSilence: запись
+// обрезается по началу тишины в конце (0 — обрезать нечего). Тишина ищется
+// отдельным проходом silencedetect: реверс потока держал бы в памяти всю запись.
+func (o PreprocessOptions) outputLimit(inputPath string) (float64, error) {
+	if !o.TrimSilence {
+		return 0, nil
+	}
+	return TrailingSilence(inputPath, trimThresholdDB, trimMinSilenceSec)
+}
+
+// Preprocess применяет фильтры и конвертирует результат в OGG Opus (как ExtractAudio).
+func Preprocess(inputPath, outputDir string, opts PreprocessOptions) (string, error) {
+	limit, err := opts.outputLimit(inputPath)
+	if err != nil {
+		return "", err
+	}
+	outputPath := filepath.Join(outputDir, fmt.Sprintf("%s.ogg", uuid.New().String()))
+	if err := encodeOpus(inputPath, outputPath, opts.filterChain(), limit); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// PreprocessWAV применяет фильтры и конвертирует результат в WAV 16 кГц моно.
+func PreprocessWAV(inputPath, outputDir string, opts PreprocessOptions) (string, error) {
+	limit, err := opts.outputLimit(inputPath)
+	if err != nil {
+		return "", err
+	}
+	outputPath := filepath.Join(outputDir, fmt.Sprintf("%s.wav", uuid.New().String()))
+	if err := encodePCM(inputPath, outputPath, opts.filterChain(), limit); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
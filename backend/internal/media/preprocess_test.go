@@ -0,0 +1,53 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePreprocess_Preset(t *testing.T) {
+	opts, err := ParsePreprocess("phone")
+	require.NoError(t, err)
+	assert.Equal(t, Presets["phone"], opts)
+
+	opts, err = ParsePreprocess("")
+	require.NoError(t, err)
+	assert.True(t, opts.IsZero())
+}
+
+func TestParsePreprocess_JSON(t *testing.T) {
+	opts, err := ParsePreprocess(`{"channel": 2, "highpassHz": 150, "loudnorm": true}`)
+	require.NoError(t, err)
+	assert.Equal(t, PreprocessOptions{Channel: 2, HighpassHz: 150, Loudnorm: true}, opts)
+}
+
+func TestParsePreprocess_Invalid(t *testing.T) {
+	tests := []string{
+		"unknown-preset",
+		`{"channel": -1}`,
+		`{"highpassHz": 3000, "lowpassHz": 2000}`,
+		`{"lowpassHz": 50}`,
+		`{"loudnorm": "yes"}`,
+	}
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			_, err := ParsePreprocess(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPreprocessOptions_FilterChain(t *testing.T) {
+	assert.Equal(t, "", PreprocessOptions{}.filterChain())
+	assert.Equal(t, "loudnorm=I=-16:TP=-1.5:LRA=11", Presets["normalize"].filterChain())
+
+	opts := PreprocessOptions{Channel: 1, HighpassHz: 200, LowpassHz: 3400, Denoise: true, Loudnorm: true}
+	assert.Equal(t,
+		"pan=mono|c0=c0,highpass=f=200,lowpass=f=3400,afftdn=nf=-25,loudnorm=I=-16:TP=-1.5:LRA=11",
+		opts.filterChain(),
+	)
+	// Тишина в конце обрезается через -t, а не фильтром
+	assert.Equal(t, "highpass=f=80,afftdn=nf=-25,loudnorm=I=-16:TP=-1.5:LRA=11", Presets["noisy"].filterChain())
+}
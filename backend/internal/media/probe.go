@@ -0,0 +1,113 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// MediaInfo описывает медиафайл по данным ffprobe.
+type MediaInfo struct {
+	FormatName string
+	Duration   float64 // секунды
+	BitRate    int64   // бит/с
+	AudioCodec string
+	SampleRate int
+	Channels   int
+	HasAudio   bool
+	HasVideo   bool
+}
+
+type probeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
+		SampleRate  string `json:"sample_rate"`
+		Channels    int    `json:"channels"`
+		BitRate     string `json:"bit_rate"`
+		Duration    string `json:"duration"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// Probe читает параметры медиафайла через ffprobe.
+func Probe(inputPath string) (*MediaInfo, error) {
+	cmd := exec.Command(
+		"ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		inputPath,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	return parseProbeOutput(out)
+}
+
+// parseProbeOutput разбирает JSON-вывод ffprobe.
+// Берётся первая аудиодорожка; обложки (attached_pic) видео не считаются.
+func parseProbeOutput(data []byte) (*MediaInfo, error) {
+	var out probeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	info := &MediaInfo{
+		FormatName: out.Format.FormatName,
+		Duration:   parseFloat(out.Format.Duration),
+		BitRate:    parseInt(out.Format.BitRate),
+	}
+
+	for _, st := range out.Streams {
+		switch st.CodecType {
+		case "audio":
+			if info.HasAudio {
+				continue
+			}
+			info.HasAudio = true
+			info.AudioCodec = st.CodecName
+			info.SampleRate = int(parseInt(st.SampleRate))
+			info.Channels = st.Channels
+			if br := parseInt(st.BitRate); br > 0 {
+				info.BitRate = br
+			}
+			if info.Duration == 0 {
+				info.Duration = parseFloat(st.Duration)
+			}
+		case "video":
+			if st.Disposition.AttachedPic == 0 {
+				info.HasVideo = true
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func parseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func parseInt(s string) int64 {
+	v, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
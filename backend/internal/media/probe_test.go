@@ -0,0 +1,64 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProbeOutput_Audio(t *testing.T) {
+	data := []byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "mp3", "sample_rate": "44100", "channels": 2, "bit_rate": "128000"},
+			{"codec_type": "video", "codec_name": "mjpeg", "disposition": {"attached_pic": 1}}
+		],
+		"format": {"format_name": "mp3", "duration": "125.431000", "bit_rate": "130000"}
+	}`)
+
+	info, err := parseProbeOutput(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "mp3", info.FormatName)
+	assert.InDelta(t, 125.431, info.Duration, 0.0001)
+	assert.Equal(t, int64(128000), info.BitRate)
+	assert.Equal(t, "mp3", info.AudioCodec)
+	assert.Equal(t, 44100, info.SampleRate)
+	assert.Equal(t, 2, info.Channels)
+	assert.True(t, info.HasAudio)
+	assert.False(t, info.HasVideo, "cover art is not a video stream")
+}
+
+func TestParseProbeOutput_Video(t *testing.T) {
+	data := []byte(`{
+		"streams": [
+			{"codec_type": "video", "codec_name": "h264"},
+			{"codec_type": "audio", "codec_name": "aac", "sample_rate": "48000", "channels": 1, "duration": "60.0"}
+		],
+		"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "bit_rate": "900000"}
+	}`)
+
+	info, err := parseProbeOutput(data)
+	require.NoError(t, err)
+
+	assert.True(t, info.HasVideo)
+	assert.True(t, info.HasAudio)
+	assert.Equal(t, "aac", info.AudioCodec)
+	assert.InDelta(t, 60.0, info.Duration, 0.0001)
+	assert.Equal(t, int64(900000), info.BitRate)
+}
+
+func TestParseProbeOutput_NoAudio(t *testing.T) {
+	data := []byte(`{"streams": [{"codec_type": "video", "codec_name": "h264"}], "format": {"duration": "3.0"}}`)
+
+	info, err := parseProbeOutput(data)
+	require.NoError(t, err)
+
+	assert.False(t, info.HasAudio)
+	assert.True(t, info.HasVideo)
+}
+
+func TestParseProbeOutput_Invalid(t *testing.T) {
+	_, err := parseProbeOutput([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package media
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Silence — интервал тишины в секундах.
+type Silence struct {
+	Start float64
+	End   float64
+}
+
+// Chunk — часть аудио для распознавания, границы в секундах от начала файла.
+type Chunk struct {
+	Start float64
+	End   float64
+}
+
+// Duration возвращает длительность части в секундах.
+func (c Chunk) Duration() float64 {
+	return c.End - c.Start
+}
+
+var (
+	silenceStartRe = regexp.MustCompile(`silence_start:\s*(-?[0-9.]+)`)
+	silenceEndRe   = regexp.MustCompile(`silence_end:\s*(-?[0-9.]+)`)
+)
+
+// DetectSilences находит паузы через фильтр silencedetect.
+// thresholdDB — уровень тишины (например, -35), minDuration — минимальная пауза в секундах.
+func DetectSilences(inputPath string, thresholdDB, minDuration float64) ([]Silence, error) {
+	out, err := silenceDetect(inputPath, thresholdDB, minDuration)
+	if err != nil {
+		return nil, err
+	}
+	return parseSilenceDetect(out), nil
+}
+
+// TrailingSilence возвращает начало тишины, которой заканчивается запись,
+// в секундах. 0 — запись заканчивается звуком или целиком состоит из тишины.
+func TrailingSilence(inputPath string, thresholdDB, minDuration float64) (float64, error) {
+	duration, err := GetDuration(inputPath)
+	if err != nil {
+		return 0, err
+	}
+	out, err := silenceDetect(inputPath, thresholdDB, minDuration)
+	if err != nil {
+		return 0, err
+	}
+	return parseTrailingSilence(out, duration), nil
+}
+
+func silenceDetect(inputPath string, thresholdDB, minDuration float64) (string, error) {
+	cmd := exec.Command(
+		"ffmpeg",
+		"-hide_banner",
+		"-nostats",
+		"-i", inputPath,
+		"-vn",
+		"-af", fmt.Sprintf("silencedetect=noise=%gdB:d=%g", thresholdDB, minDuration),
+		"-f", "null",
+		"-",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg silencedetect failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// parseSilenceDetect разбирает вывод silencedetect.
+// Незакрытая пауза в конце файла отбрасывается — резать по ней нечего.
+func parseSilenceDetect(output string) []Silence {
+	var silences []Silence
+	start := -1.0
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := silenceStartRe.FindStringSubmatch(line); m != nil {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				start = v
+				if start < 0 {
+					start = 0
+				}
+			}
+			continue
+		}
+		if m := silenceEndRe.FindStringSubmatch(line); m != nil && start >= 0 {
+			if end, err := strconv.ParseFloat(m[1], 64); err == nil && end > start {
+				silences = append(silences, Silence{Start: start, End: end})
+			}
+			start = -1
+		}
+	}
+	return silences
+}
+
+// trailingSilenceSlack — допуск в секундах, с которым конец паузы считается
+// концом записи: ffmpeg закрывает паузу на последнем кадре.
+const trailingSilenceSlack = 0.1
+
+// parseTrailingSilence находит в выводе silencedetect паузу, которая длится
+// до конца записи: незакрытую или закрытую в пределах trailingSilenceSlack
+// от duration.
+func parseTrailingSilence(output string, duration float64) float64 {
+	start, lastStart, lastEnd := -1.0, -1.0, -1.0
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := silenceStartRe.FindStringSubmatch(line); m != nil {
+			if v, err := strconv.ParseFloat(m[1], 64); err == nil {
+				start = math.Max(v, 0)
+			}
+			continue
+		}
+		if m := silenceEndRe.FindStringSubmatch(line); m != nil && start >= 0 {
+			if end, err := strconv.ParseFloat(m[1], 64); err == nil {
+				lastStart, lastEnd = start, end
+			}
+			start = -1
+		}
+	}
+	switch {
+	case start > 0:
+		return start
+	case lastStart > 0 && lastEnd >= duration-trailingSilenceSlack:
+		return lastStart
+	}
+	return 0
+}
+
+// minChunkDuration — минимальная длительность последней части в секундах.
+// Более короткий хвост ffmpeg может не выделить в отдельный файл, если
+// аудиопоток кончается раньше длительности контейнера.
+const minChunkDuration = 1.0
+
+// PlanChunks делит запись на части не длиннее maxLen секунд, разрезая по паузам.
+// Из пауз во второй половине допустимого окна выбирается самая длинная,
+// иначе — самая поздняя не раньше четверти окна; без пауз запись режется ровно по maxLen.
+// Разрезы ставятся не ближе minChunkDuration к концу записи: хвост остаётся
+// в предыдущей части.
+func PlanChunks(duration float64, silences []Silence, maxLen float64) []Chunk {
+	if duration <= 0 || maxLen <= 0 {
+		return nil
+	}
+
+	var chunks []Chunk
+	start := 0.0
+	for duration-start > maxLen {
+		limit := math.Min(start+maxLen, duration-minChunkDuration)
+		if limit <= start {
+			limit = start + maxLen
+		}
+		cut := limit
+
+		best := -1.0
+		bestLen := 0.0
+		latest := -1.0
+		for _, s := range silences {
+			mid := (s.Start + s.End) / 2
+			if mid <= start || mid > limit {
+				continue
+			}
+			if mid >= start+maxLen/4 && mid > latest {
+				latest = mid
+			}
+			if mid >= start+maxLen/2 && s.End-s.Start > bestLen {
+				best = mid
+				bestLen = s.End - s.Start
+			}
+		}
+		if best > 0 {
+			cut = best
+		} else if latest > 0 {
+			cut = latest
+		}
+
+		chunks = append(chunks, Chunk{Start: start, End: cut})
+		start = cut
+	}
+	chunks = append(chunks, Chunk{Start: start, End: duration})
+	return chunks
+}
@@ -0,0 +1,105 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSilenceDetect(t *testing.T) {
+	output := `Input #0, ogg, from 'in.ogg':
+[silencedetect @ 0x5581] silence_start: -0.0123
+[silencedetect @ 0x5581] silence_end: 1.2 | silence_duration: 1.2123
+size=N/A time=00:00:10.00 bitrate=N/A
+[silencedetect @ 0x5581] silence_start: 12.5
+[silencedetect @ 0x5581] silence_end: 13.25 | silence_duration: 0.75
+[silencedetect @ 0x5581] silence_start: 58.1`
+
+	silences := parseSilenceDetect(output)
+
+	assert.Equal(t, []Silence{
+		{Start: 0, End: 1.2},
+		{Start: 12.5, End: 13.25},
+	}, silences)
+}
+
+func TestParseTrailingSilence(t *testing.T) {
+	// Пауза в конце не закрыта
+	assert.Equal(t, 58.1, parseTrailingSilence(`[silencedetect @ 0x5581] silence_start: 12.5
+[silencedetect @ 0x5581] silence_end: 13.25 | silence_duration: 0.75
+[silencedetect @ 0x5581] silence_start: 58.1`, 60))
+
+	// ffmpeg закрыл паузу на последнем кадре
+	assert.Equal(t, 58.1, parseTrailingSilence(`[silencedetect @ 0x5581] silence_start: 58.1
+[silencedetect @ 0x5581] silence_end: 59.98 | silence_duration: 1.88`, 60))
+
+	// Запись заканчивается звуком
+	assert.Equal(t, 0.0, parseTrailingSilence(`[silencedetect @ 0x5581] silence_start: 12.5
+[silencedetect @ 0x5581] silence_end: 13.25 | silence_duration: 0.75`, 60))
+
+	// Тишина во всей записи — обрезать нечего
+	assert.Equal(t, 0.0, parseTrailingSilence(`[silencedetect @ 0x5581] silence_start: -0.01`, 60))
+}
+
+func TestPlanChunks_ShortAudio(t *testing.T) {
+	chunks := PlanChunks(20, nil, 29)
+	assert.Equal(t, []Chunk{{Start: 0, End: 20}}, chunks)
+}
+
+func TestPlanChunks_NoSilences(t *testing.T) {
+	chunks := PlanChunks(70, nil, 29)
+	assert.Equal(t, []Chunk{
+		{Start: 0, End: 29},
+		{Start: 29, End: 58},
+		{Start: 58, End: 70},
+	}, chunks)
+}
+
+func TestPlanChunks_NoShortTail(t *testing.T) {
+	// Разрез по паузе оставил бы хвост в 0.2 с — пауза пропускается
+	chunks := PlanChunks(29.3, []Silence{{Start: 29, End: 29.2}}, 29)
+	assert.Equal(t, []Chunk{{Start: 0, End: 28.3}, {Start: 28.3, End: 29.3}}, chunks)
+
+	// Без пауз граница сдвигается назад
+	chunks = PlanChunks(58.2, nil, 29)
+	assert.Equal(t, []Chunk{
+		{Start: 0, End: 29},
+		{Start: 29, End: 57.2},
+		{Start: 57.2, End: 58.2},
+	}, chunks)
+	for _, c := range chunks {
+		assert.GreaterOrEqual(t, c.Duration(), minChunkDuration)
+	}
+}
+
+func TestPlanChunks_CutsAtLongestPause(t *testing.T) {
+	silences := []Silence{
+		{Start: 5, End: 5.5},     // слишком рано — не выбирается, есть пауза позже
+		{Start: 20, End: 21},     // длинная пауза во второй половине окна
+		{Start: 27.8, End: 28.2}, // поздняя, но короткая
+		{Start: 40, End: 40.4},
+	}
+
+	chunks := PlanChunks(60, silences, 29)
+
+	assert.Equal(t, []Chunk{
+		{Start: 0, End: 20.5},
+		{Start: 20.5, End: 40.2},
+		{Start: 40.2, End: 60},
+	}, chunks)
+	for _, c := range chunks {
+		assert.LessOrEqual(t, c.Duration(), 29.0)
+	}
+}
+
+func TestPlanChunks_FallsBackToLatestPause(t *testing.T) {
+	silences := []Silence{{Start: 9, End: 10}}
+
+	chunks := PlanChunks(40, silences, 29)
+
+	assert.Equal(t, []Chunk{
+		{Start: 0, End: 9.5},
+		{Start: 9.5, End: 38.5},
+		{Start: 38.5, End: 40},
+	}, chunks)
+}
@@ -2,19 +2,26 @@ package mlclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	timeouts   Timeouts
 }
 
 type DiarizationSegment struct {
@@ -29,6 +36,25 @@ type DiarizationResponse struct {
 	NumSpeakers int                  `json:"num_speakers"`
 }
 
+// SpeakerTurn — реплика спикера для расчёта эмбеддинга голоса (секунды).
+type SpeakerTurn struct {
+	Speaker string  `json:"speaker"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+}
+
+// SpeakerEmbedding — L2-нормированный эмбеддинг голоса спикера.
+// Duration — длительность реплик, по которым он посчитан (секунды).
+type SpeakerEmbedding struct {
+	Speaker   string    `json:"speaker"`
+	Embedding []float64 `json:"embedding"`
+	Duration  float64   `json:"duration"`
+}
+
+type SpeakerEmbeddingsResponse struct {
+	Speakers []SpeakerEmbedding `json:"speakers"`
+}
+
 type TextSegment struct {
 	Text         string   `json:"text"`
 	HasFillers   bool     `json:"has_fillers"`
@@ -42,9 +68,10 @@ type TextProcessResponse struct {
 }
 
 type TextProcessRequest struct {
-	Text          string `json:"text"`
-	DetectFillers bool   `json:"detect_fillers"`
-	RemoveFillers bool   `json:"remove_fillers"`
+	Text          string   `json:"text"`
+	Texts         []string `json:"texts,omitempty"`
+	DetectFillers bool     `json:"detect_fillers"`
+	RemoveFillers bool     `json:"remove_fillers"`
 }
 
 type WordTimestamp struct {
@@ -58,9 +85,12 @@ type TranscribeSegment struct {
 	Start        float64         `json:"start"`
 	End          float64         `json:"end"`
 	Text         string          `json:"text"`
-	Words        []WordTimestamp  `json:"words"`
+	Words        []WordTimestamp `json:"words"`
 	HasFillers   bool            `json:"has_fillers"`
 	FillersFound []string        `json:"fillers_found"`
+	// CleanedText — текст без слов-паразитов; transcribe-full его не возвращает,
+	// worker заполняет по ответу process-text. nil — не определён.
+	CleanedText *string `json:"cleaned_text,omitempty"`
 }
 
 type TranscribeFullResponse struct {
@@ -71,173 +101,353 @@ type TranscribeFullResponse struct {
 	ProcessingTimeSeconds float64             `json:"processing_time_seconds"`
 }
 
-func New(baseURL string) *Client {
-	return &Client{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Minute,
-		},
+// Timeouts — ограничения времени для каждого эндпоинта ML-сервиса.
+// Нулевые поля заменяются значениями DefaultTimeouts.
+type Timeouts struct {
+	Diarize        time.Duration
+	TranscribeFull time.Duration
+	ProcessText    time.Duration
+	// SpeakerEmbeddings — эмбеддинги голосов спикеров записи.
+	SpeakerEmbeddings time.Duration
+	Health            time.Duration
+	// SubmitJob — загрузка файла при постановке задачи в очередь.
+	SubmitJob time.Duration
+	// Job — запросы статуса, результата и отмены задачи.
+	Job time.Duration
+}
+
+// DefaultTimeouts возвращает ограничения по умолчанию: распознавание часовой
+// записи на CPU занимает десятки минут, обработка текста — секунды.
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Diarize:           20 * time.Minute,
+		TranscribeFull:    60 * time.Minute,
+		ProcessText:       time.Minute,
+		SpeakerEmbeddings: 10 * time.Minute,
+		Health:            5 * time.Second,
+		SubmitJob:         10 * time.Minute,
+		Job:               time.Minute,
 	}
 }
 
-// Diarize отправляет аудиофайл на диаризацию.
-func (c *Client) Diarize(audioPath string) (*DiarizationResponse, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// APIError — ответ ML-сервиса с кодом, отличным от 200.
+// Detail — поле detail из ответа FastAPI (или тело ответа, если его нет).
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	Detail     string
+}
 
-	file, err := os.Open(audioPath)
-	if err != nil {
-		return nil, fmt.Errorf("open audio file: %w", err)
-	}
-	defer file.Close()
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s error: status %d: %s", e.Endpoint, e.StatusCode, e.Detail)
+}
 
-	part, err := writer.CreateFormFile("audio", filepath.Base(audioPath))
-	if err != nil {
-		return nil, fmt.Errorf("create form file: %w", err)
+func New(baseURL string) *Client {
+	return NewWithTimeouts(baseURL, Timeouts{})
+}
+
+// NewWithTimeouts создаёт клиент с собственными ограничениями времени запросов.
+func NewWithTimeouts(baseURL string, timeouts Timeouts) *Client {
+	defaults := DefaultTimeouts()
+	if timeouts.Diarize <= 0 {
+		timeouts.Diarize = defaults.Diarize
 	}
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("copy audio data: %w", err)
+	if timeouts.TranscribeFull <= 0 {
+		timeouts.TranscribeFull = defaults.TranscribeFull
 	}
-	writer.Close()
-
-	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/diarize", body)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+	if timeouts.ProcessText <= 0 {
+		timeouts.ProcessText = defaults.ProcessText
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("diarize request failed: %w", err)
+	if timeouts.SpeakerEmbeddings <= 0 {
+		timeouts.SpeakerEmbeddings = defaults.SpeakerEmbeddings
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+	if timeouts.Health <= 0 {
+		timeouts.Health = defaults.Health
+	}
+	if timeouts.SubmitJob <= 0 {
+		timeouts.SubmitJob = defaults.SubmitJob
+	}
+	if timeouts.Job <= 0 {
+		timeouts.Job = defaults.Job
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("diarize error: status %d, body: %s", resp.StatusCode, string(respBody))
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		// Общий таймаут не задаётся: время ограничивается контекстом каждого запроса
+		httpClient: &http.Client{},
+		timeouts:   timeouts,
 	}
+}
+
+// Diarize отправляет аудиофайл на диаризацию.
+func (c *Client) Diarize(ctx context.Context, audioPath string) (*DiarizationResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.Diarize)
+	defer cancel()
 
 	var result DiarizationResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("parse diarize response: %w", err)
+	if err := c.postAudio(ctx, "/diarize", c.baseURL+"/diarize", audioPath, nil, &result); err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
+// SpeakerEmbeddings считает эмбеддинги голосов спикеров по их репликам.
+// Спикеры без достаточно длинных реплик в ответ не попадают.
+func (c *Client) SpeakerEmbeddings(ctx context.Context, audioPath string, turns []SpeakerTurn) ([]SpeakerEmbedding, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.SpeakerEmbeddings)
+	defer cancel()
+
+	if turns == nil {
+		turns = []SpeakerTurn{}
+	}
+	turnsJSON, err := json.Marshal(turns)
+	if err != nil {
+		return nil, fmt.Errorf("marshal turns: %w", err)
+	}
+
+	var result SpeakerEmbeddingsResponse
+	if err := c.postAudio(ctx, "/speaker-embeddings", c.baseURL+"/speaker-embeddings", audioPath,
+		map[string]string{"turns": string(turnsJSON)}, &result); err != nil {
+		return nil, err
+	}
+	return result.Speakers, nil
+}
+
 // ProcessText отправляет текст на обработку (определение паразитов).
-func (c *Client) ProcessText(text string, detectFillers, removeFillers bool) (*TextProcessResponse, error) {
-	reqBody := TextProcessRequest{
+func (c *Client) ProcessText(ctx context.Context, text string, detectFillers, removeFillers bool) (*TextProcessResponse, error) {
+	return c.processText(ctx, TextProcessRequest{
 		Text:          text,
 		DetectFillers: detectFillers,
 		RemoveFillers: removeFillers,
+	})
+}
+
+// ProcessSegments обрабатывает готовые сегменты одним запросом.
+// Ответ содержит по одному TextSegment на каждый входной текст, в том же порядке.
+func (c *Client) ProcessSegments(ctx context.Context, texts []string, detectFillers, removeFillers bool) (*TextProcessResponse, error) {
+	if texts == nil {
+		texts = []string{}
+	}
+	result, err := c.processText(ctx, TextProcessRequest{
+		Texts:         texts,
+		DetectFillers: detectFillers,
+		RemoveFillers: removeFillers,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Segments) != len(texts) {
+		return nil, fmt.Errorf("process-text returned %d segments for %d texts", len(result.Segments), len(texts))
 	}
+	return result, nil
+}
+
+func (c *Client) processText(ctx context.Context, reqBody TextProcessRequest) (*TextProcessResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.ProcessText)
+	defer cancel()
+
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/process-text", bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/process-text", bytes.NewReader(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("process-text request failed: %w", err)
+	var result TextProcessResponse
+	if err := c.do(req, "/process-text", &result); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &result, nil
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
+// TranscribeFull отправляет аудиофайл на полный pipeline: транскрибация + диаризация + alignment.
+// hotwords — подсказка Whisper (термины словаря проекта); пустая строка — без подсказки.
+func (c *Client) TranscribeFull(ctx context.Context, audioPath string, language string, numSpeakers *int, detectFillers bool, hotwords string) (*TranscribeFullResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.TranscribeFull)
+	defer cancel()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("process-text error: status %d, body: %s", resp.StatusCode, string(respBody))
+	query := transcribeQuery(language, numSpeakers, detectFillers)
+
+	var result TranscribeFullResponse
+	if err := c.postAudio(ctx, "/transcribe-full", c.baseURL+"/transcribe-full?"+query, audioPath, transcribeFields(hotwords), &result); err != nil {
+		return nil, err
 	}
+	return &result, nil
+}
 
-	var result TextProcessResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("parse response: %w", err)
+// transcribeQuery формирует query-параметры полного pipeline.
+func transcribeQuery(language string, numSpeakers *int, detectFillers bool) string {
+	query := url.Values{}
+	query.Set("detect_fillers", strconv.FormatBool(detectFillers))
+	if language != "" {
+		query.Set("language", language)
+	}
+	if numSpeakers != nil {
+		query.Set("num_speakers", strconv.Itoa(*numSpeakers))
 	}
+	return query.Encode()
+}
 
-	return &result, nil
+// transcribeFields — поля формы полного pipeline. Подсказка передаётся в теле,
+// а не в query: словарь может быть длинным.
+func transcribeFields(hotwords string) map[string]string {
+	if hotwords == "" {
+		return nil
+	}
+	return map[string]string{"hotwords": hotwords}
 }
 
-// TranscribeFull отправляет аудиофайл на полный pipeline: транскрибация + диаризация + alignment.
-func (c *Client) TranscribeFull(audioPath string, language string, numSpeakers *int, detectFillers bool) (*TranscribeFullResponse, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+// Health проверяет доступность ML-сервиса.
+func (c *Client) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.Health)
+	defer cancel()
 
-	file, err := os.Open(audioPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
 	if err != nil {
-		return nil, fmt.Errorf("open audio file: %w", err)
+		return fmt.Errorf("create request: %w", err)
 	}
-	defer file.Close()
 
-	part, err := writer.CreateFormFile("audio", filepath.Base(audioPath))
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("create form file: %w", err)
-	}
-	if _, err := io.Copy(part, file); err != nil {
-		return nil, fmt.Errorf("copy audio data: %w", err)
+		return fmt.Errorf("health check failed: %w", err)
 	}
-	writer.Close()
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
 
-	// Формируем URL с query-параметрами
-	url := c.baseURL + "/transcribe-full?detect_fillers=" + fmt.Sprintf("%t", detectFillers)
-	if language != "" {
-		url += "&language=" + language
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ml service unhealthy: status %d", resp.StatusCode)
 	}
-	if numSpeakers != nil {
-		url += "&num_speakers=" + fmt.Sprintf("%d", *numSpeakers)
+	return nil
+}
+
+// postAudio отправляет файл полем audio в multipart-запросе, fields — дополнительные поля формы.
+// Тело формируется потоково через io.Pipe: файл не читается в память целиком.
+func (c *Client) postAudio(ctx context.Context, endpoint, target, audioPath string, fields map[string]string, result interface{}) error {
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return fmt.Errorf("open audio file: %w", err)
 	}
+	defer file.Close()
 
-	req, err := http.NewRequest(http.MethodPost, url, body)
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		var err error
+		for name, value := range fields {
+			if err = writer.WriteField(name, value); err != nil {
+				break
+			}
+		}
+		var part io.Writer
+		if err == nil {
+			part, err = writer.CreateFormFile("audio", filepath.Base(audioPath))
+		}
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, pr)
 	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
+		pr.CloseWithError(err)
+		return fmt.Errorf("create request: %w", err)
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
+	err = c.do(req, endpoint, result)
+	// Разблокирует горутину записи, если запрос завершился раньше, чем отправлен файл
+	pr.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+// do выполняет запрос и разбирает JSON-ответ в result.
+func (c *Client) do(req *http.Request, endpoint string, result interface{}) error {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("transcribe-full request failed: %w", err)
+		return fmt.Errorf("%s request failed: %w", strings.TrimPrefix(endpoint, "/"), err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return fmt.Errorf("read response: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("transcribe-full error: status %d, body: %s", resp.StatusCode, string(respBody))
+	// /jobs/transcribe-full отвечает 202 Accepted
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{
+			Endpoint:   strings.TrimPrefix(endpoint, "/"),
+			StatusCode: resp.StatusCode,
+			Detail:     parseErrorDetail(respBody),
+		}
 	}
 
-	var result TranscribeFullResponse
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, fmt.Errorf("parse transcribe-full response: %w", err)
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("parse %s response: %w", strings.TrimPrefix(endpoint, "/"), err)
 	}
-
-	return &result, nil
+	return nil
 }
 
-// Health проверяет доступность ML-сервиса.
-func (c *Client) Health() error {
-	resp, err := c.httpClient.Get(c.baseURL + "/health")
-	if err != nil {
-		return fmt.Errorf("health check failed: %w", err)
+// parseErrorDetail извлекает detail из ответа FastAPI: строку HTTPException
+// или список ошибок валидации. Если формат другой, возвращается тело ответа.
+func parseErrorDetail(body []byte) string {
+	var resp struct {
+		Detail json.RawMessage `json:"detail"`
+	}
+	if json.Unmarshal(body, &resp) != nil || len(resp.Detail) == 0 {
+		return strings.TrimSpace(string(body))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ml service unhealthy: status %d", resp.StatusCode)
+	var text string
+	if json.Unmarshal(resp.Detail, &text) == nil {
+		return text
 	}
-	return nil
+
+	var validation []struct {
+		Loc []interface{} `json:"loc"`
+		Msg string        `json:"msg"`
+	}
+	if json.Unmarshal(resp.Detail, &validation) == nil && len(validation) > 0 {
+		messages := make([]string, 0, len(validation))
+		for _, v := range validation {
+			loc := make([]string, 0, len(v.Loc))
+			for _, l := range v.Loc {
+				loc = append(loc, fmt.Sprint(l))
+			}
+			messages = append(messages, strings.Join(loc, ".")+": "+v.Msg)
+		}
+		return strings.Join(messages, "; ")
+	}
+
+	return string(resp.Detail)
+}
+
+// IsTransient сообщает, что ошибка временная: ML-сервис недоступен,
+// перегружен или не ответил вовремя. Ошибки обработки самого файла к ним не относятся.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
@@ -0,0 +1,173 @@
+package mlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAudio(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	path := filepath.Join(t.TempDir(), "audio.ogg")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path, data
+}
+
+func TestTranscribeFull_StreamsMultipart(t *testing.T) {
+	path, data := writeAudio(t, 3<<20)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/transcribe-full", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("detect_fillers"))
+		assert.Equal(t, "ru", r.URL.Query().Get("language"))
+		assert.Equal(t, "2", r.URL.Query().Get("num_speakers"))
+		// Тело передаётся потоково, без заранее известной длины
+		assert.Equal(t, int64(-1), r.ContentLength)
+
+		file, header, err := r.FormFile("audio")
+		require.NoError(t, err)
+		defer file.Close()
+		got, _ := io.ReadAll(file)
+		assert.Equal(t, "audio.ogg", header.Filename)
+		assert.Equal(t, data, got)
+		assert.Equal(t, "Loopa, SpeechKit", r.FormValue("hotwords"))
+
+		json.NewEncoder(w).Encode(TranscribeFullResponse{Language: "ru", FullText: "привет", NumSpeakers: 2})
+	}))
+	defer srv.Close()
+
+	speakers := 2
+	resp, err := New(srv.URL).TranscribeFull(context.Background(), path, "ru", &speakers, true, "Loopa, SpeechKit")
+	require.NoError(t, err)
+	assert.Equal(t, "привет", resp.FullText)
+	assert.Equal(t, 2, resp.NumSpeakers)
+}
+
+func TestDiarize_APIErrorDetail(t *testing.T) {
+	path, _ := writeAudio(t, 1024)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"detail":"Ошибка диаризации: CUDA out of memory"}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).Diarize(context.Background(), path)
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "diarize", apiErr.Endpoint)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.Equal(t, "Ошибка диаризации: CUDA out of memory", apiErr.Detail)
+}
+
+func TestDiarize_MissingFile(t *testing.T) {
+	_, err := New("http://127.0.0.1:1").Diarize(context.Background(), "/nonexistent/audio.ogg")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "open audio file")
+}
+
+func TestDiarize_Timeout(t *testing.T) {
+	path, _ := writeAudio(t, 1024)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	client := NewWithTimeouts(srv.URL, Timeouts{Diarize: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := client.Diarize(context.Background(), path)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestSpeakerEmbeddings_SendsTurns(t *testing.T) {
+	path, data := writeAudio(t, 1024)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/speaker-embeddings", r.URL.Path)
+
+		var turns []SpeakerTurn
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("turns")), &turns))
+		assert.Equal(t, []SpeakerTurn{{Speaker: "SPEAKER_00", Start: 0, End: 2.5}}, turns)
+
+		file, _, err := r.FormFile("audio")
+		require.NoError(t, err)
+		defer file.Close()
+		got, _ := io.ReadAll(file)
+		assert.Equal(t, data, got)
+
+		w.Write([]byte(`{"speakers":[{"speaker":"SPEAKER_00","embedding":[0.6,0.8],"duration":2.5}]}`))
+	}))
+	defer srv.Close()
+
+	speakers, err := New(srv.URL).SpeakerEmbeddings(context.Background(), path,
+		[]SpeakerTurn{{Speaker: "SPEAKER_00", Start: 0, End: 2.5}})
+	require.NoError(t, err)
+	require.Len(t, speakers, 1)
+	assert.Equal(t, []float64{0.6, 0.8}, speakers[0].Embedding)
+	assert.Equal(t, 2.5, speakers[0].Duration)
+}
+
+func TestProcessSegments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req TextProcessRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, []string{"ну привет", "да"}, req.Texts)
+
+		json.NewEncoder(w).Encode(TextProcessResponse{
+			Segments: []TextSegment{
+				{Text: "ну привет", HasFillers: true, FillersFound: []string{"ну"}},
+				{Text: "да"},
+			},
+			TotalFillers: 1,
+		})
+	}))
+	defer srv.Close()
+
+	resp, err := New(srv.URL).ProcessSegments(context.Background(), []string{"ну привет", "да"}, true, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.TotalFillers)
+	assert.True(t, resp.Segments[0].HasFillers)
+}
+
+func TestProcessSegments_CountMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"segments":[],"total_fillers":0}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).ProcessSegments(context.Background(), []string{"a"}, true, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "0 segments for 1 texts")
+}
+
+func TestParseErrorDetail(t *testing.T) {
+	assert.Equal(t, "boom", parseErrorDetail([]byte(`{"detail":"boom"}`)))
+	assert.Equal(t,
+		"query.num_speakers: Input should be a valid integer; body.audio: Field required",
+		parseErrorDetail([]byte(`{"detail":[{"loc":["query","num_speakers"],"msg":"Input should be a valid integer"},{"loc":["body","audio"],"msg":"Field required"}]}`)),
+	)
+	assert.Equal(t, "Bad Gateway", parseErrorDetail([]byte("Bad Gateway\n")))
+}
@@ -0,0 +1,135 @@
+package mlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Статусы фоновой задачи ML-сервиса.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// ErrJobNotFound — ML-сервис не знает задачу: истёк срок хранения
+// или сервис перезапускался. Файл нужно отправить заново.
+var ErrJobNotFound = errors.New("ml job not found")
+
+// ErrJobFailed и ErrJobCancelled — задача ML-сервиса завершилась без результата.
+var (
+	ErrJobFailed    = errors.New("ml job failed")
+	ErrJobCancelled = errors.New("ml job cancelled")
+)
+
+// JobStatus — состояние фоновой задачи.
+type JobStatus struct {
+	JobID    string  `json:"job_id"`
+	Status   string  `json:"status"`
+	Stage    string  `json:"stage"`
+	Progress float64 `json:"progress"` // 0..1
+	Error    string  `json:"error"`
+}
+
+// Finished сообщает, что задача больше не выполняется.
+func (s *JobStatus) Finished() bool {
+	return s.Status == JobDone || s.Status == JobFailed || s.Status == JobCancelled
+}
+
+// SubmitTranscribeFull ставит полный pipeline в очередь ML-сервиса и возвращает ID задачи.
+// В отличие от TranscribeFull соединение не держится всё время обработки.
+func (c *Client) SubmitTranscribeFull(ctx context.Context, audioPath string, language string, numSpeakers *int, detectFillers bool, hotwords string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.SubmitJob)
+	defer cancel()
+
+	query := transcribeQuery(language, numSpeakers, detectFillers)
+
+	var status JobStatus
+	if err := c.postAudio(ctx, "/jobs/transcribe-full", c.baseURL+"/jobs/transcribe-full?"+query, audioPath, transcribeFields(hotwords), &status); err != nil {
+		return "", err
+	}
+	if status.JobID == "" {
+		return "", fmt.Errorf("ml service returned job without id")
+	}
+	return status.JobID, nil
+}
+
+// JobStatus возвращает состояние задачи.
+func (c *Client) JobStatus(ctx context.Context, jobID string) (*JobStatus, error) {
+	var status JobStatus
+	if err := c.jobRequest(ctx, http.MethodGet, jobID, "", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// JobResult возвращает результат завершённой задачи.
+func (c *Client) JobResult(ctx context.Context, jobID string) (*TranscribeFullResponse, error) {
+	var result TranscribeFullResponse
+	if err := c.jobRequest(ctx, http.MethodGet, jobID, "/result", &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CancelJob отменяет задачу. Уже выполняющийся шаг pipeline доработает,
+// но его результат будет отброшен.
+func (c *Client) CancelJob(ctx context.Context, jobID string) error {
+	var status JobStatus
+	return c.jobRequest(ctx, http.MethodDelete, jobID, "", &status)
+}
+
+// WaitJob опрашивает задачу с интервалом interval до завершения и возвращает результат.
+// onProgress (может быть nil) вызывается после каждого опроса.
+// Отмена ctx прекращает ожидание, но не отменяет саму задачу.
+func (c *Client) WaitJob(ctx context.Context, jobID string, interval time.Duration, onProgress func(*JobStatus)) (*TranscribeFullResponse, error) {
+	for {
+		status, err := c.JobStatus(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if onProgress != nil {
+			onProgress(status)
+		}
+
+		switch status.Status {
+		case JobDone:
+			return c.JobResult(ctx, jobID)
+		case JobFailed:
+			return nil, fmt.Errorf("%w: %s", ErrJobFailed, status.Error)
+		case JobCancelled:
+			return nil, ErrJobCancelled
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) jobRequest(ctx context.Context, method, jobID, suffix string, result interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.Job)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/jobs/"+url.PathEscape(jobID)+suffix, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	err = c.do(req, "/jobs", result)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, jobID)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package mlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeJobs — ML-сервис с одной задачей, которая завершается после polls опросов.
+type fakeJobs struct {
+	mu        sync.Mutex
+	polls     int
+	status    string
+	cancelled bool
+}
+
+func (f *fakeJobs) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs/transcribe-full", func(w http.ResponseWriter, r *http.Request) {
+		_, _, err := r.FormFile("audio")
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(JobStatus{JobID: "job-1", Status: JobQueued})
+	})
+	mux.HandleFunc("/jobs/job-1", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if r.Method == http.MethodDelete {
+			f.cancelled = true
+			json.NewEncoder(w).Encode(JobStatus{JobID: "job-1", Status: JobCancelled})
+			return
+		}
+		status := JobStatus{JobID: "job-1", Status: JobRunning, Stage: "transcription", Progress: 0.5}
+		if f.polls == 0 {
+			status = JobStatus{JobID: "job-1", Status: f.status, Progress: 1}
+			if f.status == JobFailed {
+				status.Error = "Ошибка транскрибации: CUDA out of memory"
+			}
+		} else {
+			f.polls--
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("/jobs/job-1/result", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TranscribeFullResponse{FullText: "готово", NumSpeakers: 1})
+	})
+	mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail":"Задача не найдена"}`))
+	})
+	return mux
+}
+
+func TestJobs_SubmitAndWait(t *testing.T) {
+	fake := &fakeJobs{polls: 2, status: JobDone}
+	srv := httptest.NewServer(fake.handler(t))
+	defer srv.Close()
+
+	path, _ := writeAudio(t, 1024)
+	client := New(srv.URL)
+
+	jobID, err := client.SubmitTranscribeFull(context.Background(), path, "", nil, true, "")
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", jobID)
+
+	var progress []float64
+	resp, err := client.WaitJob(context.Background(), jobID, time.Millisecond, func(s *JobStatus) {
+		progress = append(progress, s.Progress)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "готово", resp.FullText)
+	assert.Equal(t, []float64{0.5, 0.5, 1}, progress)
+}
+
+func TestJobs_WaitFailed(t *testing.T) {
+	fake := &fakeJobs{status: JobFailed}
+	srv := httptest.NewServer(fake.handler(t))
+	defer srv.Close()
+
+	_, err := New(srv.URL).WaitJob(context.Background(), "job-1", time.Millisecond, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "CUDA out of memory")
+}
+
+func TestJobs_NotFound(t *testing.T) {
+	srv := httptest.NewServer((&fakeJobs{}).handler(t))
+	defer srv.Close()
+
+	_, err := New(srv.URL).JobStatus(context.Background(), "unknown")
+	assert.True(t, errors.Is(err, ErrJobNotFound))
+}
+
+func TestJobs_WaitStopsOnContext(t *testing.T) {
+	fake := &fakeJobs{polls: 1000, status: JobDone}
+	srv := httptest.NewServer(fake.handler(t))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	_, err := New(srv.URL).WaitJob(ctx, "job-1", 5*time.Millisecond, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	// Ожидание прервано, но сама задача не отменяется
+	assert.False(t, fake.cancelled)
+}
+
+func TestJobs_Cancel(t *testing.T) {
+	fake := &fakeJobs{polls: 1000}
+	srv := httptest.NewServer(fake.handler(t))
+	defer srv.Close()
+
+	require.NoError(t, New(srv.URL).CancelJob(context.Background(), "job-1"))
+	assert.True(t, fake.cancelled)
+}
@@ -0,0 +1,266 @@
+// Package mockstt — детерминированный распознаватель для разработки и e2e-тестов.
+// Результат зависит только от содержимого файла или от fixture, без ffmpeg,
+// моделей и сети: один и тот же файл всегда даёт одни и те же сегменты,
+// спикеров, слова-паразиты и таймкоды слов.
+package mockstt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Виды внедряемых ошибок.
+const (
+	FailPermanent = "permanent"
+	FailTransient = "transient"
+)
+
+// Fixture — результат распознавания в формате sidecar-файла.
+type Fixture struct {
+	Language string    `json:"language"`
+	Segments []Segment `json:"segments"`
+	Fail     *Failure  `json:"fail,omitempty"`
+}
+
+type Segment struct {
+	Speaker string  `json:"speaker"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	// CleanedText — текст без паразитов; если не задан, из Text убираются Fillers.
+	CleanedText string   `json:"cleanedText,omitempty"`
+	Words       []Word   `json:"words,omitempty"`
+	Fillers     []string `json:"fillers,omitempty"`
+}
+
+type Word struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// Failure — ошибка, которую провайдер вернёт вместо результата.
+type Failure struct {
+	Kind    string `json:"kind"` // permanent или transient
+	Message string `json:"message"`
+	// Times — сколько первых попыток завершаются ошибкой; 0 — все.
+	Times int `json:"times,omitempty"`
+}
+
+// Text склеивает текст сегментов.
+func (f *Fixture) Text() string {
+	texts := make([]string, 0, len(f.Segments))
+	for _, seg := range f.Segments {
+		texts = append(texts, seg.Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// NumSpeakers возвращает число разных спикеров.
+func (f *Fixture) NumSpeakers() int {
+	seen := map[string]bool{}
+	for _, seg := range f.Segments {
+		if seg.Speaker != "" {
+			seen[seg.Speaker] = true
+		}
+	}
+	return len(seen)
+}
+
+// Load ищет fixture для файла: сначала sidecar <файл>.mock.json, затем
+// <fixturesDir>/<sha256 содержимого>.json. Если fixture нет, результат
+// генерируется по содержимому. duration — длительность в секундах; 0 — неизвестна.
+func Load(audioPath, fixturesDir string, duration float64) (*Fixture, error) {
+	fixture, err := readFixture(audioPath + ".mock.json")
+	if !errors.Is(err, os.ErrNotExist) {
+		return fixture, err
+	}
+
+	// Загрузки бывают большими: файл хешируется потоком, не читаясь в память
+	sum, size, err := hashFile(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("read audio: %w", err)
+	}
+	if fixturesDir != "" {
+		fixture, err := readFixture(filepath.Join(fixturesDir, hex.EncodeToString(sum[:])+".json"))
+		if !errors.Is(err, os.ErrNotExist) {
+			return fixture, err
+		}
+	}
+	return generate(sum, size, duration), nil
+}
+
+func hashFile(path string) (sum [sha256.Size]byte, size int64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return sum, 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if size, err = io.Copy(hash, file); err != nil {
+		return sum, 0, err
+	}
+	copy(sum[:], hash.Sum(nil))
+	return sum, size, nil
+}
+
+func readFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %w", filepath.Base(path), err)
+	}
+	for i := range fixture.Segments {
+		seg := &fixture.Segments[i]
+		if len(seg.Words) == 0 {
+			seg.Words = spreadWords(strings.Fields(seg.Text), seg.Start, seg.End)
+		}
+		if seg.CleanedText == "" {
+			seg.CleanedText = removeFillers(seg.Text, seg.Fillers)
+		}
+	}
+	return &fixture, nil
+}
+
+var vocabulary = strings.Fields(`
+	проект задача срок команда релиз встреча отчёт клиент бюджет план
+	сегодня завтра неделя нужно можно давайте обсудить проверить сделать
+	обновить данные сервер запись файл текст результат вопрос ответ идея
+	хорошо понятно согласен предлагаю думаю кажется точно быстро потом`)
+
+// fillers — однословные паразиты из словаря ML-сервиса.
+var fillers = []string{"ну", "вот", "типа", "короче", "значит"}
+
+// Generate строит транскрипт по хешу содержимого. Если длительность
+// неизвестна, она оценивается по размеру файла (как для 128 кбит/с).
+func Generate(data []byte, duration float64) *Fixture {
+	return generate(sha256.Sum256(data), int64(len(data)), duration)
+}
+
+// generate строит транскрипт по хешу sum файла размером size байт.
+func generate(sum [sha256.Size]byte, size int64, duration float64) *Fixture {
+	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(sum[:8]))))
+
+	if duration <= 0 {
+		duration = float64(size) / 16000
+	}
+	duration = min(max(duration, 3), 600)
+
+	speakers := 1 + rng.Intn(3)
+	speaker := 0
+	fixture := &Fixture{Language: "ru"}
+	for start := 0.0; duration-start >= 1; {
+		end := min(start+2+rng.Float64()*6, duration)
+		words := make([]string, 0, 12)
+		for n := max(2, int((end-start)*2)); len(words) < n; {
+			words = append(words, vocabulary[rng.Intn(len(vocabulary))])
+		}
+
+		cleaned := sentence(words)
+		var found []string
+		if rng.Intn(4) == 0 {
+			filler := fillers[rng.Intn(len(fillers))]
+			pos := rng.Intn(len(words))
+			words = append(words[:pos], append([]string{filler}, words[pos:]...)...)
+			found = append(found, filler)
+		}
+
+		fixture.Segments = append(fixture.Segments, Segment{
+			Speaker:     fmt.Sprintf("SPEAKER_%02d", speaker),
+			Start:       round(start),
+			End:         round(end),
+			Text:        sentence(words),
+			CleanedText: cleaned,
+			Words:       spreadWords(words, start, end),
+			Fillers:     found,
+		})
+
+		if speakers > 1 && rng.Intn(2) == 0 {
+			speaker = (speaker + 1 + rng.Intn(speakers-1)) % speakers
+		}
+		start = end + 0.3
+	}
+	return fixture
+}
+
+// sentence склеивает слова в предложение с заглавной буквы и точкой.
+func sentence(words []string) string {
+	text := []rune(strings.Join(words, " "))
+	text[0] = unicode.ToUpper(text[0])
+	return string(text) + "."
+}
+
+// removeFillers убирает из текста по одному вхождению каждого найденного
+// паразита: целыми словами, без учёта регистра и знаков препинания вокруг.
+// Знак в конце предложения и заглавная первая буква сохраняются.
+func removeFillers(text string, found []string) string {
+	words := strings.Fields(text)
+	if len(found) == 0 || len(words) == 0 {
+		return text
+	}
+	for _, filler := range found {
+		parts := strings.Fields(strings.ToLower(filler))
+		for i := 0; len(parts) > 0 && i+len(parts) <= len(words); i++ {
+			if !matchWords(words[i:i+len(parts)], parts) {
+				continue
+			}
+			last := words[i+len(parts)-1]
+			atEnd := i+len(parts) == len(words)
+			words = append(words[:i], words[i+len(parts):]...)
+			if atEnd && i > 0 {
+				// «…день, ну.» → «…день.»
+				word := strings.TrimRightFunc(last, unicode.IsPunct)
+				words[i-1] = strings.TrimRightFunc(words[i-1], unicode.IsPunct) + last[len(word):]
+			}
+			break
+		}
+	}
+	if len(words) == 0 {
+		return ""
+	}
+	cleaned := []rune(strings.Join(words, " "))
+	if first := []rune(text)[0]; unicode.IsUpper(first) {
+		cleaned[0] = unicode.ToUpper(cleaned[0])
+	}
+	return string(cleaned)
+}
+
+func matchWords(words, parts []string) bool {
+	for i, part := range parts {
+		if strings.ToLower(strings.TrimFunc(words[i], unicode.IsPunct)) != part {
+			return false
+		}
+	}
+	return true
+}
+
+// spreadWords распределяет слова по отрезку равномерно.
+func spreadWords(words []string, start, end float64) []Word {
+	if len(words) == 0 {
+		return nil
+	}
+	step := (end - start) / float64(len(words))
+	result := make([]Word, len(words))
+	for i, word := range words {
+		result[i] = Word{Word: word, Start: round(start + step*float64(i)), End: round(start + step*float64(i+1))}
+	}
+	return result
+}
+
+func round(v float64) float64 {
+	return float64(int64(v*1000+0.5)) / 1000
+}
@@ -0,0 +1,113 @@
+package mockstt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_Deterministic(t *testing.T) {
+	data := []byte(strings.Repeat("audio", 10000))
+
+	first := Generate(data, 30)
+	second := Generate(data, 30)
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, Generate([]byte("other audio"), 30))
+
+	require.NotEmpty(t, first.Segments)
+	last := first.Segments[len(first.Segments)-1]
+	assert.LessOrEqual(t, last.End, 30.0)
+	for _, seg := range first.Segments {
+		assert.Less(t, seg.Start, seg.End)
+		assert.True(t, strings.HasSuffix(seg.Text, "."))
+		require.NotEmpty(t, seg.Words)
+		assert.Equal(t, seg.Start, seg.Words[0].Start)
+		assert.Equal(t, seg.End, seg.Words[len(seg.Words)-1].End)
+		for _, filler := range seg.Fillers {
+			assert.Contains(t, fillers, filler)
+		}
+		if len(seg.Fillers) == 0 {
+			assert.Equal(t, seg.Text, seg.CleanedText)
+		} else {
+			assert.Len(t, strings.Fields(seg.CleanedText), len(seg.Words)-1)
+		}
+	}
+	assert.GreaterOrEqual(t, first.NumSpeakers(), 1)
+}
+
+func TestGenerate_DurationFromSize(t *testing.T) {
+	// 160 000 байт ≈ 10 секунд при 128 кбит/с
+	fixture := Generate(make([]byte, 160000), 0)
+	last := fixture.Segments[len(fixture.Segments)-1]
+	assert.LessOrEqual(t, last.End, 10.0)
+	assert.Greater(t, last.End, 5.0)
+}
+
+func TestLoad_Sidecar(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "a.ogg")
+	require.NoError(t, os.WriteFile(audio, []byte("OggS"), 0o644))
+	require.NoError(t, os.WriteFile(audio+".mock.json", []byte(`{
+		"language": "ru",
+		"segments": [{"speaker": "SPEAKER_01", "start": 1, "end": 3, "text": "ну добрый день", "fillers": ["ну"]}]
+	}`), 0o644))
+
+	fixture, err := Load(audio, "", 0)
+	require.NoError(t, err)
+	require.Len(t, fixture.Segments, 1)
+	assert.Equal(t, "ну добрый день", fixture.Text())
+	assert.Equal(t, "добрый день", fixture.Segments[0].CleanedText)
+	// Таймкоды слов без явного списка распределяются по сегменту
+	assert.Equal(t, []Word{{"ну", 1, 1.667}, {"добрый", 1.667, 2.333}, {"день", 2.333, 3}}, fixture.Segments[0].Words)
+}
+
+func TestLoad_FixturesDirByHash(t *testing.T) {
+	dir := t.TempDir()
+	audio := filepath.Join(dir, "upload.ogg")
+	content := []byte("OggS fixture audio")
+	require.NoError(t, os.WriteFile(audio, content, 0o644))
+
+	fixtures := t.TempDir()
+	sum := sha256.Sum256(content)
+	require.NoError(t, os.WriteFile(filepath.Join(fixtures, hex.EncodeToString(sum[:])+".json"),
+		[]byte(`{"fail": {"kind": "transient", "message": "GPU busy", "times": 1}}`), 0o644))
+
+	fixture, err := Load(audio, fixtures, 0)
+	require.NoError(t, err)
+	require.NotNil(t, fixture.Fail)
+	assert.Equal(t, FailTransient, fixture.Fail.Kind)
+	assert.Equal(t, 1, fixture.Fail.Times)
+}
+
+func TestLoad_Generated(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "a.ogg")
+	require.NoError(t, os.WriteFile(audio, []byte("OggS generated"), 0o644))
+
+	fixture, err := Load(audio, t.TempDir(), 12)
+	require.NoError(t, err)
+	assert.Equal(t, Generate([]byte("OggS generated"), 12), fixture)
+}
+
+func TestRemoveFillers(t *testing.T) {
+	assert.Equal(t, "Добрый день, начнём.", removeFillers("Ну, добрый день, начнём.", []string{"ну"}))
+	assert.Equal(t, "Начнём.", removeFillers("Начнём, как бы.", []string{"как бы"}))
+	assert.Equal(t, "вот так", removeFillers("вот вот так", []string{"вот"}))
+	assert.Equal(t, "текст", removeFillers("текст", nil))
+}
+
+func TestLoad_StreamsLargeFile(t *testing.T) {
+	audio := filepath.Join(t.TempDir(), "a.ogg")
+	content := []byte(strings.Repeat("OggS", 100000))
+	require.NoError(t, os.WriteFile(audio, content, 0o644))
+
+	fixture, err := Load(audio, "", 0)
+	require.NoError(t, err)
+	// Хеш и размер файла те же, что при чтении целиком
+	assert.Equal(t, Generate(content, 0), fixture)
+}
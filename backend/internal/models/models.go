@@ -6,8 +6,16 @@ type File struct {
 	ID            string
 	OriginalName  string
 	StoragePath   string
+	OriginalPath  *string
 	FileSize      int64
 	MimeType      string
+	DurationMs    *int64
+	FormatName    *string
+	AudioCodec    *string
+	SampleRate    *int
+	Channels      *int
+	BitRate       *int64
+	HasVideo      bool
 	UploadedAt    time.Time
 	UserSessionID string
 	ProjectID     *string
@@ -0,0 +1,300 @@
+// Package openaistt — клиент OpenAI-совместимого API распознавания
+// (POST /v1/audio/transcriptions). Такой API предоставляют OpenAI и многие
+// self-hosted серверы: faster-whisper-server, LocalAI, vLLM, шлюзы инференса.
+package openaistt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultBaseURL = "https://api.openai.com/v1"
+	DefaultModel   = "whisper-1"
+)
+
+// Options — настройки клиента.
+type Options struct {
+	BaseURL  string // адрес с версией API, например http://gateway:8000/v1
+	APIKey   string // пустой — без заголовка Authorization
+	Model    string
+	Language string // ISO-639-1; пустая строка — автоопределение
+	// Timeout ограничивает распознавание одного файла (по умолчанию 30m).
+	Timeout time.Duration
+}
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	language   string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// Result — распознанный текст с фразами и словами.
+type Result struct {
+	Language string
+	Duration float64
+	Text     string
+	Segments []Segment
+}
+
+type Segment struct {
+	Start float64
+	End   float64
+	Text  string
+	Words []Word
+}
+
+type Word struct {
+	Word  string
+	Start float64
+	End   float64
+}
+
+// ErrTimeout — распознавание файла не уложилось в Options.Timeout.
+// Ошибка постоянная: тот же файл и при повторе будет распознаваться не быстрее.
+var ErrTimeout = errors.New("transcription timed out")
+
+// APIError — ответ с кодом не 2xx. Тело в формате {"error": {"message", "type"}}.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("transcription API error: status %d (%s): %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("transcription API error: status %d: %s", e.StatusCode, e.Message)
+}
+
+func New(opts Options) *Client {
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	model := opts.Model
+	if model == "" {
+		model = DefaultModel
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	return &Client{
+		baseURL:    baseURL,
+		apiKey:     opts.APIKey,
+		model:      model,
+		language:   opts.Language,
+		timeout:    timeout,
+		httpClient: &http.Client{},
+	}
+}
+
+// IsTransient сообщает, что повтор запроса позже может быть успешным:
+// сеть, лимит запросов, сбой или перегрузка сервера. Истёкший таймаут
+// (ErrTimeout) и отмена контекста временными не считаются.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, ErrTimeout) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// verboseResponse — ответ response_format=verbose_json.
+type verboseResponse struct {
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+	Text     string  `json:"text"`
+	Segments []struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	} `json:"segments"`
+	Words []Word `json:"words"`
+}
+
+func (w *Word) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Word  string  `json:"word"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*w = Word{Word: strings.TrimSpace(raw.Word), Start: raw.Start, End: raw.End}
+	return nil
+}
+
+// Transcribe отправляет файл и запрашивает таймкоды фраз и слов.
+// prompt — подсказка с терминами словаря; пустая строка — без подсказки.
+// Файл передаётся потоком, не загружаясь в память целиком.
+func (c *Client) Transcribe(parent context.Context, audioPath, prompt string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(parent, c.timeout)
+	defer cancel()
+
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("open audio file: %w", err)
+	}
+	defer file.Close()
+
+	fields := [][2]string{
+		{"model", c.model},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+		{"timestamp_granularities[]", "word"},
+	}
+	if c.language != "" {
+		fields = append(fields, [2]string{"language", c.language})
+	}
+	if prompt != "" {
+		fields = append(fields, [2]string{"prompt", prompt})
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		var err error
+		for _, field := range fields {
+			if err = writer.WriteField(field[0], field[1]); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			var part io.Writer
+			part, err = writer.CreateFormFile("file", filepath.Base(audioPath))
+			if err == nil {
+				_, err = io.Copy(part, file)
+			}
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/audio/transcriptions", pr)
+	if err != nil {
+		pr.CloseWithError(err)
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var resp verboseResponse
+	err = c.do(req, &resp)
+	// Разблокирует горутину записи, если запрос завершился раньше, чем отправлен файл
+	pr.CloseWithError(io.ErrClosedPipe)
+	if err != nil && parent.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s: %v", ErrTimeout, c.timeout, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildResult(&resp), nil
+}
+
+// Health проверяет доступность API и ключа запросом списка моделей.
+// Серверы без /models (404) считаются доступными.
+func (c *Client) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	var discard json.RawMessage
+	err = c.do(req, &discard)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (c *Client) do(req *http.Request, result interface{}) error {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("transcription API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+		var errResp struct {
+			Error struct {
+				Message string `json:"message"`
+				Type    string `json:"type"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+			apiErr.Message = errResp.Error.Message
+			apiErr.Type = errResp.Error.Type
+		}
+		return apiErr
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// buildResult раскладывает слова по фразам по времени начала слова.
+// Если сервер не вернул фраз, весь текст становится одной фразой.
+func buildResult(resp *verboseResponse) *Result {
+	res := &Result{Language: resp.Language, Duration: resp.Duration, Text: strings.TrimSpace(resp.Text)}
+
+	for _, s := range resp.Segments {
+		if text := strings.TrimSpace(s.Text); text != "" {
+			res.Segments = append(res.Segments, Segment{Start: s.Start, End: s.End, Text: text})
+		}
+	}
+	if len(res.Segments) == 0 && res.Text != "" {
+		seg := Segment{Text: res.Text, End: resp.Duration}
+		if n := len(resp.Words); n > 0 {
+			seg.Start, seg.End = resp.Words[0].Start, resp.Words[n-1].End
+		}
+		res.Segments = []Segment{seg}
+	}
+
+	i := 0
+	for _, word := range resp.Words {
+		// Слово относится к последней фразе, начавшейся не позже него
+		for i+1 < len(res.Segments) && res.Segments[i+1].Start <= word.Start {
+			i++
+		}
+		if len(res.Segments) > 0 {
+			res.Segments[i].Words = append(res.Segments[i].Words, word)
+		}
+	}
+	return res
+}
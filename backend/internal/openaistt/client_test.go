@@ -0,0 +1,146 @@
+package openaistt
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAudio(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audio.ogg")
+	require.NoError(t, os.WriteFile(path, []byte("OggS fake audio"), 0o644))
+	return path
+}
+
+func TestTranscribe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/transcriptions", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "large-v3", r.FormValue("model"))
+		assert.Equal(t, "verbose_json", r.FormValue("response_format"))
+		assert.Equal(t, []string{"segment", "word"}, r.MultipartForm.Value["timestamp_granularities[]"])
+		assert.Equal(t, "ru", r.FormValue("language"))
+		assert.Equal(t, "Loopa, SpeechKit", r.FormValue("prompt"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		assert.Equal(t, "audio.ogg", header.Filename)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"language": "russian",
+			"duration": 4.2,
+			"text":     " Добрый день. Как дела?",
+			"segments": []map[string]interface{}{
+				{"start": 0.0, "end": 1.8, "text": " Добрый день."},
+				{"start": 2.0, "end": 4.2, "text": " Как дела?"},
+			},
+			"words": []map[string]interface{}{
+				{"word": " Добрый", "start": 0.0, "end": 0.7},
+				{"word": " день.", "start": 0.8, "end": 1.8},
+				{"word": " Как", "start": 2.0, "end": 2.4},
+				{"word": " дела?", "start": 2.5, "end": 4.2},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client := New(Options{BaseURL: srv.URL + "/v1/", APIKey: "secret", Model: "large-v3", Language: "ru"})
+	res, err := client.Transcribe(context.Background(), writeAudio(t), "Loopa, SpeechKit")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Добрый день. Как дела?", res.Text)
+	assert.Equal(t, "russian", res.Language)
+	require.Len(t, res.Segments, 2)
+	assert.Equal(t, "Добрый день.", res.Segments[0].Text)
+	assert.Equal(t, []Word{{Word: "Добрый", Start: 0, End: 0.7}, {Word: "день.", Start: 0.8, End: 1.8}}, res.Segments[0].Words)
+	require.Len(t, res.Segments[1].Words, 2)
+	assert.Equal(t, "дела?", res.Segments[1].Words[1].Word)
+}
+
+func TestTranscribe_WordsWithoutSegments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text":"раз два","words":[{"word":"раз","start":0.5,"end":0.9},{"word":"два","start":1,"end":1.6}]}`))
+	}))
+	defer srv.Close()
+
+	res, err := New(Options{BaseURL: srv.URL}).Transcribe(context.Background(), writeAudio(t), "")
+	require.NoError(t, err)
+	require.Len(t, res.Segments, 1)
+	assert.Equal(t, 0.5, res.Segments[0].Start)
+	assert.Equal(t, 1.6, res.Segments[0].End)
+	assert.Len(t, res.Segments[0].Words, 2)
+}
+
+func TestTranscribe_APIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		message   string
+		transient bool
+	}{
+		{"invalid file", http.StatusBadRequest, `{"error":{"message":"Invalid file format.","type":"invalid_request_error"}}`, "Invalid file format.", false},
+		{"rate limit", http.StatusTooManyRequests, `{"error":{"message":"Rate limit reached","type":"requests"}}`, "Rate limit reached", true},
+		{"plain text", http.StatusBadGateway, "bad gateway", "bad gateway", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			_, err := New(Options{BaseURL: srv.URL}).Transcribe(context.Background(), writeAudio(t), "")
+			var apiErr *APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, tt.message, apiErr.Message)
+			assert.Equal(t, tt.transient, IsTransient(err))
+		})
+	}
+}
+
+func TestTranscribe_TimeoutIsPermanent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	_, err := New(Options{BaseURL: srv.URL, Timeout: 20 * time.Millisecond}).Transcribe(context.Background(), writeAudio(t), "")
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.False(t, IsTransient(err))
+}
+
+func TestHealth(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer srv.Close()
+	client := New(Options{BaseURL: srv.URL + "/v1"})
+
+	assert.NoError(t, client.Health(context.Background()))
+	status = http.StatusNotFound
+	assert.NoError(t, client.Health(context.Background()))
+	status = http.StatusUnauthorized
+	assert.Error(t, client.Health(context.Background()))
+}
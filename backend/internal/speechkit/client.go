@@ -2,24 +2,74 @@ package speechkit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	recognizeURL     = "https://stt.api.cloud.yandex.net/speech/v1/stt:recognize"
-	longRunningURL   = "https://transcribe.api.cloud.yandex.net/speech/stt/v2/longRunningRecognize"
-	operationsURL    = "https://operation.api.cloud.yandex.net/operations"
+	recognizePath   = "/speech/v1/stt:recognize"
+	longRunningPath = "/speech/stt/v2/longRunningRecognize"
+	operationsPath  = "/operations"
+
+	// maxPollFailures — сколько ошибок опроса подряд допускается до отказа.
+	maxPollFailures = 5
 )
 
+// ErrOperationNotFound — SpeechKit не знает операцию (истекла или чужая).
+// Распознавание нужно запустить заново.
+var ErrOperationNotFound = errors.New("speechkit operation not found")
+
+// ErrOperationTimeout — операция не завершилась за OperationTimeout.
+// Ошибка постоянная: повторное ожидание той же операции бессмысленно.
+var ErrOperationTimeout = errors.New("speechkit operation timed out")
+
+// Endpoints — базовые адреса сервисов SpeechKit (схема и хост без пути).
+// Позволяют направить клиент на прокси или тестовый сервер (см. speechkittest).
+type Endpoints struct {
+	STT        string // синхронный API v1 и API v3
+	Transcribe string // longRunningRecognize v2
+	Operation  string // статус асинхронных операций
+}
+
+// DefaultEndpoints возвращает адреса Yandex Cloud.
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		STT:        "https://stt.api.cloud.yandex.net",
+		Transcribe: "https://transcribe.api.cloud.yandex.net",
+		Operation:  "https://operation.api.cloud.yandex.net",
+	}
+}
+
+// Options — необязательные параметры клиента.
+type Options struct {
+	// Endpoints — пустые поля заменяются адресами по умолчанию.
+	Endpoints Endpoints
+	// PollInterval — первая пауза при опросе операции (1s), дальше удваивается
+	// до MaxPollInterval (30s).
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// OperationTimeout — сколько ждать завершения одной операции (3h).
+	OperationTimeout time.Duration
+}
+
 type Client struct {
 	apiKey     string
 	folderId   string
 	httpClient *http.Client
+	endpoints  Endpoints
+
+	pollInterval     time.Duration
+	maxPollInterval  time.Duration
+	operationTimeout time.Duration
 }
 
 type RecognizeResponse struct {
@@ -43,11 +93,13 @@ type RecognitionConfig struct {
 }
 
 type RecognitionSpec struct {
-	LanguageCode       string `json:"languageCode"`
-	Model              string `json:"model,omitempty"`
-	AudioEncoding      string `json:"audioEncoding"`
-	SampleRateHertz    int    `json:"sampleRateHertz"`
-	AudioChannelCount  int    `json:"audioChannelCount"`
+	LanguageCode      string `json:"languageCode"`
+	Model             string `json:"model,omitempty"`
+	AudioEncoding     string `json:"audioEncoding"`
+	SampleRateHertz   int    `json:"sampleRateHertz"`
+	AudioChannelCount int    `json:"audioChannelCount"`
+	ProfanityFilter   bool   `json:"profanityFilter,omitempty"`
+	LiteratureText    bool   `json:"literature_text,omitempty"`
 }
 
 type AudioSource struct {
@@ -55,10 +107,10 @@ type AudioSource struct {
 }
 
 type Operation struct {
-	ID          string           `json:"id"`
-	Done        bool             `json:"done"`
-	Response    *OperationResult `json:"response,omitempty"`
-	Error       *OperationError  `json:"error,omitempty"`
+	ID       string           `json:"id"`
+	Done     bool             `json:"done"`
+	Response *OperationResult `json:"response,omitempty"`
+	Error    *OperationError  `json:"error,omitempty"`
 }
 
 type OperationResult struct {
@@ -67,10 +119,40 @@ type OperationResult struct {
 
 type TranscriptChunk struct {
 	Alternatives []Alternative `json:"alternatives"`
+	ChannelTag   string        `json:"channelTag"`
 }
 
 type Alternative struct {
-	Text string `json:"text"`
+	Text  string     `json:"text"`
+	Words []WordInfo `json:"words"`
+}
+
+// WordInfo — слово в ответе v2, время в формате "1.23s".
+type WordInfo struct {
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+	Word      string `json:"word"`
+}
+
+// Result — результат асинхронного распознавания с таймкодами.
+type Result struct {
+	Text       string
+	Utterances []Utterance
+}
+
+// Utterance — фраза с границами и таймкодами слов (секунды).
+type Utterance struct {
+	Text  string
+	Start float64
+	End   float64
+	Words []Word
+}
+
+// Word — слово с таймкодами в секундах от начала записи.
+type Word struct {
+	Text  string
+	Start float64
+	End   float64
 }
 
 type OperationError struct {
@@ -79,12 +161,45 @@ type OperationError struct {
 }
 
 func NewClient(apiKey string, folderId string) *Client {
+	return NewClientWithOptions(apiKey, folderId, Options{})
+}
+
+// NewClientWithOptions создаёт клиент с другими адресами API или интервалами опроса.
+func NewClientWithOptions(apiKey string, folderId string, opts Options) *Client {
+	endpoints := DefaultEndpoints()
+	if opts.Endpoints.STT != "" {
+		endpoints.STT = strings.TrimRight(opts.Endpoints.STT, "/")
+	}
+	if opts.Endpoints.Transcribe != "" {
+		endpoints.Transcribe = strings.TrimRight(opts.Endpoints.Transcribe, "/")
+	}
+	if opts.Endpoints.Operation != "" {
+		endpoints.Operation = strings.TrimRight(opts.Endpoints.Operation, "/")
+	}
+
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	maxPollInterval := opts.MaxPollInterval
+	if maxPollInterval <= 0 {
+		maxPollInterval = 30 * time.Second
+	}
+	operationTimeout := opts.OperationTimeout
+	if operationTimeout <= 0 {
+		operationTimeout = 3 * time.Hour
+	}
+
 	return &Client{
 		apiKey:   apiKey,
 		folderId: folderId,
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 		},
+		endpoints:        endpoints,
+		pollInterval:     pollInterval,
+		maxPollInterval:  max(pollInterval, maxPollInterval),
+		operationTimeout: operationTimeout,
 	}
 }
 
@@ -92,51 +207,43 @@ func NewClient(apiKey string, folderId string) *Client {
 // Файл должен быть в формате OGG Opus (конвертируйте через ffmpeg).
 // Ограничение: до 30 секунд аудио для синхронного API.
 func (c *Client) RecognizeFile(filePath string, lang string) (string, error) {
+	return c.RecognizeFileContext(context.Background(), filePath, lang)
+}
+
+// RecognizeFileContext — RecognizeFile с контекстом: отмена ctx прерывает запрос.
+func (c *Client) RecognizeFileContext(ctx context.Context, filePath string, lang string) (string, error) {
 	audioData, err := os.ReadFile(filePath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read audio file: %w", err)
 	}
 
-	return c.Recognize(audioData, lang)
+	return c.RecognizeContext(ctx, audioData, lang)
 }
 
 // Recognize отправляет аудиоданные на распознавание.
 // audioData — OGG Opus данные.
 // lang — код языка (ru-RU, en-US и т.д.), пустая строка для автоопределения.
 func (c *Client) Recognize(audioData []byte, lang string) (string, error) {
-	url := recognizeURL + "?format=oggopus"
+	return c.RecognizeContext(context.Background(), audioData, lang)
+}
+
+// RecognizeContext — Recognize с контекстом: отмена ctx прерывает запрос.
+func (c *Client) RecognizeContext(ctx context.Context, audioData []byte, lang string) (string, error) {
+	url := c.endpoints.STT + recognizePath + "?format=oggopus"
 	if lang != "" {
 		url += "&lang=" + lang
 	}
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(audioData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(audioData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
-
-	req.Header.Set("Authorization", "Api-Key "+c.apiKey)
 	req.Header.Set("Content-Type", "application/octet-stream")
-	if c.folderId != "" {
-		req.Header.Set("x-folder-id", c.folderId)
-	}
+	c.setAuthHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	body, err := c.do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
-			return "", fmt.Errorf("speechkit error (code %d): %s", errResp.Code, errResp.Message)
-		}
-		return "", fmt.Errorf("speechkit error: status %d, body: %s", resp.StatusCode, string(body))
+		return "", err
 	}
 
 	var result RecognizeResponse
@@ -149,27 +256,39 @@ func (c *Client) Recognize(audioData []byte, lang string) (string, error) {
 
 // RecognizeLongAudio запускает асинхронное распознавание для длинных файлов.
 // fileURI — URL файла в Yandex Object Storage (https://storage.yandexcloud.net/bucket/key).
-// Возвращает текст транскрипта после завершения операции.
-func (c *Client) RecognizeLongAudio(fileURI string, lang string) (string, error) {
-	// Запускаем операцию
-	opID, err := c.startLongRunningRecognition(fileURI, lang)
+// Возвращает транскрипт с таймкодами слов после завершения операции.
+// Нормализация чисел (opts.Normalize) доступна только в API v3.
+func (c *Client) RecognizeLongAudio(ctx context.Context, fileURI string, opts RecognitionOptions) (*Result, error) {
+	opID, err := c.StartLongAudio(ctx, fileURI, opts)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	// Ждём завершения с polling
-	return c.waitForOperation(opID)
+	return c.WaitLongAudio(ctx, opID)
 }
 
-func (c *Client) startLongRunningRecognition(fileURI string, lang string) (string, error) {
+// StartLongAudio запускает распознавание v2 и возвращает ID операции.
+// ID стоит сохранить: после перезапуска ожидание продолжается через WaitLongAudio
+// без повторной (платной) отправки файла.
+func (c *Client) StartLongAudio(ctx context.Context, fileURI string, opts RecognitionOptions) (string, error) {
+	lang := opts.Language
+	if lang == "" {
+		lang = "ru-RU"
+	}
+	model := opts.Model
+	if model == "" {
+		model = "general"
+	}
+
 	reqBody := LongRunningRequest{
 		Config: RecognitionConfig{
 			Specification: RecognitionSpec{
 				LanguageCode:      lang,
-				Model:             "general",
+				Model:             model,
 				AudioEncoding:     "OGG_OPUS",
 				SampleRateHertz:   48000,
 				AudioChannelCount: 1,
+				ProfanityFilter:   opts.ProfanityFilter,
+				LiteratureText:    opts.LiteratureText,
 			},
 		},
 		Audio: AudioSource{
@@ -183,116 +302,209 @@ func (c *Client) startLongRunningRecognition(fileURI string, lang string) (strin
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, longRunningURL, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoints.Transcribe+longRunningPath, bytes.NewReader(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
-
-	req.Header.Set("Authorization", "Api-Key "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
-	if c.folderId != "" {
-		req.Header.Set("x-folder-id", c.folderId)
-	}
+	c.setAuthHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	body, err := c.do(req)
 	if err != nil {
-		return "", fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
-			return "", fmt.Errorf("speechkit error: %s", errResp.Message)
-		}
-		return "", fmt.Errorf("speechkit error: status %d, body: %s", resp.StatusCode, string(body))
+		return "", err
 	}
 
 	var op Operation
 	if err := json.Unmarshal(body, &op); err != nil {
 		return "", fmt.Errorf("failed to parse operation: %w", err)
 	}
-
+	if op.ID == "" {
+		return "", fmt.Errorf("speechkit returned operation without id")
+	}
 	return op.ID, nil
 }
 
-func (c *Client) waitForOperation(opID string) (string, error) {
-	url := fmt.Sprintf("%s/%s", operationsURL, opID)
-
-	// Polling с экспоненциальной задержкой: 1s, 2s, 4s, 8s... max 30s
-	delay := time.Second
-	maxDelay := 30 * time.Second
-	maxAttempts := 120 // ~30 минут максимум
-
-	for i := 0; i < maxAttempts; i++ {
-		time.Sleep(delay)
+// WaitLongAudio дожидается операции v2 и возвращает её результат.
+func (c *Client) WaitLongAudio(ctx context.Context, opID string) (*Result, error) {
+	op, err := c.waitForOperation(ctx, opID)
+	if err != nil {
+		return nil, err
+	}
+	return buildResult(op.Response), nil
+}
 
-		req, err := http.NewRequest(http.MethodGet, url, nil)
-		if err != nil {
-			return "", fmt.Errorf("failed to create request: %w", err)
-		}
-		req.Header.Set("Authorization", "Api-Key "+c.apiKey)
-		if c.folderId != "" {
-			req.Header.Set("x-folder-id", c.folderId)
+// waitForOperation опрашивает операцию до завершения и возвращает её.
+// Пауза между опросами растёт от pollInterval до maxPollInterval; Retry-After
+// сервера имеет приоритет. Сетевые ошибки, 429 и 5xx повторяются не больше
+// maxPollFailures раз подряд, остальные ответы кроме 200 — фатальны.
+// Если операция не завершилась за operationTimeout, возвращается ErrOperationTimeout.
+func (c *Client) waitForOperation(parent context.Context, opID string) (*Operation, error) {
+	ctx, cancel := context.WithTimeout(parent, c.operationTimeout)
+	defer cancel()
+
+	url := fmt.Sprintf("%s%s/%s", c.endpoints.Operation, operationsPath, opID)
+	delay := c.pollInterval
+	failures := 0
+
+	// Отмена или дедлайн вызывающего отличаются от истечения operationTimeout
+	stopped := func() error {
+		if parent.Err() == nil {
+			return fmt.Errorf("%w: %s not done after %s", ErrOperationTimeout, opID, c.operationTimeout)
 		}
+		return fmt.Errorf("waiting for operation %s: %w", opID, parent.Err())
+	}
 
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			// Retry on network errors
-			delay = min(delay*2, maxDelay)
-			continue
+	for {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, stopped()
+		case <-timer.C:
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
+		op, retryAfter, err := c.getOperation(ctx, url)
 		if err != nil {
-			delay = min(delay*2, maxDelay)
-			continue
+			if ctx.Err() != nil {
+				return nil, stopped()
+			}
+			var transient *transientError
+			if !errors.As(err, &transient) {
+				return nil, err
+			}
+			failures++
+			if failures > maxPollFailures {
+				return nil, fmt.Errorf("operation check failed %d times: %w", failures, err)
+			}
+		} else {
+			failures = 0
+			if op.Done {
+				if op.Error != nil {
+					return nil, fmt.Errorf("recognition failed: %s", op.Error.Message)
+				}
+				return op, nil
+			}
 		}
 
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("operation check failed: status %d", resp.StatusCode)
+		delay = min(delay*2, c.maxPollInterval)
+		if retryAfter > 0 {
+			delay = retryAfter
 		}
+	}
+}
 
-		var op Operation
-		if err := json.Unmarshal(body, &op); err != nil {
-			return "", fmt.Errorf("failed to parse operation: %w", err)
-		}
+// getOperation запрашивает состояние операции. Возвращает паузу из Retry-After, если она есть.
+func (c *Client) getOperation(ctx context.Context, url string) (*Operation, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setAuthHeaders(req)
 
-		if op.Done {
-			if op.Error != nil {
-				return "", fmt.Errorf("recognition failed: %s", op.Error.Message)
-			}
-			return extractText(op.Response), nil
-		}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, &transientError{err: fmt.Errorf("request failed: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, retryAfter, &transientError{err: fmt.Errorf("failed to read response: %w", err)}
+	}
 
-		// Увеличиваем задержку
-		delay = min(delay*2, maxDelay)
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, 0, ErrOperationNotFound
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, retryAfter, &transientError{err: fmt.Errorf("operation check failed: status %d", resp.StatusCode)}
+	default:
+		return nil, 0, fmt.Errorf("operation check failed: status %d", resp.StatusCode)
 	}
 
-	return "", fmt.Errorf("operation timed out after %d attempts", maxAttempts)
+	var op Operation
+	if err := json.Unmarshal(body, &op); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse operation: %w", err)
+	}
+	return &op, retryAfter, nil
+}
+
+// transientError — ошибка опроса, после которой имеет смысл повторить запрос.
+type transientError struct {
+	err error
 }
 
-func extractText(result *OperationResult) string {
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// parseRetryAfter разбирает заголовок Retry-After: секунды или HTTP-дата.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// buildResult собирает текст и фразы из чанков ответа v2.
+func buildResult(result *OperationResult) *Result {
+	res := &Result{}
 	if result == nil {
-		return ""
+		return res
 	}
 
-	var text string
+	var texts []string
 	for _, chunk := range result.Chunks {
-		if len(chunk.Alternatives) > 0 {
-			if text != "" {
-				text += " "
-			}
-			text += chunk.Alternatives[0].Text
+		if len(chunk.Alternatives) == 0 {
+			continue
+		}
+		alt := chunk.Alternatives[0]
+		if alt.Text == "" {
+			continue
 		}
+		texts = append(texts, alt.Text)
+
+		utt := Utterance{Text: alt.Text}
+		for _, w := range alt.Words {
+			utt.Words = append(utt.Words, Word{
+				Text:  w.Word,
+				Start: parseSeconds(w.StartTime),
+				End:   parseSeconds(w.EndTime),
+			})
+		}
+		if len(utt.Words) > 0 {
+			utt.Start = utt.Words[0].Start
+			utt.End = utt.Words[len(utt.Words)-1].End
+		}
+		res.Utterances = append(res.Utterances, utt)
+	}
+	res.Text = strings.Join(texts, " ")
+
+	// Несколько каналов приходят вперемешку — упорядочиваем по времени
+	sort.SliceStable(res.Utterances, func(i, j int) bool {
+		return res.Utterances[i].Start < res.Utterances[j].Start
+	})
+	return res
+}
+
+// parseSeconds разбирает длительность вида "1.230s".
+func parseSeconds(value string) float64 {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
 	}
-	return text
+	return d.Seconds()
 }
 
 func min(a, b time.Duration) time.Duration {
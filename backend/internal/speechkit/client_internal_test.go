@@ -0,0 +1,20 @@
+package speechkit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Sat, 01 Mar 2025 12:01:30 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("0", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	// Дата в прошлом — повторять можно сразу
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Sat, 01 Mar 2025 11:59:00 GMT", now))
+}
@@ -0,0 +1,215 @@
+package speechkit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/speechkit"
+	"loopa/backend/internal/speechkit/speechkittest"
+)
+
+const v2Response = `{"chunks":[
+	{"alternatives":[{"text":"вторая фраза","words":[{"startTime":"2.5s","endTime":"3s","word":"вторая"},{"startTime":"3s","endTime":"3.4s","word":"фраза"}]}],"channelTag":"1"},
+	{"alternatives":[{"text":"первая","words":[{"startTime":"0.2s","endTime":"0.9s","word":"первая"}]}],"channelTag":"1"}
+]}`
+
+func TestRecognize(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetRecognizeText("привет мир")
+
+	text, err := srv.Client().Recognize([]byte("audio"), "ru-RU")
+	require.NoError(t, err)
+	assert.Equal(t, "привет мир", text)
+
+	reqs := srv.Requests()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "format=oggopus&lang=ru-RU", reqs[0].Query)
+	assert.Equal(t, []byte("audio"), reqs[0].Body)
+}
+
+func TestRecognize_Error(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.ScriptRecognize(speechkittest.Reply{
+		Status: http.StatusBadRequest,
+		Body:   `{"code":3,"message":"audio is too long"}`,
+	})
+
+	_, err := srv.Client().Recognize([]byte("audio"), "ru-RU")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "audio is too long")
+}
+
+func TestRecognizeLongAudio_WaitsForOperation(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetPendingPolls(3)
+	srv.SetV2Result(v2Response)
+	// Медленный ответ на первый опрос не должен прерывать ожидание
+	srv.ScriptOperations(speechkittest.Reply{Delay: 20 * time.Millisecond})
+
+	res, err := srv.Client().RecognizeLongAudio(context.Background(), "https://storage/bucket/a.ogg", speechkit.RecognitionOptions{
+		Model:           "deferred-general",
+		ProfanityFilter: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, srv.Count("/operations/"))
+	require.Len(t, res.Utterances, 2)
+	assert.Equal(t, "первая", res.Utterances[0].Text)
+	assert.Equal(t, 2.5, res.Utterances[1].Start)
+	assert.Equal(t, 3.4, res.Utterances[1].End)
+	require.Len(t, res.Utterances[1].Words, 2)
+
+	var req speechkit.LongRunningRequest
+	require.NoError(t, json.Unmarshal(srv.Requests()[0].Body, &req))
+	assert.Equal(t, "deferred-general", req.Config.Specification.Model)
+	assert.Equal(t, "ru-RU", req.Config.Specification.LanguageCode)
+	assert.True(t, req.Config.Specification.ProfanityFilter)
+	assert.Equal(t, "https://storage/bucket/a.ogg", req.Audio.URI)
+}
+
+func TestRecognizeLongAudio_OperationFailed(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetOperationError("unsupported audio")
+
+	_, err := srv.Client().RecognizeLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported audio")
+}
+
+func TestRecognizeLongAudio_OperationCheckFailed(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.ScriptOperations(speechkittest.Reply{Status: http.StatusForbidden, Body: `{}`})
+
+	_, err := srv.Client().RecognizeLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 403")
+	// Ошибки кроме 429 и 5xx не повторяются
+	assert.Equal(t, 1, srv.Count("/operations/"))
+}
+
+func TestRecognizeLongAudio_StartFailed(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.ScriptLongRunning(speechkittest.Reply{
+		Status: http.StatusForbidden,
+		Body:   `{"code":7,"message":"permission denied"}`,
+	})
+
+	_, err := srv.Client().RecognizeLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+	assert.Equal(t, 0, srv.Count("/operations/"))
+}
+
+func TestRecognizeFileAsync(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetPendingPolls(1)
+	srv.SetV3Stream(`{"result":{"channelTag":"0","audioCursors":{"finalIndex":"0"},"final":{"alternatives":[{"text":"пять рублей","startTimeMs":"100","endTimeMs":"900","words":[{"text":"пять","startTimeMs":"100","endTimeMs":"400"},{"text":"рублей","startTimeMs":"400","endTimeMs":"900"}]}]}}}
+{"result":{"channelTag":"0","finalRefinement":{"finalIndex":"0","normalizedText":{"alternatives":[{"text":"5 рублей.","startTimeMs":"100","endTimeMs":"900"}]}}}}
+`)
+
+	res, err := srv.Client().RecognizeFileAsync(context.Background(), "uri", speechkit.RecognitionOptions{Normalize: true, Language: "ru-RU"})
+	require.NoError(t, err)
+	assert.Equal(t, "5 рублей.", res.Text)
+	require.Len(t, res.Utterances, 1)
+	assert.Equal(t, 0.1, res.Utterances[0].Start)
+	assert.Len(t, res.Utterances[0].Words, 2)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(srv.Requests()[0].Body, &body))
+	model := body["recognitionModel"].(map[string]any)
+	norm := model["textNormalization"].(map[string]any)
+	assert.Equal(t, "TEXT_NORMALIZATION_ENABLED", norm["textNormalization"])
+}
+
+func TestRecognizeLongAudio_RetriesTransientErrors(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetV2Result(v2Response)
+	srv.ScriptOperations(
+		speechkittest.Reply{Status: http.StatusServiceUnavailable, Body: `{}`},
+		speechkittest.Reply{Status: http.StatusTooManyRequests, Body: `{}`, Header: map[string]string{"Retry-After": "1"}},
+	)
+
+	start := time.Now()
+	res, err := srv.Client().RecognizeLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.NoError(t, err)
+	assert.Len(t, res.Utterances, 2)
+	assert.Equal(t, 3, srv.Count("/operations/"))
+	// Пауза из Retry-After важнее короткого интервала опроса
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+}
+
+func TestRecognizeLongAudio_GivesUpAfterRepeatedFailures(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	for i := 0; i < 10; i++ {
+		srv.ScriptOperations(speechkittest.Reply{Status: http.StatusBadGateway, Body: `{}`})
+	}
+
+	_, err := srv.Client().RecognizeLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 502")
+	assert.Equal(t, 6, srv.Count("/operations/"))
+}
+
+func TestWaitLongAudio_UnknownOperation(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+
+	_, err := srv.Client().WaitLongAudio(context.Background(), "missing")
+	assert.True(t, errors.Is(err, speechkit.ErrOperationNotFound))
+}
+
+func TestWaitLongAudio_Cancelled(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetPendingPolls(1000)
+
+	client := srv.Client()
+	opID, err := client.StartLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.WaitLongAudio(ctx, opID)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWaitLongAudio_OperationTimeout(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetPendingPolls(1000)
+
+	client := speechkit.NewClientWithOptions("test-key", "test-folder", speechkit.Options{
+		Endpoints:        srv.Endpoints(),
+		PollInterval:     time.Millisecond,
+		MaxPollInterval:  5 * time.Millisecond,
+		OperationTimeout: 50 * time.Millisecond,
+	})
+	opID, err := client.StartLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.NoError(t, err)
+
+	_, err = client.WaitLongAudio(context.Background(), opID)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, speechkit.ErrOperationTimeout))
+	// Ожидание той же операции не повторяется
+	assert.False(t, speechkit.IsTransient(err))
+}
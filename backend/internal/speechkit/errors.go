@@ -0,0 +1,94 @@
+package speechkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// APIError — ответ SpeechKit с кодом, отличным от 200.
+type APIError struct {
+	StatusCode int
+	Code       int // код ошибки gRPC из тела ответа, если есть
+	Message    string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("speechkit error (code %d): %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("speechkit error: status %d, body: %s", e.StatusCode, e.Body)
+}
+
+// IsTransient сообщает, что ошибка временная: сеть, лимит запросов или сбой
+// на стороне SpeechKit. Повтор того же запроса позже может быть успешным.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrOperationTimeout) {
+		return false
+	}
+	var transient *transientError
+	if errors.As(err, &transient) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (c *Client) setAuthHeaders(req *http.Request) {
+	req.Header.Set("Authorization", "Api-Key "+c.apiKey)
+	if c.folderId != "" {
+		req.Header.Set("x-folder-id", c.folderId)
+	}
+}
+
+// do выполняет запрос и возвращает тело ответа 200 OK.
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &transientError{err: fmt.Errorf("failed to read response: %w", err)}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		var errResp ErrorResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
+			apiErr.Code = errResp.Code
+			apiErr.Message = errResp.Message
+		}
+		return nil, apiErr
+	}
+	return body, nil
+}
+
+// Health проверяет, что API SpeechKit доступен и принимает ключ:
+// запрашивает заведомо несуществующую операцию. 404 означает, что сервис
+// работает, 401/403 — что ключ не принят, 5xx и сетевые ошибки — что он недоступен.
+func (c *Client) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoints.Operation+operationsPath+"/healthcheck", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setAuthHeaders(req)
+
+	_, err = c.do(req)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusBadRequest) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,321 @@
+// Package speechkittest содержит фейковый сервер SpeechKit для тестов.
+//
+// Сервер реализует синхронный API v1, longRunningRecognize v2, операции,
+// recognizeFileAsync/getRecognition v3 и простейшее хранилище объектов
+// (PUT/DELETE по любому другому пути) для проверки загрузки в Object Storage.
+// Ответы можно подменять по очереди через Script*, в том числе с задержками и ошибками.
+package speechkittest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"loopa/backend/internal/speechkit"
+)
+
+const (
+	recognizePath          = "/speech/v1/stt:recognize"
+	longRunningPath        = "/speech/stt/v2/longRunningRecognize"
+	operationsPrefix       = "/operations/"
+	recognizeFileAsyncPath = "/stt/v3/recognizeFileAsync"
+	getRecognitionPath     = "/stt/v3/getRecognition"
+)
+
+// Reply — заранее заданный ответ на очередной запрос.
+// Нулевой Status и пустой Body означают обычный ответ фейка (после Delay).
+type Reply struct {
+	Status int
+	Body   string
+	Delay  time.Duration
+	Header map[string]string // например, Retry-After
+}
+
+// Request — запрос, полученный сервером.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+}
+
+// Server — фейковый SpeechKit поверх httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	recognize     []Reply
+	longRunning   []Reply
+	operations    []Reply
+	recognizeText string
+	pendingPolls  int
+	v2Result      string
+	v3Stream      string
+	opError       string
+	ops           map[string]*operation
+	objects       map[string][]byte
+	requests      []Request
+	nextID        int
+}
+
+type operation struct {
+	polls int
+	v3    bool
+}
+
+// NewServer запускает сервер. Закрывается через Close.
+func NewServer() *Server {
+	s := &Server{
+		recognizeText: "тест",
+		v2Result:      `{"chunks":[]}`,
+		ops:           map[string]*operation{},
+		objects:       map[string][]byte{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Endpoints возвращает адреса для speechkit.Options.
+func (s *Server) Endpoints() speechkit.Endpoints {
+	return speechkit.Endpoints{STT: s.URL, Transcribe: s.URL, Operation: s.URL}
+}
+
+// Client создаёт клиент SpeechKit, направленный на фейк, с быстрым опросом операций.
+func (s *Server) Client() *speechkit.Client {
+	return speechkit.NewClientWithOptions("test-key", "test-folder", speechkit.Options{
+		Endpoints:       s.Endpoints(),
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: 5 * time.Millisecond,
+	})
+}
+
+// SetRecognizeText задаёт ответ синхронного API.
+func (s *Server) SetRecognizeText(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recognizeText = text
+}
+
+// SetPendingPolls задаёт, сколько опросов новая операция остаётся незавершённой.
+func (s *Server) SetPendingPolls(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pendingPolls = n
+}
+
+// SetV2Result задаёт поле response завершённой операции v2 (JSON с chunks).
+func (s *Server) SetV2Result(response string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v2Result = response
+}
+
+// SetV3Stream задаёт поток ответов getRecognition (JSON-объекты по строкам).
+func (s *Server) SetV3Stream(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v3Stream = stream
+}
+
+// SetOperationError завершает операции ошибкой с указанным сообщением.
+func (s *Server) SetOperationError(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opError = message
+}
+
+// ScriptRecognize добавляет ответы синхронного API в очередь.
+func (s *Server) ScriptRecognize(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recognize = append(s.recognize, replies...)
+}
+
+// ScriptLongRunning добавляет ответы на запуск распознавания (v2 и v3) в очередь.
+func (s *Server) ScriptLongRunning(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.longRunning = append(s.longRunning, replies...)
+}
+
+// ScriptOperations добавляет ответы на опрос операций в очередь.
+func (s *Server) ScriptOperations(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.operations = append(s.operations, replies...)
+}
+
+// Requests возвращает копию списка полученных запросов.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Count возвращает число запросов, путь которых начинается с prefix.
+func (s *Server) Count(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if strings.HasPrefix(r.Path, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// Object возвращает содержимое объекта, загруженного PUT-запросом по пути path.
+func (s *Server) Object(path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[path]
+	return data, ok
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Body: body})
+	s.mu.Unlock()
+
+	path := r.URL.Path
+	isSpeechKit := path == recognizePath || path == longRunningPath || path == recognizeFileAsyncPath ||
+		path == getRecognitionPath || strings.HasPrefix(path, operationsPrefix)
+	if isSpeechKit && !strings.HasPrefix(r.Header.Get("Authorization"), "Api-Key ") {
+		writeError(w, http.StatusUnauthorized, "missing api key")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && path == recognizePath:
+		if s.scripted(w, &s.recognize) {
+			return
+		}
+		s.mu.Lock()
+		text := s.recognizeText
+		s.mu.Unlock()
+		writeJSON(w, map[string]string{"result": text})
+
+	case r.Method == http.MethodPost && (path == longRunningPath || path == recognizeFileAsyncPath):
+		if s.scripted(w, &s.longRunning) {
+			return
+		}
+		s.mu.Lock()
+		s.nextID++
+		id := fmt.Sprintf("op-%d", s.nextID)
+		s.ops[id] = &operation{polls: s.pendingPolls, v3: path == recognizeFileAsyncPath}
+		s.mu.Unlock()
+		writeJSON(w, map[string]any{"id": id, "done": false})
+
+	case r.Method == http.MethodGet && strings.HasPrefix(path, operationsPrefix):
+		if s.scripted(w, &s.operations) {
+			return
+		}
+		s.handleOperation(w, strings.TrimPrefix(path, operationsPrefix))
+
+	case r.Method == http.MethodGet && path == getRecognitionPath:
+		s.mu.Lock()
+		op, ok := s.ops[r.URL.Query().Get("operationId")]
+		stream := s.v3Stream
+		s.mu.Unlock()
+		if !ok || !op.v3 || op.polls > 0 {
+			writeError(w, http.StatusNotFound, "recognition not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, stream)
+
+	case r.Method == http.MethodPut:
+		s.mu.Lock()
+		s.objects[path] = body
+		s.mu.Unlock()
+		w.Header().Set("ETag", `"fake"`)
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodDelete:
+		s.mu.Lock()
+		delete(s.objects, path)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleOperation(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	op, ok := s.ops[id]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "operation not found")
+		return
+	}
+	if op.polls > 0 {
+		op.polls--
+		s.mu.Unlock()
+		writeJSON(w, map[string]any{"id": id, "done": false})
+		return
+	}
+	opError, v2Result, v3 := s.opError, s.v2Result, op.v3
+	s.mu.Unlock()
+
+	resp := map[string]any{"id": id, "done": true}
+	switch {
+	case opError != "":
+		resp["error"] = map[string]any{"code": 3, "message": opError}
+	case !v3:
+		resp["response"] = json.RawMessage(v2Result)
+	}
+	writeJSON(w, resp)
+}
+
+// scripted отвечает очередным ответом из очереди, если он есть.
+// Возвращает false, если запрос нужно обработать обычным образом.
+func (s *Server) scripted(w http.ResponseWriter, queue *[]Reply) bool {
+	s.mu.Lock()
+	if len(*queue) == 0 {
+		s.mu.Unlock()
+		return false
+	}
+	reply := (*queue)[0]
+	*queue = (*queue)[1:]
+	s.mu.Unlock()
+
+	if reply.Delay > 0 {
+		time.Sleep(reply.Delay)
+	}
+	if reply.Status == 0 && reply.Body == "" {
+		return false
+	}
+
+	status := reply.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	for k, v := range reply.Header {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, reply.Body)
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"code": status, "message": message})
+}
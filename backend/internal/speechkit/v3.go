@@ -0,0 +1,258 @@
+package speechkit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	recognizeFileAsyncPath = "/stt/v3/recognizeFileAsync"
+	getRecognitionPath     = "/stt/v3/getRecognition"
+)
+
+// RecognitionOptions — параметры асинхронного распознавания.
+type RecognitionOptions struct {
+	Model    string // "general" по умолчанию
+	Language string // ru-RU, en-US...; пустая строка — автоопределение
+	// Normalize включает нормализацию: числа цифрами, пунктуация.
+	Normalize bool
+	// LiteratureText — литературная норма (заглавные буквы, знаки препинания).
+	LiteratureText  bool
+	ProfanityFilter bool
+}
+
+type v3Request struct {
+	URI              string             `json:"uri"`
+	RecognitionModel v3RecognitionModel `json:"recognitionModel"`
+}
+
+type v3RecognitionModel struct {
+	Model               string                 `json:"model"`
+	AudioFormat         v3AudioFormat          `json:"audioFormat"`
+	TextNormalization   v3TextNormalization    `json:"textNormalization"`
+	LanguageRestriction *v3LanguageRestriction `json:"languageRestriction,omitempty"`
+	AudioProcessingType string                 `json:"audioProcessingType"`
+}
+
+type v3AudioFormat struct {
+	ContainerAudio struct {
+		ContainerAudioType string `json:"containerAudioType"`
+	} `json:"containerAudio"`
+}
+
+type v3TextNormalization struct {
+	TextNormalization string `json:"textNormalization"`
+	ProfanityFilter   bool   `json:"profanityFilter"`
+	LiteratureText    bool   `json:"literatureText"`
+}
+
+type v3LanguageRestriction struct {
+	RestrictionType string   `json:"restrictionType"`
+	LanguageCode    []string `json:"languageCode"`
+}
+
+// v3Response — одно сообщение потока getRecognition.
+type v3Response struct {
+	Result struct {
+		ChannelTag   string `json:"channelTag"`
+		AudioCursors struct {
+			FinalIndex string `json:"finalIndex"`
+		} `json:"audioCursors"`
+		Final *struct {
+			Alternatives []v3Alternative `json:"alternatives"`
+		} `json:"final"`
+		FinalRefinement *struct {
+			FinalIndex     string `json:"finalIndex"`
+			NormalizedText struct {
+				Alternatives []v3Alternative `json:"alternatives"`
+			} `json:"normalizedText"`
+		} `json:"finalRefinement"`
+	} `json:"result"`
+}
+
+type v3Alternative struct {
+	Text        string   `json:"text"`
+	StartTimeMs string   `json:"startTimeMs"`
+	EndTimeMs   string   `json:"endTimeMs"`
+	Words       []v3Word `json:"words"`
+}
+
+type v3Word struct {
+	Text        string `json:"text"`
+	StartTimeMs string `json:"startTimeMs"`
+	EndTimeMs   string `json:"endTimeMs"`
+}
+
+// RecognizeFileAsync распознаёт файл из Object Storage через API v3.
+// В отличие от RecognizeLongAudio (v2) поддерживает нормализацию текста,
+// фильтр ненормативной лексики и выбор модели; результат разбит на фразы.
+func (c *Client) RecognizeFileAsync(ctx context.Context, fileURI string, opts RecognitionOptions) (*Result, error) {
+	opID, err := c.StartFileAsync(ctx, fileURI, opts)
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitFileAsync(ctx, opID)
+}
+
+// WaitFileAsync дожидается операции v3 и загружает результат распознавания.
+func (c *Client) WaitFileAsync(ctx context.Context, opID string) (*Result, error) {
+	if _, err := c.waitForOperation(ctx, opID); err != nil {
+		return nil, err
+	}
+	return c.getRecognition(ctx, opID)
+}
+
+// StartFileAsync запускает распознавание v3 и возвращает ID операции.
+func (c *Client) StartFileAsync(ctx context.Context, fileURI string, opts RecognitionOptions) (string, error) {
+	model := opts.Model
+	if model == "" {
+		model = "general"
+	}
+	normalization := "TEXT_NORMALIZATION_DISABLED"
+	if opts.Normalize {
+		normalization = "TEXT_NORMALIZATION_ENABLED"
+	}
+
+	reqBody := v3Request{
+		URI: fileURI,
+		RecognitionModel: v3RecognitionModel{
+			Model: model,
+			TextNormalization: v3TextNormalization{
+				TextNormalization: normalization,
+				ProfanityFilter:   opts.ProfanityFilter,
+				LiteratureText:    opts.LiteratureText,
+			},
+			AudioProcessingType: "FULL_DATA",
+		},
+	}
+	reqBody.RecognitionModel.AudioFormat.ContainerAudio.ContainerAudioType = "OGG_OPUS"
+	if opts.Language != "" {
+		reqBody.RecognitionModel.LanguageRestriction = &v3LanguageRestriction{
+			RestrictionType: "WHITELIST",
+			LanguageCode:    []string{opts.Language},
+		}
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoints.STT+recognizeFileAsyncPath, bytes.NewReader(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req)
+
+	body, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+
+	var op Operation
+	if err := json.Unmarshal(body, &op); err != nil {
+		return "", fmt.Errorf("failed to parse operation: %w", err)
+	}
+	if op.ID == "" {
+		return "", fmt.Errorf("speechkit returned operation without id")
+	}
+	return op.ID, nil
+}
+
+func (c *Client) getRecognition(ctx context.Context, opID string) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoints.STT+getRecognitionPath+"?operationId="+url.QueryEscape(opID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.setAuthHeaders(req)
+
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return parseV3Stream(body)
+}
+
+// parseV3Stream собирает фразы из потока ответов getRecognition.
+// Для каждой финальной фразы берётся нормализованный вариант (finalRefinement), если он есть.
+func parseV3Stream(data []byte) (*Result, error) {
+	type key struct{ channel, index string }
+	finals := map[key]v3Alternative{}
+	refined := map[key]v3Alternative{}
+
+	// Поток — последовательность JSON-объектов (обычно по одному на строку)
+	dec := json.NewDecoder(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		var msg v3Response
+		if err := dec.Decode(&msg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse recognition stream: %w", err)
+		}
+
+		r := msg.Result
+		if r.Final != nil && len(r.Final.Alternatives) > 0 {
+			finals[key{r.ChannelTag, r.AudioCursors.FinalIndex}] = r.Final.Alternatives[0]
+		}
+		if r.FinalRefinement != nil && len(r.FinalRefinement.NormalizedText.Alternatives) > 0 {
+			refined[key{r.ChannelTag, r.FinalRefinement.FinalIndex}] = r.FinalRefinement.NormalizedText.Alternatives[0]
+		}
+	}
+
+	res := &Result{}
+	for k, alt := range finals {
+		if norm, ok := refined[k]; ok {
+			if len(norm.Words) == 0 {
+				norm.Words = alt.Words
+			}
+			alt = norm
+		}
+		if strings.TrimSpace(alt.Text) == "" {
+			continue
+		}
+
+		utt := Utterance{
+			Text:  alt.Text,
+			Start: parseMs(alt.StartTimeMs),
+			End:   parseMs(alt.EndTimeMs),
+		}
+		for _, w := range alt.Words {
+			utt.Words = append(utt.Words, Word{
+				Text:  w.Text,
+				Start: parseMs(w.StartTimeMs),
+				End:   parseMs(w.EndTimeMs),
+			})
+		}
+		res.Utterances = append(res.Utterances, utt)
+	}
+
+	sort.SliceStable(res.Utterances, func(i, j int) bool {
+		return res.Utterances[i].Start < res.Utterances[j].Start
+	})
+
+	texts := make([]string, len(res.Utterances))
+	for i, utt := range res.Utterances {
+		texts[i] = utt.Text
+	}
+	res.Text = strings.Join(texts, " ")
+	return res, nil
+}
+
+// parseMs переводит миллисекунды (int64 в JSON приходит строкой) в секунды.
+func parseMs(value string) float64 {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return float64(ms) / 1000
+}
@@ -0,0 +1,38 @@
+package speechkit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseV3Stream(t *testing.T) {
+	stream := `{"result":{"channelTag":"0","audioCursors":{"finalIndex":"1"},"final":{"alternatives":[{"text":"второе","startTimeMs":"3000","endTimeMs":"3500","words":[{"text":"второе","startTimeMs":"3000","endTimeMs":"3500"}]}]}}}
+{"result":{"channelTag":"0","audioCursors":{"finalIndex":"0"},"final":{"alternatives":[{"text":"договор номер пять","startTimeMs":"0","endTimeMs":"1500","words":[{"text":"договор","startTimeMs":"0","endTimeMs":"500"},{"text":"номер","startTimeMs":"500","endTimeMs":"1000"},{"text":"пять","startTimeMs":"1000","endTimeMs":"1500"}]}]}}}
+{"result":{"channelTag":"0","finalRefinement":{"finalIndex":"0","normalizedText":{"alternatives":[{"text":"Договор № 5.","startTimeMs":"0","endTimeMs":"1500"}]}}}}
+{"result":{"channelTag":"0","audioCursors":{"finalIndex":"2"},"final":{"alternatives":[{"text":"","startTimeMs":"4000","endTimeMs":"4100"}]}}}
+`
+
+	res, err := parseV3Stream([]byte(stream))
+	require.NoError(t, err)
+	require.Len(t, res.Utterances, 2)
+
+	first := res.Utterances[0]
+	assert.Equal(t, "Договор № 5.", first.Text)
+	assert.Equal(t, 0.0, first.Start)
+	assert.Equal(t, 1.5, first.End)
+	// Нормализованный вариант без слов сохраняет таймкоды исходных слов
+	require.Len(t, first.Words, 3)
+	assert.Equal(t, "номер", first.Words[1].Text)
+	assert.Equal(t, 0.5, first.Words[1].Start)
+
+	assert.Equal(t, "второе", res.Utterances[1].Text)
+	assert.Equal(t, 3.0, res.Utterances[1].Start)
+	assert.Equal(t, "Договор № 5. второе", res.Text)
+}
+
+func TestParseV3Stream_Invalid(t *testing.T) {
+	_, err := parseV3Stream([]byte(`{"result":`))
+	assert.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -12,15 +13,23 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
-const yandexStorageEndpoint = "https://storage.yandexcloud.net"
+// DefaultEndpoint — адрес Yandex Object Storage.
+const DefaultEndpoint = "https://storage.yandexcloud.net"
 
 type S3Client struct {
-	client *s3.Client
-	bucket string
+	client   *s3.Client
+	bucket   string
+	endpoint string
 }
 
 // NewS3Client создаёт клиент для Yandex Object Storage.
-func NewS3Client(accessKey, secretKey, bucket string) (*S3Client, error) {
+// endpoint — адрес S3-совместимого хранилища, пустая строка — DefaultEndpoint.
+func NewS3Client(accessKey, secretKey, bucket, endpoint string) (*S3Client, error) {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+
 	cfg, err := config.LoadDefaultConfig(context.Background(),
 		config.WithCredentialsProvider(
 			credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
@@ -32,12 +41,15 @@ func NewS3Client(accessKey, secretKey, bucket string) (*S3Client, error) {
 	}
 
 	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
-		o.BaseEndpoint = aws.String(yandexStorageEndpoint)
+		o.BaseEndpoint = aws.String(endpoint)
+		// Адреса вида endpoint/bucket/key — в том же виде URI передаётся в SpeechKit
+		o.UsePathStyle = true
 	})
 
 	return &S3Client{
-		client: client,
-		bucket: bucket,
+		client:   client,
+		bucket:   bucket,
+		endpoint: endpoint,
 	}, nil
 }
 
@@ -59,7 +71,7 @@ func (c *S3Client) Upload(ctx context.Context, localPath, key string) (string, e
 	}
 
 	// Возвращаем URI в формате для SpeechKit
-	uri := fmt.Sprintf("https://storage.yandexcloud.net/%s/%s", c.bucket, key)
+	uri := fmt.Sprintf("%s/%s/%s", c.endpoint, c.bucket, key)
 	return uri, nil
 }
 
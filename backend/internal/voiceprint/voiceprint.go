@@ -0,0 +1,140 @@
+// Package voiceprint сравнивает эмбеддинги голосов спикеров с профилями
+// проекта и уточняет профили новыми записями.
+package voiceprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DefaultThreshold — минимальная косинусная близость, при которой спикер
+// считается владельцем профиля.
+const DefaultThreshold = 0.7
+
+// Profile — голосовой профиль проекта.
+type Profile struct {
+	ID        string
+	Name      string
+	Embedding []float64
+}
+
+// Speaker — спикер записи с эмбеддингом голоса.
+type Speaker struct {
+	ID        string
+	Embedding []float64
+}
+
+// Match — профиль, найденный для спикера.
+type Match struct {
+	SpeakerID  string
+	Profile    Profile
+	Similarity float64
+}
+
+// Encode сериализует эмбеддинг для хранения в базе.
+func Encode(embedding []float64) string {
+	data, _ := json.Marshal(embedding)
+	return string(data)
+}
+
+// Decode разбирает сохранённый эмбеддинг.
+func Decode(value string) ([]float64, error) {
+	var embedding []float64
+	if err := json.Unmarshal([]byte(value), &embedding); err != nil {
+		return nil, fmt.Errorf("parse embedding: %w", err)
+	}
+	if len(embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding")
+	}
+	return embedding, nil
+}
+
+// Cosine возвращает косинусную близость векторов; для векторов разной
+// длины или нулевых — 0.
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// Merge добавляет эмбеддинг новой записи к профилю, построенному по samples
+// записям: профиль — нормированное среднее всех записей.
+func Merge(profile []float64, samples int, embedding []float64) []float64 {
+	return Combine(profile, float64(samples), embedding, 1)
+}
+
+// Combine возвращает нормированное взвешенное среднее двух эмбеддингов
+// (веса — число записей или длительность речи). Если один из эмбеддингов пуст,
+// имеет нулевой вес или размерности не совпадают, берётся другой (при
+// несовпадении — b).
+func Combine(a []float64, weightA float64, b []float64, weightB float64) []float64 {
+	switch {
+	case len(b) == 0 || weightB <= 0:
+		return normalize(a)
+	case len(a) == 0 || weightA <= 0 || len(a) != len(b):
+		return normalize(b)
+	}
+	merged := make([]float64, len(a))
+	for i := range a {
+		merged[i] = (a[i]*weightA + b[i]*weightB) / (weightA + weightB)
+	}
+	return normalize(merged)
+}
+
+// MatchSpeakers сопоставляет спикеров записи с профилями. Пары перебираются
+// по убыванию близости, поэтому каждому профилю достаётся не больше одного
+// спикера записи и наоборот. Пары ниже threshold не сопоставляются.
+func MatchSpeakers(speakers []Speaker, profiles []Profile, threshold float64) []Match {
+	var pairs []Match
+	for _, sp := range speakers {
+		for _, p := range profiles {
+			sim := Cosine(sp.Embedding, p.Embedding)
+			if sim >= threshold {
+				pairs = append(pairs, Match{SpeakerID: sp.ID, Profile: p, Similarity: sim})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Similarity > pairs[j].Similarity })
+
+	usedSpeakers := map[string]bool{}
+	usedProfiles := map[string]bool{}
+	var matches []Match
+	for _, pair := range pairs {
+		if usedSpeakers[pair.SpeakerID] || usedProfiles[pair.Profile.ID] {
+			continue
+		}
+		usedSpeakers[pair.SpeakerID] = true
+		usedProfiles[pair.Profile.ID] = true
+		matches = append(matches, pair)
+	}
+	return matches
+}
+
+func normalize(v []float64) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	out := make([]float64, len(v))
+	if norm == 0 {
+		copy(out, v)
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package voiceprint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosine(t *testing.T) {
+	assert.InDelta(t, 1.0, Cosine([]float64{1, 0}, []float64{2, 0}), 1e-9)
+	assert.InDelta(t, 0.0, Cosine([]float64{1, 0}, []float64{0, 1}), 1e-9)
+	assert.Equal(t, 0.0, Cosine([]float64{1, 0}, []float64{1, 0, 0}))
+	assert.Equal(t, 0.0, Cosine([]float64{0, 0}, []float64{1, 0}))
+}
+
+func TestMatchSpeakers_OneProfilePerSpeaker(t *testing.T) {
+	profiles := []Profile{
+		{ID: "p-anna", Name: "Анна", Embedding: []float64{1, 0, 0}},
+		{ID: "p-boris", Name: "Борис", Embedding: []float64{0, 1, 0}},
+	}
+	speakers := []Speaker{
+		{ID: "SPEAKER_00", Embedding: []float64{0.1, 0.99, 0}},
+		// Оба спикера похожи на Анну, но SPEAKER_02 ближе
+		{ID: "SPEAKER_01", Embedding: []float64{0.8, 0, 0.6}},
+		{ID: "SPEAKER_02", Embedding: []float64{0.95, 0, 0.3}},
+		// Незнакомый голос
+		{ID: "SPEAKER_03", Embedding: []float64{0, 0, 1}},
+	}
+
+	matches := MatchSpeakers(speakers, profiles, 0.7)
+
+	require.Len(t, matches, 2)
+	bySpeaker := map[string]string{}
+	for _, m := range matches {
+		bySpeaker[m.SpeakerID] = m.Profile.Name
+	}
+	assert.Equal(t, map[string]string{"SPEAKER_00": "Борис", "SPEAKER_02": "Анна"}, bySpeaker)
+}
+
+func TestMerge(t *testing.T) {
+	merged := Merge([]float64{1, 0}, 1, []float64{0, 1})
+	assert.InDelta(t, 0.7071, merged[0], 1e-4)
+	assert.InDelta(t, 0.7071, merged[1], 1e-4)
+
+	// Новый профиль — нормированный эмбеддинг записи
+	assert.Equal(t, []float64{0.6, 0.8}, Merge(nil, 0, []float64{3, 4}))
+}
+
+func TestCombine(t *testing.T) {
+	// Вес 3:1 в пользу первого
+	combined := Combine([]float64{1, 0}, 3, []float64{0, 1}, 1)
+	assert.InDelta(t, 0.9487, combined[0], 1e-4)
+	assert.InDelta(t, 0.3162, combined[1], 1e-4)
+
+	assert.Equal(t, []float64{0, 1}, Combine(nil, 0, []float64{0, 2}, 1))
+	assert.Equal(t, []float64{1, 0}, Combine([]float64{2, 0}, 1, nil, 0))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	embedding, err := Decode(Encode([]float64{0.6, -0.8}))
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0.6, -0.8}, embedding)
+
+	_, err = Decode("[]")
+	assert.Error(t, err)
+	_, err = Decode("oops")
+	assert.Error(t, err)
+}
@@ -0,0 +1,261 @@
+// Package whispercpp запускает распознавание локальным бинарником whisper.cpp
+// (whisper-cli) без ML-сервиса: для небольших установок без GPU.
+package whispercpp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Options — настройки запуска whisper.cpp.
+type Options struct {
+	Binary   string // путь или имя в PATH; по умолчанию whisper-cli
+	Model    string // путь к модели ggml, например ggml-large-v3-turbo.bin
+	Threads  int    // 0 — по умолчанию whisper.cpp
+	Language string // ru, en...; пустая строка — автоопределение
+}
+
+// Client запускает whisper.cpp для каждого файла отдельным процессом.
+type Client struct {
+	binary   string
+	model    string
+	threads  int
+	language string
+}
+
+// Result — результат распознавания файла.
+type Result struct {
+	Language string
+	Text     string
+	Segments []Segment
+}
+
+// Segment — фраза whisper.cpp с таймкодами в секундах.
+type Segment struct {
+	Start float64
+	End   float64
+	Text  string
+	Words []Word
+}
+
+// Word — слово, собранное из токенов whisper.cpp.
+type Word struct {
+	Text  string
+	Start float64
+	End   float64
+}
+
+func New(opts Options) *Client {
+	binary := opts.Binary
+	if binary == "" {
+		binary = "whisper-cli"
+	}
+	language := opts.Language
+	if language == "" {
+		language = "auto"
+	}
+	return &Client{binary: binary, model: opts.Model, threads: opts.Threads, language: language}
+}
+
+// Check проверяет, что бинарник и модель на месте.
+func (c *Client) Check(ctx context.Context) error {
+	if _, err := exec.LookPath(c.binary); err != nil {
+		return fmt.Errorf("whisper.cpp binary not found: %w", err)
+	}
+	if c.model == "" {
+		return errors.New("whisper.cpp model is not configured")
+	}
+	if _, err := os.Stat(c.model); err != nil {
+		return fmt.Errorf("whisper.cpp model not found: %w", err)
+	}
+	return nil
+}
+
+var progressRe = regexp.MustCompile(`progress\s*=\s*(\d+)%`)
+
+// Transcribe распознаёт WAV 16 кГц моно (см. media.PreprocessWAV).
+// prompt — начальная подсказка с терминами словаря; пустая строка — без подсказки.
+// onProgress, если задан, получает прогресс в процентах.
+// Отмена ctx останавливает процесс whisper.cpp.
+func (c *Client) Transcribe(ctx context.Context, wavPath, prompt string, onProgress func(percent int)) (*Result, error) {
+	outDir, err := os.MkdirTemp("", "whispercpp-")
+	if err != nil {
+		return nil, fmt.Errorf("create output dir: %w", err)
+	}
+	defer os.RemoveAll(outDir)
+	outBase := filepath.Join(outDir, "result")
+
+	args := []string{
+		"-m", c.model,
+		"-f", wavPath,
+		"-l", c.language,
+		"-oj", "-ojf",
+		"-of", outBase,
+		"-pp",
+	}
+	if c.threads > 0 {
+		args = append(args, "-t", strconv.Itoa(c.threads))
+	}
+	if prompt != "" {
+		args = append(args, "--prompt", prompt)
+	}
+
+	cmd := exec.CommandContext(ctx, c.binary, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("whisper.cpp: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start whisper.cpp: %w", err)
+	}
+
+	// Последние строки stderr попадают в текст ошибки
+	var tail []string
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := progressRe.FindStringSubmatch(line); m != nil {
+			if percent, err := strconv.Atoi(m[1]); err == nil && onProgress != nil {
+				onProgress(percent)
+			}
+			continue
+		}
+		tail = append(tail, line)
+		if len(tail) > 5 {
+			tail = tail[1:]
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("whisper.cpp failed: %w: %s", err, strings.TrimSpace(strings.Join(tail, "\n")))
+	}
+
+	data, err := os.ReadFile(outBase + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("read whisper.cpp output: %w", err)
+	}
+	return parseOutput(data)
+}
+
+// output — JSON whisper.cpp с ключами -oj -ojf (токены с таймкодами).
+type output struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets offsets         `json:"offsets"`
+		Text    json.RawMessage `json:"text"`
+		Tokens  []struct {
+			Text    json.RawMessage `json:"text"`
+			Offsets offsets         `json:"offsets"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// offsets — таймкоды в миллисекундах.
+type offsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+func parseOutput(data []byte) (*Result, error) {
+	var out output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("parse whisper.cpp output: %w", err)
+	}
+
+	res := &Result{Language: out.Result.Language}
+	texts := make([]string, 0, len(out.Transcription))
+	for _, item := range out.Transcription {
+		seg := Segment{
+			Start: float64(item.Offsets.From) / 1000,
+			End:   float64(item.Offsets.To) / 1000,
+			Text:  strings.TrimSpace(rawString(item.Text)),
+		}
+		if seg.Text == "" {
+			continue
+		}
+
+		// Токен с пробелом в начале открывает новое слово. Токены склеиваются
+		// побайтно: буква кириллицы может быть разрезана между двумя токенами.
+		var word []byte
+		var start, end int64
+		flush := func() {
+			if text := strings.TrimSpace(string(word)); text != "" {
+				seg.Words = append(seg.Words, Word{Text: text, Start: float64(start) / 1000, End: float64(end) / 1000})
+			}
+			word = word[:0]
+		}
+		for _, token := range item.Tokens {
+			text := rawString(token.Text)
+			if strings.HasPrefix(text, "[_") && strings.HasSuffix(text, "]") {
+				continue // служебные токены: [_BEG_], [_TT_150]
+			}
+			if strings.HasPrefix(text, " ") && len(word) > 0 {
+				flush()
+			}
+			if len(word) == 0 {
+				start = token.Offsets.From
+			}
+			word = append(word, text...)
+			end = token.Offsets.To
+		}
+		flush()
+
+		res.Segments = append(res.Segments, seg)
+		texts = append(texts, seg.Text)
+	}
+	res.Text = strings.Join(texts, " ")
+	return res, nil
+}
+
+// rawString раскрывает JSON-строку, сохраняя байты как есть.
+// json.Unmarshal заменил бы части многобайтовых символов на U+FFFD.
+func rawString(raw json.RawMessage) string {
+	s := strings.TrimSpace(string(raw))
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return ""
+	}
+	s = s[1 : len(s)-1]
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 32); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					continue
+				}
+			}
+			b.WriteByte('u')
+		default: // \" \\ \/
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,123 @@
+package whispercpp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// «привет» разрезано посреди буквы «в» (0xD0 0xB2), как это делает токенизатор.
+var sampleOutput = strings.NewReplacer(`\xd0`, "\xd0", `\xb2`, "\xb2").Replace(`{
+  "result": {"language": "ru"},
+  "transcription": [
+    {
+      "offsets": {"from": 0, "to": 2100},
+      "text": " Привет, мир.",
+      "tokens": [
+        {"text": "[_BEG_]", "offsets": {"from": 0, "to": 0}},
+        {"text": " При\xd0", "offsets": {"from": 0, "to": 400}},
+        {"text": "\xb2ет,", "offsets": {"from": 400, "to": 900}},
+        {"text": " мир.", "offsets": {"from": 1000, "to": 2100}},
+        {"text": "[_TT_105]", "offsets": {"from": 2100, "to": 2100}}
+      ]
+    },
+    {"offsets": {"from": 2100, "to": 2500}, "text": " ", "tokens": []},
+    {
+      "offsets": {"from": 2500, "to": 4000},
+      "text": " Скажи \"да\"",
+      "tokens": [
+        {"text": " Скажи", "offsets": {"from": 2500, "to": 3000}},
+        {"text": " \"", "offsets": {"from": 3100, "to": 3200}},
+        {"text": "да\"", "offsets": {"from": 3200, "to": 4000}}
+      ]
+    }
+  ]
+}`)
+
+func TestParseOutput(t *testing.T) {
+	res, err := parseOutput([]byte(sampleOutput))
+	require.NoError(t, err)
+
+	assert.Equal(t, "ru", res.Language)
+	assert.Equal(t, `Привет, мир. Скажи "да"`, res.Text)
+	require.Len(t, res.Segments, 2)
+
+	first := res.Segments[0]
+	assert.Equal(t, 0.0, first.Start)
+	assert.Equal(t, 2.1, first.End)
+	assert.Equal(t, []Word{
+		{Text: "Привет,", Start: 0, End: 0.9},
+		{Text: "мир.", Start: 1, End: 2.1},
+	}, first.Words)
+
+	second := res.Segments[1]
+	require.Len(t, second.Words, 2)
+	assert.Equal(t, `"да"`, second.Words[1].Text)
+	assert.Equal(t, 3.1, second.Words[1].Start)
+}
+
+func TestParseOutput_Invalid(t *testing.T) {
+	_, err := parseOutput([]byte("not json"))
+	assert.Error(t, err)
+}
+
+// writeFakeBinary создаёт скрипт, который ведёт себя как whisper-cli:
+// печатает прогресс в stderr и пишет JSON в файл из аргумента -of.
+func writeFakeBinary(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "output.json"), []byte(sampleOutput), 0o644))
+	script := "#!/bin/sh\nFIXTURE=" + filepath.Join(dir, "output.json") + "\n" + body
+	path := filepath.Join(dir, "whisper-cli")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestTranscribe(t *testing.T) {
+	binary := writeFakeBinary(t, `
+while [ $# -gt 0 ]; do
+  case "$1" in
+    -of) OUT="$2"; shift ;;
+    --prompt) [ "$2" = "Loopa, SpeechKit" ] || exit 7; shift ;;
+  esac
+  shift
+done
+echo "whisper_print_progress_callback: progress =  50%" >&2
+echo "whisper_print_progress_callback: progress = 100%" >&2
+cp "$FIXTURE" "$OUT.json"
+`)
+	client := New(Options{Binary: binary, Model: "model.bin", Threads: 2})
+
+	var progress []int
+	res, err := client.Transcribe(context.Background(), "audio.wav", "Loopa, SpeechKit", func(p int) { progress = append(progress, p) })
+	require.NoError(t, err)
+	assert.Len(t, res.Segments, 2)
+	assert.Equal(t, []int{50, 100}, progress)
+}
+
+func TestTranscribe_Failure(t *testing.T) {
+	binary := writeFakeBinary(t, `
+echo "error: failed to open 'model.bin'" >&2
+exit 3
+`)
+	client := New(Options{Binary: binary, Model: "model.bin"})
+
+	_, err := client.Transcribe(context.Background(), "audio.wav", "", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to open 'model.bin'")
+}
+
+func TestCheck(t *testing.T) {
+	model := filepath.Join(t.TempDir(), "ggml-base.bin")
+	binary := writeFakeBinary(t, "exit 0\n")
+
+	assert.Error(t, New(Options{Binary: binary, Model: model}).Check(context.Background()))
+	require.NoError(t, os.WriteFile(model, []byte("ggml"), 0o644))
+	assert.NoError(t, New(Options{Binary: binary, Model: model}).Check(context.Background()))
+	assert.Error(t, New(Options{Binary: filepath.Join(t.TempDir(), "missing"), Model: model}).Check(context.Background()))
+}
@@ -0,0 +1,109 @@
+package worker
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"loopa/backend/internal/mlclient"
+)
+
+// alignWords назначает каждое слово спикеру с максимальным перекрытием по времени
+// (или ближайшему, если перекрытия нет) и группирует подряд идущие слова
+// одного спикера в сегменты. Тот же алгоритм, что и в ML-сервисе для Whisper.
+func alignWords(words []mlclient.WordTimestamp, turns []mlclient.DiarizationSegment) []mlclient.TranscribeSegment {
+	if len(words) == 0 {
+		return nil
+	}
+	if len(turns) == 0 {
+		return []mlclient.TranscribeSegment{buildSegment("", words)}
+	}
+
+	var segments []mlclient.TranscribeSegment
+	currentSpeaker := speakerForWord(words[0], turns)
+	current := []mlclient.WordTimestamp{words[0]}
+
+	for _, word := range words[1:] {
+		speaker := speakerForWord(word, turns)
+		if speaker != currentSpeaker {
+			segments = append(segments, buildSegment(currentSpeaker, current))
+			currentSpeaker = speaker
+			current = nil
+		}
+		current = append(current, word)
+	}
+	segments = append(segments, buildSegment(currentSpeaker, current))
+
+	return segments
+}
+
+func speakerForWord(word mlclient.WordTimestamp, turns []mlclient.DiarizationSegment) string {
+	best := ""
+	bestOverlap := 0.0
+	for _, turn := range turns {
+		overlap := min(word.End, turn.End) - max(word.Start, turn.Start)
+		if overlap > bestOverlap {
+			bestOverlap = overlap
+			best = turn.Speaker
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	// Слово попало в паузу между репликами — берём ближайшую границу
+	best = turns[0].Speaker
+	bestDistance := -1.0
+	for _, turn := range turns {
+		dist := min(math.Abs(word.Start-turn.Start), math.Abs(word.Start-turn.End))
+		if bestDistance < 0 || dist < bestDistance {
+			bestDistance = dist
+			best = turn.Speaker
+		}
+	}
+	return best
+}
+
+func buildSegment(speaker string, words []mlclient.WordTimestamp) mlclient.TranscribeSegment {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.Word
+	}
+	return mlclient.TranscribeSegment{
+		Speaker: speaker,
+		Start:   words[0].Start,
+		End:     words[len(words)-1].End,
+		Text:    strings.Join(texts, " "),
+		Words:   words,
+	}
+}
+
+// estimateWordTimings равномерно распределяет слова по интервалу [start, end]
+// пропорционально их длине. Используется для синхронного API SpeechKit,
+// который не возвращает таймкоды слов.
+func estimateWordTimings(text string, start, end float64) []mlclient.WordTimestamp {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// +1 на каждое слово — условный пробел, чтобы короткие слова не схлопывались
+	total := 0
+	for _, f := range fields {
+		total += utf8.RuneCountInString(f) + 1
+	}
+
+	span := end - start
+	words := make([]mlclient.WordTimestamp, len(fields))
+	pos := 0
+	for i, f := range fields {
+		length := utf8.RuneCountInString(f) + 1
+		words[i] = mlclient.WordTimestamp{
+			Word:  f,
+			Start: start + span*float64(pos)/float64(total),
+			End:   start + span*float64(pos+length-1)/float64(total),
+		}
+		pos += length
+	}
+	return words
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/mlclient"
+)
+
+func TestAlignWords_SplitsBySpeakerTurns(t *testing.T) {
+	words := []mlclient.WordTimestamp{
+		{Word: "добрый", Start: 0.1, End: 0.4},
+		{Word: "день", Start: 0.45, End: 0.8},
+		{Word: "здравствуйте", Start: 1.2, End: 1.9},
+		{Word: "начнём", Start: 2.5, End: 2.9},
+	}
+	turns := []mlclient.DiarizationSegment{
+		{Speaker: "SPEAKER_00", Start: 0, End: 1.0},
+		{Speaker: "SPEAKER_01", Start: 1.1, End: 2.0},
+		{Speaker: "SPEAKER_00", Start: 2.4, End: 3.0},
+	}
+
+	segments := alignWords(words, turns)
+
+	require.Len(t, segments, 3)
+	assert.Equal(t, "SPEAKER_00", segments[0].Speaker)
+	assert.Equal(t, "добрый день", segments[0].Text)
+	assert.Equal(t, 0.1, segments[0].Start)
+	assert.Equal(t, 0.8, segments[0].End)
+	assert.Equal(t, "SPEAKER_01", segments[1].Speaker)
+	assert.Equal(t, "здравствуйте", segments[1].Text)
+	assert.Equal(t, "SPEAKER_00", segments[2].Speaker)
+	assert.Len(t, segments[2].Words, 1)
+}
+
+func TestAlignWords_WordInPauseGoesToNearestTurn(t *testing.T) {
+	words := []mlclient.WordTimestamp{
+		{Word: "да", Start: 5.0, End: 5.2},
+	}
+	turns := []mlclient.DiarizationSegment{
+		{Speaker: "SPEAKER_00", Start: 0, End: 2.0},
+		{Speaker: "SPEAKER_01", Start: 5.5, End: 8.0},
+	}
+
+	segments := alignWords(words, turns)
+
+	require.Len(t, segments, 1)
+	assert.Equal(t, "SPEAKER_01", segments[0].Speaker)
+}
+
+func TestAlignWords_NoTurns(t *testing.T) {
+	words := []mlclient.WordTimestamp{
+		{Word: "раз", Start: 0, End: 0.3},
+		{Word: "два", Start: 0.4, End: 0.7},
+	}
+
+	segments := alignWords(words, nil)
+
+	require.Len(t, segments, 1)
+	assert.Equal(t, "", segments[0].Speaker)
+	assert.Equal(t, "раз два", segments[0].Text)
+	assert.Nil(t, alignWords(nil, nil))
+}
+
+func TestEstimateWordTimings(t *testing.T) {
+	words := estimateWordTimings("а бв где", 10, 19)
+
+	require.Len(t, words, 3)
+	// длины с пробелом: 2 + 3 + 4 = 9, по секунде на символ
+	assert.Equal(t, mlclient.WordTimestamp{Word: "а", Start: 10, End: 11}, words[0])
+	assert.Equal(t, mlclient.WordTimestamp{Word: "бв", Start: 12, End: 14}, words[1])
+	assert.Equal(t, mlclient.WordTimestamp{Word: "где", Start: 15, End: 18}, words[2])
+	assert.Nil(t, estimateWordTimings("   ", 0, 1))
+}
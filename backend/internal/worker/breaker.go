@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// Состояния circuitBreaker.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// circuitBreaker отключает провайдера после threshold ошибок подряд.
+// Пока он открыт, задачи не берутся в работу и остаются в очереди;
+// через cooldown пропускается одна пробная проверка (half-open).
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// Allow сообщает, можно ли обращаться к провайдеру.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked() != breakerOpen
+}
+
+// Success сбрасывает счётчик ошибок и закрывает breaker.
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+// Failure учитывает ошибку. Возвращает true, если breaker только что открылся.
+func (b *circuitBreaker) Failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasOpen := !b.openUntil.IsZero()
+	b.failures++
+	if b.failures >= b.threshold {
+		// Неудачная пробная проверка снова открывает breaker на cooldown
+		b.openUntil = b.now().Add(b.cooldown)
+		return !wasOpen
+	}
+	return false
+}
+
+// State возвращает состояние и число ошибок подряд.
+func (b *circuitBreaker) State() (string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked(), b.failures
+}
+
+func (b *circuitBreaker) stateLocked() string {
+	switch {
+	case b.openUntil.IsZero():
+		return breakerClosed
+	case b.now().Before(b.openUntil):
+		return breakerOpen
+	default:
+		return breakerHalfOpen
+	}
+}
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := newCircuitBreaker(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	assert.True(t, b.Allow())
+	assert.False(t, b.Failure())
+	assert.False(t, b.Failure())
+	state, failures := b.State()
+	assert.Equal(t, breakerClosed, state)
+	assert.Equal(t, 2, failures)
+
+	// Третья ошибка подряд открывает breaker
+	assert.True(t, b.Failure())
+	assert.False(t, b.Allow())
+
+	// После cooldown пропускается пробная проверка
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	state, _ = b.State()
+	assert.Equal(t, breakerHalfOpen, state)
+
+	// Неудачная проба снова открывает breaker, но это не новое открытие
+	assert.False(t, b.Failure())
+	assert.False(t, b.Allow())
+
+	now = now.Add(time.Minute)
+	b.Success()
+	state, failures = b.State()
+	assert.Equal(t, breakerClosed, state)
+	assert.Equal(t, 0, failures)
+	assert.True(t, b.Allow())
+}
+
+func TestCircuitBreaker_SuccessResetsCount(t *testing.T) {
+	b := newCircuitBreaker(2, time.Minute)
+	b.Failure()
+	b.Success()
+	assert.False(t, b.Failure())
+	assert.True(t, b.Allow())
+}
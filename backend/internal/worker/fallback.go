@@ -0,0 +1,118 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// ProviderAttempt — неудачная попытка распознавания провайдером из цепочки.
+type ProviderAttempt struct {
+	Provider string `json:"provider"`
+	Error    string `json:"error"`
+}
+
+// providerFailure — постоянная ошибка провайдера: повтор у того же провайдера
+// не поможет, но следующий в цепочке может справиться. Временные ошибки
+// (сеть, перегрузка) возвращают задачу в очередь через requeueTask.
+type providerFailure struct {
+	provider string
+	message  string
+}
+
+func (e *providerFailure) Error() string {
+	return e.provider + ": " + e.message
+}
+
+// transcribe распознаёт задачу провайдерами цепочки по порядку.
+// Провайдер, чей сервис сейчас недоступен, пропускается (кроме последнего).
+// Неудачные попытки сохраняются в provider_attempts; если не справился
+// никто, задача завершается ошибкой последнего провайдера.
+func (w *Worker) transcribe(ctx context.Context, task TaskRow, startTime time.Time) error {
+	var attempts []ProviderAttempt
+	var lastMessage string
+	for i, provider := range w.chain {
+		last := i == len(w.chain)-1
+		if !last && !w.providerAvailable(provider) {
+			log.Printf("task %s: %s is unavailable, skipping", task.ID, provider)
+			attempts = append(attempts, ProviderAttempt{Provider: provider, Error: "Провайдер недоступен"})
+			w.saveAttempts(task.ID, attempts)
+			continue
+		}
+
+		err := w.runProvider(ctx, provider, task, startTime)
+		var failure *providerFailure
+		if !errors.As(err, &failure) {
+			return err
+		}
+
+		lastMessage = failure.message
+		attempts = append(attempts, ProviderAttempt{Provider: provider, Error: failure.message})
+		w.saveAttempts(task.ID, attempts)
+		if !last {
+			log.Printf("task %s: %s failed, falling back to %s: %s", task.ID, provider, w.chain[i+1], failure.message)
+		}
+	}
+	return w.failTask(task.ID, lastMessage)
+}
+
+func (w *Worker) runProvider(ctx context.Context, provider string, task TaskRow, startTime time.Time) error {
+	switch provider {
+	case "whisper":
+		return w.processTaskWhisper(ctx, task, startTime)
+	case "speechkit":
+		return w.processTaskSpeechKit(ctx, task, startTime)
+	case "whispercpp":
+		return w.processTaskWhisperCpp(ctx, task, startTime)
+	case "openai":
+		return w.processTaskOpenAI(ctx, task, startTime)
+	case "mock":
+		return w.processTaskMock(ctx, task, startTime)
+	}
+	return &providerFailure{provider: provider, message: "Неизвестный провайдер " + provider}
+}
+
+// providerAvailable сообщает, стоит ли сейчас отправлять задачу провайдеру:
+// его сервис не отключён breaker'ом и последняя проверка прошла успешно.
+func (w *Worker) providerAvailable(provider string) bool {
+	var service string
+	switch provider {
+	case "whisper":
+		service = serviceML
+	case "speechkit":
+		service = serviceSpeechKit
+	case "whispercpp":
+		service = serviceWhisperCpp
+	case "openai":
+		service = serviceOpenAI
+	}
+	p := w.monitor(service)
+	if p == nil {
+		// У провайдера нет внешнего сервиса (mock) или он не настроен
+		return true
+	}
+	return p.breaker.Allow() && (p.lastCheck.IsZero() || p.healthy)
+}
+
+// saveAttempts сохраняет неудачные попытки. ID задачи ML-сервиса сбрасывается:
+// к ней уже не нужно переподключаться.
+func (w *Worker) saveAttempts(taskID string, attempts []ProviderAttempt) {
+	data, _ := json.Marshal(attempts)
+	if _, err := w.db.Exec(
+		`UPDATE transcription_tasks SET provider_attempts = ?, ml_job_id = NULL WHERE id = ?`,
+		string(data), taskID,
+	); err != nil {
+		log.Printf("task %s: failed to save provider attempts: %v", taskID, err)
+	}
+}
+
+func containsProvider(chain []string, provider string) bool {
+	for _, name := range chain {
+		if name == provider {
+			return true
+		}
+	}
+	return false
+}
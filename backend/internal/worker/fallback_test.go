@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFailingMLServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"detail":"Не удалось декодировать аудио"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProcessTaskWhisper_PermanentErrorIsProviderFailure(t *testing.T) {
+	srv := newFailingMLServer(t)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	err := w.processTaskWhisper(context.Background(), task, time.Now())
+
+	var failure *providerFailure
+	require.True(t, errors.As(err, &failure))
+	assert.Equal(t, "whisper", failure.provider)
+	assert.Contains(t, failure.message, "Не удалось декодировать аудио")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTranscribe_FallsBackToNextProvider(t *testing.T) {
+	var submits int32
+	srv := newMLJobServer(t, &submits)
+	w, mock := newTestWorker(t, Config{Provider: "speechkit", Fallback: []string{"whisper"}, MLServiceURL: srv.URL})
+	// Без ffmpeg SpeechKit в тестах не запустить: не настроенный клиент — постоянная ошибка
+	w.speechKit = nil
+
+	mock.ExpectExec("SET provider_attempts = \\?, ml_job_id = NULL").
+		WithArgs(`[{"provider":"speechkit","error":"SpeechKit не настроен"}]`, "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = \\?").
+		WithArgs("job-1", "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWhisperResult(mock)
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.transcribe(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTranscribe_AllProvidersFail(t *testing.T) {
+	srv := newFailingMLServer(t)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", Fallback: []string{"speechkit"}, MLServiceURL: srv.URL})
+	w.speechKit = nil
+
+	mock.ExpectExec("SET provider_attempts = \\?").
+		WithArgs(sqlmock.AnyArg(), "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET provider_attempts = \\?").
+		WithArgs(sqlmock.AnyArg(), "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'ошибка'").
+		WithArgs("SpeechKit не настроен", sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.transcribe(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTranscribe_SkipsUnavailableProvider(t *testing.T) {
+	var submits int32
+	srv := newMLJobServer(t, &submits)
+	w, mock := newTestWorker(t, Config{Provider: "speechkit", Fallback: []string{"whisper"}, MLServiceURL: srv.URL})
+	// Без ffmpeg SpeechKit в тестах не запустить: не настроенный клиент — постоянная ошибка
+	w.speechKit = nil
+
+	// Breaker SpeechKit открыт — к сервису не обращаемся
+	sk := w.monitor(serviceSpeechKit)
+	require.NotNil(t, sk)
+	for i := 0; i < 3; i++ {
+		sk.breaker.Failure()
+	}
+
+	mock.ExpectExec("SET provider_attempts = \\?").
+		WithArgs(`[{"provider":"speechkit","error":"Провайдер недоступен"}]`, "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = \\?").
+		WithArgs("job-1", "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWhisperResult(mock)
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.transcribe(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int32(1), submits)
+}
@@ -0,0 +1,36 @@
+package worker
+
+import (
+	"loopa/backend/internal/glossary"
+	"loopa/backend/internal/mlclient"
+)
+
+// applyGlossary исправляет результат заменами словаря проекта: полный текст,
+// тексты сегментов с паразитами и без них, отдельные слова. Замена фразы
+// из нескольких слов меняет тексты, но не слова. Сохранённые ответы провайдера
+// не меняются: rebuild-segments применяет словарь заново.
+func applyGlossary(replacer *glossary.Replacer, result *transcriptResult) {
+	if replacer.Empty() {
+		return
+	}
+	result.Text = replacer.Replace(result.Text)
+	replaceSegmentTexts(replacer, result.Segments)
+}
+
+// replaceSegmentTexts применяет замены словаря к сегментам и их словам.
+func replaceSegmentTexts(replacer *glossary.Replacer, segments []mlclient.TranscribeSegment) {
+	if replacer.Empty() {
+		return
+	}
+	for i := range segments {
+		seg := &segments[i]
+		seg.Text = replacer.Replace(seg.Text)
+		if seg.CleanedText != nil {
+			cleaned := replacer.Replace(*seg.CleanedText)
+			seg.CleanedText = &cleaned
+		}
+		for j := range seg.Words {
+			seg.Words[j].Word = replacer.Replace(seg.Words[j].Word)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Имена провайдеров в таблице provider_health.
+const (
+	serviceML         = "ml_service"
+	serviceSpeechKit  = "yandex_speechkit"
+	serviceWhisperCpp = "whisper_cpp"
+	serviceOpenAI     = "openai_stt"
+)
+
+// Статусы провайдера в provider_health.
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+// healthCheckTimeout ограничивает одну проверку доступности провайдера.
+const healthCheckTimeout = 5 * time.Second
+
+// providerMonitor следит за доступностью внешнего сервиса.
+// required — сервис нужен провайдеру из цепочки распознавания. Если недоступны
+// все такие сервисы, worker не берёт задачи в работу — они остаются в очереди
+// до восстановления.
+type providerMonitor struct {
+	name     string
+	check    func(ctx context.Context) error
+	breaker  *circuitBreaker
+	required bool
+
+	// Результат последней проверки; повторно проверяем не чаще healthInterval.
+	lastCheck time.Time
+	healthy   bool
+}
+
+// providersReady проверяет провайдеров и сообщает, можно ли брать задачи.
+func (w *Worker) providersReady(ctx context.Context) bool {
+	ready, required := false, false
+	for _, p := range w.providers {
+		available := w.checkProvider(ctx, p)
+		if p.required {
+			required = true
+			ready = ready || available
+		}
+	}
+	// Без внешних сервисов проверять нечего: ошибку конфигурации покажет сама задача
+	return ready || !required
+}
+
+func (w *Worker) checkProvider(ctx context.Context, p *providerMonitor) bool {
+	if !p.breaker.Allow() {
+		return false
+	}
+	state, _ := p.breaker.State()
+	if state == breakerClosed && p.healthy && time.Since(p.lastCheck) < w.healthInterval {
+		return true
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	err := p.check(checkCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return false
+	}
+	p.lastCheck = time.Now()
+	w.recordProviderResult(p, err)
+	return err == nil
+}
+
+// recordProviderResult учитывает результат обращения к провайдеру в breaker'е
+// и сохраняет состояние для /api/health.
+func (w *Worker) recordProviderResult(p *providerMonitor, err error) {
+	if p == nil {
+		return
+	}
+	if err == nil {
+		if state, failures := p.breaker.State(); failures > 0 {
+			log.Printf("provider %s: available again (was %s)", p.name, state)
+		}
+		p.healthy = true
+		p.breaker.Success()
+	} else {
+		p.healthy = false
+		if p.breaker.Failure() {
+			log.Printf("provider %s: circuit opened, tasks stay queued: %v", p.name, err)
+		} else {
+			log.Printf("provider %s: request failed: %v", p.name, err)
+		}
+	}
+	w.saveProviderHealth(p, err)
+}
+
+func (w *Worker) saveProviderHealth(p *providerMonitor, checkErr error) {
+	state, failures := p.breaker.State()
+	status := healthOK
+	switch {
+	case state != breakerClosed:
+		status = healthDown
+	case failures > 0:
+		status = healthDegraded
+	}
+
+	now := time.Now().UTC()
+	var lastError, lastSuccess interface{}
+	if checkErr != nil {
+		lastError = checkErr.Error()
+	} else {
+		lastSuccess = now
+	}
+
+	if _, err := w.db.Exec(
+		`INSERT INTO provider_health (provider, status, consecutive_failures, last_error, checked_at, last_success_at)
+		 VALUES (?, ?, ?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE status = VALUES(status), consecutive_failures = VALUES(consecutive_failures),
+		     last_error = VALUES(last_error), checked_at = VALUES(checked_at),
+		     last_success_at = COALESCE(VALUES(last_success_at), last_success_at)`,
+		p.name, status, failures, lastError, now, lastSuccess,
+	); err != nil {
+		log.Printf("provider %s: failed to save health: %v", p.name, err)
+	}
+}
+
+// monitor возвращает монитор провайдера по имени или nil.
+func (w *Worker) monitor(name string) *providerMonitor {
+	for _, p := range w.providers {
+		if p.name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// requeueTask возвращает задачу в очередь после временной ошибки провайдера,
+// вместо того чтобы помечать её ошибкой. Сохранённые ID операции SpeechKit
+// и задачи ML-сервиса остаются, чтобы продолжить с того же места.
+func (w *Worker) requeueTask(taskID, provider string, cause error) error {
+	log.Printf("task %s: %s unavailable, returning to queue: %v", taskID, provider, cause)
+	w.recordProviderResult(w.monitor(provider), cause)
+	_, err := w.db.Exec(
+		`UPDATE transcription_tasks SET status = 'ожидает', started_at = NULL
+		 WHERE id = ? AND status = 'в процессе'`,
+		taskID,
+	)
+	return err
+}
+
+// recordProviderSuccess сбрасывает счётчик ошибок провайдера после успешной задачи.
+// Состояние в provider_health обновится при следующей проверке.
+func (w *Worker) recordProviderSuccess(provider string) {
+	if p := w.monitor(provider); p != nil {
+		p.breaker.Success()
+	}
+}
@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessBatch_LeavesQueueWhenMLIsDown(t *testing.T) {
+	var checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checks, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	w, mock := newTestWorker(t, Config{
+		Provider:                 "whisper",
+		MLServiceURL:             srv.URL,
+		ProviderFailureThreshold: 2,
+		ProviderCooldown:         time.Hour,
+	})
+
+	for i, status := range []string{healthDegraded, healthDown, healthDown} {
+		mock.ExpectQuery("WHERE t.status = 'извлечение аудио'").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "id", "storage_path"}))
+		if i < 2 {
+			// Третий проход не проверяет сервис: breaker открыт
+			mock.ExpectExec("INSERT INTO provider_health").
+				WithArgs(serviceML, status, i+1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+	}
+
+	// Задачи не запрашиваются — иначе sqlmock вернул бы ошибку на лишний запрос
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.processBatch(context.Background()))
+	}
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int32(2), checks)
+}
+
+func TestProcessBatch_CachesHealthyProvider(t *testing.T) {
+	var checks int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checks, 1)
+	}))
+	t.Cleanup(srv.Close)
+
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	mock.ExpectQuery("WHERE t.status = 'извлечение аудио'").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id", "storage_path"}))
+	mock.ExpectExec("INSERT INTO provider_health").
+		WithArgs(serviceML, healthOK, 0, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			mock.ExpectQuery("WHERE t.status = 'извлечение аудио'").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "id", "storage_path"}))
+		}
+		mock.ExpectQuery("WHERE t.status = 'ожидает'").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "storage_path", "duration_ms", "preprocessing",
+				"speechkit_operation_id", "speechkit_api", "speechkit_object_key", "ml_job_id", "project_id", "glossary"}))
+	}
+
+	require.NoError(t, w.processBatch(context.Background()))
+	require.NoError(t, w.processBatch(context.Background()))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int32(1), checks)
+}
+
+func TestProcessTaskWhisper_RequeuesOnTransientError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	mock.ExpectExec("INSERT INTO provider_health").
+		WithArgs(serviceML, healthDegraded, 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'ожидает', started_at = NULL").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.processTaskWhisper(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/mockstt"
+)
+
+// processTaskMock — детерминированный провайдер для разработки и e2e-тестов:
+// результат строится по содержимому файла или fixture (см. mockstt.Load),
+// без ffmpeg, ML-сервиса и сети.
+func (w *Worker) processTaskMock(ctx context.Context, task TaskRow, startTime time.Time) error {
+	if w.mockDelay > 0 {
+		select {
+		case <-ctx.Done():
+			log.Printf("task %s: interrupted by shutdown", task.ID)
+			return nil
+		case <-time.After(w.mockDelay):
+		}
+	}
+
+	fixture, err := mockstt.Load(task.StoragePath, w.mockFixturesDir, task.Duration)
+	if err != nil {
+		return &providerFailure{provider: "mock", message: "Ошибка mock-распознавания: " + err.Error()}
+	}
+
+	failure := fixture.Fail
+	if w.mockFail != "" {
+		failure = &mockstt.Failure{Kind: w.mockFail}
+	}
+	if failure != nil && w.mockShouldFail(task.ID, failure) {
+		message := failure.Message
+		if message == "" {
+			message = "injected " + failure.Kind + " failure"
+		}
+		if failure.Kind == mockstt.FailTransient {
+			return w.requeueTask(task.ID, "mock", errors.New(message))
+		}
+		return &providerFailure{provider: "mock", message: "Ошибка mock-распознавания: " + message}
+	}
+
+	resp := mlclient.TranscribeFullResponse{
+		Language:    fixture.Language,
+		FullText:    fixture.Text(),
+		NumSpeakers: fixture.NumSpeakers(),
+	}
+	for _, seg := range fixture.Segments {
+		cleaned := seg.CleanedText
+		segment := mlclient.TranscribeSegment{
+			Speaker:      seg.Speaker,
+			Start:        seg.Start,
+			End:          seg.End,
+			Text:         seg.Text,
+			CleanedText:  &cleaned,
+			HasFillers:   len(seg.Fillers) > 0,
+			FillersFound: seg.Fillers,
+		}
+		for _, word := range seg.Words {
+			segment.Words = append(segment.Words, mlclient.WordTimestamp{Word: word.Word, Start: word.Start, End: word.End})
+		}
+		resp.Segments = append(resp.Segments, segment)
+	}
+	resp.ProcessingTimeSeconds = time.Since(startTime).Seconds()
+
+	response := newResponse(responseTranscription, 0, 0, 0, resp)
+	return w.finishTask(ctx, task, startTime, transcriptResult{
+		Provider:    "mock",
+		Text:        resp.FullText,
+		SpeakerData: response.Body,
+		Segments:    resp.Segments,
+		Responses:   []providerResponse{response},
+	})
+}
+
+// mockShouldFail учитывает попытку задачи: при Times > 0 ошибкой
+// завершаются только первые Times попыток.
+func (w *Worker) mockShouldFail(taskID string, failure *mockstt.Failure) bool {
+	if failure.Times <= 0 {
+		return true
+	}
+	w.mockMu.Lock()
+	defer w.mockMu.Unlock()
+	if w.mockAttempts == nil {
+		w.mockAttempts = map[string]int{}
+	}
+	w.mockAttempts[taskID]++
+	return w.mockAttempts[taskID] <= failure.Times
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMockFixture(t *testing.T, audioPath, fixture string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(audioPath+".mock.json", []byte(fixture), 0o644))
+}
+
+const twoSpeakerFixture = `{
+	"language": "ru",
+	"segments": [
+		{"speaker": "SPEAKER_00", "start": 0, "end": 1.5, "text": "ну добрый день", "fillers": ["ну"]},
+		{"speaker": "SPEAKER_01", "start": 2, "end": 3, "text": "здравствуйте"}
+	]
+}`
+
+func TestProcessTaskMock(t *testing.T) {
+	w, mock := newTestWorker(t, Config{Provider: "mock"})
+	audio := writeTempAudio(t, "a.ogg")
+	writeMockFixture(t, audio, twoSpeakerFixture)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_tasks SET speaker_data").
+		WithArgs(sqlmock.AnyArg(), "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments .* VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\), \\(").
+		WithArgs(
+			sqlmock.AnyArg(), "task-1", "SPEAKER_00", 0, 1500, "ну добрый день", "добрый день", true, sqlmock.AnyArg(),
+			sqlmock.AnyArg(), "task-1", "SPEAKER_01", 2000, 3000, "здравствуйте", "здравствуйте", false, sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// Слова без явных таймкодов распределены по сегменту
+	mock.ExpectExec("INSERT INTO transcription_words").
+		WithArgs(
+			sqlmock.AnyArg(), 0, "task-1", "ну", 0, 500,
+			sqlmock.AnyArg(), 1, "task-1", "добрый", 500, 1000,
+			sqlmock.AnyArg(), 2, "task-1", "день", 1000, 1500,
+			sqlmock.AnyArg(), 0, "task-1", "здравствуйте", 2000, 3000,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("INSERT INTO segment_fillers").
+		WithArgs(sqlmock.AnyArg(), "ну", "task-1", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO provider_responses").
+		WithArgs(sqlmock.AnyArg(), "task-1", "mock", "transcription", 0, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово', transcript_text = \\?, provider = \\?").
+		WithArgs("ну добрый день здравствуйте", "mock", sqlmock.AnyArg(), sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	task := TaskRow{ID: "task-1", StoragePath: audio}
+	require.NoError(t, w.processTaskMock(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTaskMock_TransientFailureThenSuccess(t *testing.T) {
+	w, mock := newTestWorker(t, Config{Provider: "mock"})
+	audio := writeTempAudio(t, "a.ogg")
+	writeMockFixture(t, audio, `{
+		"segments": [{"speaker": "SPEAKER_00", "start": 0, "end": 1, "text": "алло"}],
+		"fail": {"kind": "transient", "message": "GPU busy", "times": 1}
+	}`)
+	task := TaskRow{ID: "task-1", StoragePath: audio}
+
+	mock.ExpectExec("SET status = 'ожидает', started_at = NULL").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, w.processTaskMock(context.Background(), task, time.Now()))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_tasks SET speaker_data").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transcription_words").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO provider_responses").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово'").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, w.processTaskMock(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTaskMock_InjectedPermanentFailure(t *testing.T) {
+	w, _ := newTestWorker(t, Config{Provider: "mock", MockFail: "permanent"})
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	err := w.processTaskMock(context.Background(), task, time.Now())
+	var failure *providerFailure
+	require.True(t, errors.As(err, &failure))
+	assert.Contains(t, failure.message, "injected permanent failure")
+}
+
+func TestProcessTaskMock_ShutdownDuringDelay(t *testing.T) {
+	w, mock := newTestWorker(t, Config{Provider: "mock", MockDelay: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.processTaskMock(ctx, task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
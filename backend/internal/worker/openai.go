@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"loopa/backend/internal/media"
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/openaistt"
+)
+
+// processTaskOpenAI — pipeline через OpenAI-совместимый API распознавания.
+// Файл отправляется целиком в OGG Opus; диаризация и поиск слов-паразитов —
+// через ML-сервис, если он настроен.
+func (w *Worker) processTaskOpenAI(ctx context.Context, task TaskRow, startTime time.Time) error {
+	if w.openAI == nil {
+		return &providerFailure{provider: "openai", message: "OpenAI-совместимый API не настроен"}
+	}
+
+	oggPath, err := media.Preprocess(task.StoragePath, w.uploadDir, task.Preprocessing)
+	if err != nil {
+		return &providerFailure{provider: "openai", message: "Ошибка конвертации аудио: " + err.Error()}
+	}
+	defer os.Remove(oggPath)
+
+	log.Printf("task %s: starting OpenAI-compatible transcription", task.ID)
+
+	result, err := w.openAI.Transcribe(ctx, oggPath, task.Glossary.Prompt())
+	if err != nil && ctx.Err() != nil {
+		log.Printf("task %s: interrupted by shutdown", task.ID)
+		return nil
+	}
+	if err != nil && openaistt.IsTransient(err) {
+		return w.requeueTask(task.ID, serviceOpenAI, err)
+	}
+	if err != nil {
+		return &providerFailure{provider: "openai", message: "Ошибка распознавания: " + err.Error()}
+	}
+	w.recordProviderSuccess(serviceOpenAI)
+
+	log.Printf("task %s: OpenAI-compatible transcription done — %d segments, lang=%s",
+		task.ID, len(result.Segments), result.Language)
+
+	segments, diarization := w.buildTimedSegments(ctx, task.ID, oggPath, openAIPieces(result))
+	return w.finishTask(ctx, task, startTime, timedResult(
+		"openai", result.Text, segments, diarization,
+		[]providerResponse{newResponse(responseTranscription, 0, 0, 0, result)},
+	))
+}
+
+// openAIPieces переводит фразы ответа в части с таймкодами слов.
+// Сервер может не вернуть слова (не поддерживает word timestamps) —
+// тогда они оцениваются по длине.
+func openAIPieces(result *openaistt.Result) []timedText {
+	pieces := make([]timedText, 0, len(result.Segments))
+	for _, seg := range result.Segments {
+		piece := timedText{Start: seg.Start, End: seg.End, Text: seg.Text}
+		for _, word := range seg.Words {
+			piece.Words = append(piece.Words, mlclient.WordTimestamp{Word: word.Word, Start: word.Start, End: word.End})
+		}
+		if len(piece.Words) == 0 {
+			piece.Words = estimateWordTimings(seg.Text, seg.Start, seg.End)
+		}
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
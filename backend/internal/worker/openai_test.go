@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/openaistt"
+)
+
+func TestOpenAIPieces(t *testing.T) {
+	pieces := openAIPieces(&openaistt.Result{Segments: []openaistt.Segment{
+		{Start: 0, End: 1.5, Text: "добрый день", Words: []openaistt.Word{
+			{Word: "добрый", Start: 0, End: 0.6},
+			{Word: "день", Start: 0.7, End: 1.5},
+		}},
+		{Start: 2, End: 3, Text: "как дела"},
+	}})
+
+	require.Len(t, pieces, 2)
+	assert.Equal(t, "добрый", pieces[0].Words[0].Word)
+	assert.Equal(t, 1.5, pieces[0].Words[1].End)
+	assert.Len(t, pieces[1].Words, 2)
+}
+
+func TestProcessTaskOpenAI_NotConfigured(t *testing.T) {
+	w, _ := newTestWorker(t, Config{Provider: "whisper"})
+
+	err := w.processTaskOpenAI(context.Background(), TaskRow{ID: "task-1"}, time.Now())
+	var failure *providerFailure
+	require.True(t, errors.As(err, &failure))
+	assert.Equal(t, "openai", failure.provider)
+}
@@ -0,0 +1,224 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"loopa/backend/internal/glossary"
+	"loopa/backend/internal/mlclient"
+)
+
+// persistBatchSize — строк в одном INSERT: ограничивает размер запроса
+// и число плейсхолдеров (в MySQL не больше 65535 на запрос).
+const persistBatchSize = 500
+
+// transcriptResult — итог распознавания задачи.
+type transcriptResult struct {
+	Provider    string // значение transcription_tasks.provider
+	Text        string
+	SpeakerData []byte // JSON для speaker_data; nil — не менять
+	Segments    []mlclient.TranscribeSegment
+	Responses   []providerResponse // ответы провайдера для перестроения сегментов
+	Speakers    []taskSpeaker      // эмбеддинги голосов спикеров; nil — не считались
+}
+
+// saveResult сохраняет сегменты, слова, speaker_data и статус «готово»
+// одной транзакцией: задача не может оказаться готовой с частью транскрипта.
+// Сегменты и ответы провайдеров прошлых запусков (повторная обработка, восстановление после сбоя)
+// заменяются, а не дублируются. Если задачу за это время удалили или сбросили,
+// результат отбрасывается.
+func (w *Worker) saveResult(taskID string, startTime time.Time, result transcriptResult) error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if result.SpeakerData != nil {
+		if _, err := tx.Exec(
+			`UPDATE transcription_tasks SET speaker_data = ? WHERE id = ?`,
+			string(result.SpeakerData), taskID,
+		); err != nil {
+			return fmt.Errorf("save speaker data: %w", err)
+		}
+	}
+
+	if err := deletePreviousResult(tx, taskID); err != nil {
+		return err
+	}
+	if err := insertSegments(tx, taskID, result.Segments); err != nil {
+		return err
+	}
+	if err := insertResponses(tx, taskID, result.Provider, result.Responses); err != nil {
+		return err
+	}
+	if err := insertTaskSpeakers(tx, taskID, result.Speakers); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(
+		`UPDATE transcription_tasks
+		 SET status = 'готово', transcript_text = ?, provider = ?,
+		     processing_time = ?, completed_at = ?, ml_job_id = NULL, progress = 100
+		 WHERE id = ? AND status = 'в процессе'`,
+		result.Text, result.Provider, int(time.Since(startTime).Seconds()), time.Now().UTC(), taskID,
+	)
+	if err != nil {
+		return fmt.Errorf("update task: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		log.Printf("task %s: no longer in progress, result discarded", taskID)
+		return nil
+	}
+	return tx.Commit()
+}
+
+// finishTask исправляет текст словарём проекта, узнаёт спикеров по голосовым
+// профилям проекта и сохраняет результат; если сохранить не удалось, задача
+// завершается ошибкой, а не остаётся «готовой» без части транскрипта.
+func (w *Worker) finishTask(ctx context.Context, task TaskRow, startTime time.Time, result transcriptResult) error {
+	applyGlossary(glossary.NewReplacer(task.Glossary.Replacements), &result)
+	// mock работает без ML-сервиса
+	if result.Provider != "mock" {
+		result.Speakers = w.identifySpeakers(ctx, task, result.Segments)
+	}
+	if err := w.saveResult(task.ID, startTime, result); err != nil {
+		return w.failTask(task.ID, "Ошибка сохранения результата: "+err.Error())
+	}
+	return nil
+}
+
+// deletePreviousResult удаляет сегменты, слова, ответы провайдеров, спикеров
+// и объединения спикеров задачи, сохранённые раньше.
+func deletePreviousResult(tx *sql.Tx, taskID string) error {
+	if err := deleteSegments(tx, taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM provider_responses WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("delete previous responses: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM task_speakers WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("delete previous speakers: %w", err)
+	}
+	// ID спикеров нового распознавания не связаны с прежними
+	if _, err := tx.Exec(`DELETE FROM speaker_merges WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("delete previous speaker merges: %w", err)
+	}
+	return nil
+}
+
+// deleteSegments удаляет сегменты и слова задачи.
+func deleteSegments(tx *sql.Tx, taskID string) error {
+	if _, err := tx.Exec(`DELETE FROM transcription_words WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("delete previous words: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM transcription_segments WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("delete previous segments: %w", err)
+	}
+	return nil
+}
+
+// insertSegments вставляет сегменты, их слова и слова-паразиты пачками многострочных INSERT.
+func insertSegments(tx *sql.Tx, taskID string, segments []mlclient.TranscribeSegment) error {
+	now := time.Now().UTC()
+	segmentRows := make([][]interface{}, 0, len(segments))
+	var wordRows, fillerRows [][]interface{}
+	for _, seg := range segments {
+		// Пустой Speaker и неизвестный CleanedText сохраняются как NULL
+		var speaker, cleaned interface{}
+		if seg.Speaker != "" {
+			speaker = seg.Speaker
+		}
+		if seg.CleanedText != nil {
+			cleaned = *seg.CleanedText
+		}
+		segmentID := uuid.New().String()
+		segmentRows = append(segmentRows, []interface{}{
+			segmentID, taskID, speaker, int(seg.Start * 1000), int(seg.End * 1000), seg.Text, cleaned, seg.HasFillers, now,
+		})
+		for _, filler := range countFillers(seg.FillersFound) {
+			fillerRows = append(fillerRows, []interface{}{segmentID, filler.word, taskID, filler.count})
+		}
+		for i, word := range seg.Words {
+			wordRows = append(wordRows, []interface{}{
+				segmentID, i, taskID, word.Word, int(word.Start * 1000), int(word.End * 1000),
+			})
+		}
+	}
+
+	if err := insertBatches(tx,
+		`INSERT INTO transcription_segments
+		 (id, task_id, speaker_id, start_time, end_time, text, cleaned_text, has_fillers, created_at) VALUES `,
+		segmentRows,
+	); err != nil {
+		return fmt.Errorf("save segments: %w", err)
+	}
+	if err := insertBatches(tx,
+		`INSERT INTO transcription_words
+		 (segment_id, word_index, task_id, word, start_time, end_time) VALUES `,
+		wordRows,
+	); err != nil {
+		return fmt.Errorf("save words: %w", err)
+	}
+	if err := insertBatches(tx,
+		`INSERT INTO segment_fillers (segment_id, filler, task_id, occurrences) VALUES `,
+		fillerRows,
+	); err != nil {
+		return fmt.Errorf("save fillers: %w", err)
+	}
+	return nil
+}
+
+type fillerCount struct {
+	word  string
+	count int
+}
+
+// countFillers сводит найденные паразиты сегмента в пары «паразит — число повторов»
+// в порядке первого появления.
+func countFillers(found []string) []fillerCount {
+	var counts []fillerCount
+	index := map[string]int{}
+	for _, filler := range found {
+		filler = strings.ToLower(strings.TrimSpace(filler))
+		if filler == "" {
+			continue
+		}
+		if i, ok := index[filler]; ok {
+			counts[i].count++
+			continue
+		}
+		index[filler] = len(counts)
+		counts = append(counts, fillerCount{word: filler, count: 1})
+	}
+	return counts
+}
+
+// insertBatches выполняет prefix + "(?, ...), (?, ...)" по persistBatchSize строк.
+func insertBatches(tx *sql.Tx, prefix string, rows [][]interface{}) error {
+	for start := 0; start < len(rows); start += persistBatchSize {
+		batch := rows[start:min(start+persistBatchSize, len(rows))]
+		placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(batch[0])), ", ") + ")"
+
+		var query strings.Builder
+		query.WriteString(prefix)
+		args := make([]interface{}, 0, len(batch)*len(batch[0]))
+		for i, row := range batch {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(placeholder)
+			args = append(args, row...)
+		}
+		if _, err := tx.Exec(query.String(), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/glossary"
+	"loopa/backend/internal/mlclient"
+)
+
+// expectReplaceSegments ожидает удаление сегментов, ответов, спикеров и их объединений
+// прошлого запуска задачи.
+func expectReplaceSegments(mock sqlmock.Sqlmock, taskID string) {
+	mock.ExpectExec("DELETE FROM transcription_words WHERE task_id = \\?").
+		WithArgs(taskID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM transcription_segments WHERE task_id = \\?").
+		WithArgs(taskID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM provider_responses WHERE task_id = \\?").
+		WithArgs(taskID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM task_speakers WHERE task_id = \\?").
+		WithArgs(taskID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM speaker_merges WHERE task_id = \\?").
+		WithArgs(taskID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestSaveResult_BatchesInserts(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	segments := make([]mlclient.TranscribeSegment, persistBatchSize+1)
+	for i := range segments {
+		segments[i] = mlclient.TranscribeSegment{Start: float64(i), End: float64(i) + 0.5, Text: fmt.Sprint(i)}
+	}
+	segments[0].Words = []mlclient.WordTimestamp{{Word: "0", Start: 0, End: 0.5}}
+
+	mock.ExpectBegin()
+	// Без SpeakerData speaker_data не меняется
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments").WillReturnResult(sqlmock.NewResult(0, persistBatchSize))
+	mock.ExpectExec("INSERT INTO transcription_segments .* VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)$").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transcription_words").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово'").
+		WithArgs("text", "mock", sqlmock.AnyArg(), sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, w.saveResult("task-1", time.Now(), transcriptResult{Provider: "mock", Text: "text", Segments: segments}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFinishTask_FailsTaskWhenInsertFails(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_tasks SET speaker_data").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments").WillReturnError(errors.New("Data too long for column 'text'"))
+	mock.ExpectRollback()
+	mock.ExpectExec("SET status = 'ошибка'").
+		WithArgs("Ошибка сохранения результата: save segments: Data too long for column 'text'", sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, w.finishTask(context.Background(), TaskRow{ID: "task-1"}, time.Now(), transcriptResult{
+		Provider:    "mock",
+		SpeakerData: []byte(`{}`),
+		Segments:    []mlclient.TranscribeSegment{{Text: "a"}},
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveResult_DiscardsDeletedTask(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("SET status = 'готово'").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	require.NoError(t, w.saveResult("task-1", time.Now(), transcriptResult{Provider: "mock"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveResult_ReplacesPreviousRun(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM transcription_words WHERE task_id = \\?").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 12))
+	mock.ExpectExec("DELETE FROM transcription_segments WHERE task_id = \\?").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM provider_responses WHERE task_id = \\?").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM task_speakers WHERE task_id = \\?").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM speaker_merges WHERE task_id = \\?").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO transcription_segments").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO provider_responses").
+		WithArgs(sqlmock.AnyArg(), "task-1", "mock", "transcription", 0, 0, 0, `{}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово'").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, w.saveResult("task-1", time.Now(), transcriptResult{
+		Provider:  "mock",
+		Segments:  []mlclient.TranscribeSegment{{Text: "a"}},
+		Responses: []providerResponse{{Kind: responseTranscription, Body: []byte(`{}`)}},
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFinishTask_DeleteFailureKeepsPreviousSegments(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM transcription_words").WillReturnError(errors.New("lock wait timeout"))
+	mock.ExpectRollback()
+	mock.ExpectExec("SET status = 'ошибка'").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, w.finishTask(context.Background(), TaskRow{ID: "task-1"}, time.Now(), transcriptResult{Provider: "mock"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFinishTask_AppliesGlossary(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments").
+		WithArgs(sqlmock.AnyArg(), "task-1", nil, 0, 1000, "запускаем Loopa", "запускаем Loopa", true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transcription_words").
+		WithArgs(sqlmock.AnyArg(), 0, "task-1", "ну", 0, 200, sqlmock.AnyArg(), 1, "task-1", "Loopa,", 500, 1000).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO segment_fillers").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово'").
+		WithArgs("Ну, запускаем Loopa", "mock", sqlmock.AnyArg(), sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	cleaned := "запускаем лупу"
+	task := TaskRow{ID: "task-1", Glossary: glossary.Glossary{Replacements: []glossary.Replacement{
+		{From: "лупу", To: "Loopa"},
+		{From: "ну, запускаем", To: "запускаем", CaseSensitive: true},
+	}}}
+	require.NoError(t, w.finishTask(context.Background(), task, time.Now(), transcriptResult{
+		Provider: "mock",
+		Text:     "Ну, запускаем лупу",
+		Segments: []mlclient.TranscribeSegment{{
+			Start: 0, End: 1, Text: "ну, запускаем лупу", CleanedText: &cleaned,
+			HasFillers: true, FillersFound: []string{"ну"},
+			Words: []mlclient.WordTimestamp{{Word: "ну", Start: 0, End: 0.2}, {Word: "лупу,", Start: 0.5, End: 1}},
+		}},
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountFillers(t *testing.T) {
+	counts := countFillers([]string{"как бы", "ну", "Ну", " ", "как бы", "вот"})
+	require.Equal(t, []fillerCount{{"как бы", 2}, {"ну", 2}, {"вот", 1}}, counts)
+}
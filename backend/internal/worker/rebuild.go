@@ -0,0 +1,233 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"loopa/backend/internal/glossary"
+	"loopa/backend/internal/mlclient"
+)
+
+var (
+	// ErrTaskNotReady — задача не завершена: перестраивать нечего.
+	ErrTaskNotReady = errors.New("task is not completed")
+	// ErrNoResponses — для задачи нет сохранённых ответов провайдера
+	// (обработана до их появления или провайдер вернул пустой результат).
+	ErrNoResponses = errors.New("no stored provider responses")
+	// ErrHasCorrections — в задаче есть исправленные вручную сегменты.
+	ErrHasCorrections = errors.New("task has manually corrected segments")
+)
+
+// RebuildSegments заново строит сегменты задачи по сохранённым ответам
+// провайдера текущим алгоритмом выравнивания, без повторного распознавания.
+// Объединения спикеров применяются заново, имена спикеров сохраняются;
+// исправленные вручную сегменты перезаписываются
+// только при force. Слова-паразиты определяются заново, если настроен ML-сервис;
+// замены применяются по текущему словарю проекта. Полный текст задачи
+// собирается из новых сегментов.
+func (w *Worker) RebuildSegments(ctx context.Context, taskID string, force bool) (int, error) {
+	var provider, status string
+	var vocabulary sql.NullString
+	err := w.db.QueryRowContext(ctx,
+		`SELECT t.provider, t.status, p.glossary
+		 FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 LEFT JOIN projects p ON p.id = f.project_id
+		 WHERE t.id = ?`, taskID,
+	).Scan(&provider, &status, &vocabulary)
+	if err != nil {
+		return 0, err
+	}
+	if status != "готово" {
+		return 0, ErrTaskNotReady
+	}
+	var dictionary glossary.Glossary
+	if vocabulary.Valid {
+		if err := json.Unmarshal([]byte(vocabulary.String), &dictionary); err != nil {
+			log.Printf("task %s: invalid project glossary, ignoring: %v", taskID, err)
+			dictionary = glossary.Glossary{}
+		}
+	}
+
+	if !force {
+		var corrected int
+		if err := w.db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM transcription_segments WHERE task_id = ? AND is_corrected = 1`, taskID,
+		).Scan(&corrected); err != nil {
+			return 0, err
+		}
+		if corrected > 0 {
+			return 0, ErrHasCorrections
+		}
+	}
+
+	responses, err := w.loadResponses(ctx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if len(responses) == 0 {
+		return 0, ErrNoResponses
+	}
+	segments, err := segmentsFromResponses(provider, responses)
+	if err != nil {
+		return 0, err
+	}
+	w.detectFillers(ctx, taskID, segments)
+	replaceSegmentTexts(glossary.NewReplacer(dictionary.Replacements), segments)
+
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Задачу могли удалить или запустить заново, пока строились сегменты
+	if err := tx.QueryRow(
+		`SELECT status FROM transcription_tasks WHERE id = ? FOR UPDATE`, taskID,
+	).Scan(&status); err != nil {
+		return 0, err
+	}
+	if status != "готово" {
+		return 0, ErrTaskNotReady
+	}
+
+	names, err := speakerNames(tx, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if err := deleteSegments(tx, taskID); err != nil {
+		return 0, err
+	}
+	if err := insertSegments(tx, taskID, segments); err != nil {
+		return 0, err
+	}
+	// Полный текст собирается из новых сегментов, как после ручной правки
+	if _, err := tx.Exec(
+		`UPDATE transcription_tasks SET transcript_text = ? WHERE id = ?`,
+		segmentsText(segments), taskID,
+	); err != nil {
+		return 0, fmt.Errorf("update transcript text: %w", err)
+	}
+	if _, err := tx.Exec(
+		`UPDATE transcription_segments s
+		 JOIN speaker_merges m ON m.task_id = s.task_id AND m.from_speaker = s.speaker_id
+		 SET s.speaker_id = m.into_speaker
+		 WHERE s.task_id = ?`,
+		taskID,
+	); err != nil {
+		return 0, fmt.Errorf("apply speaker merges: %w", err)
+	}
+	for speakerID, name := range names {
+		if _, err := tx.Exec(
+			`UPDATE transcription_segments SET speaker_name = ? WHERE task_id = ? AND speaker_id = ?`,
+			name, taskID, speakerID,
+		); err != nil {
+			return 0, fmt.Errorf("restore speaker name: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	log.Printf("task %s: rebuilt %d segments from %d stored responses", taskID, len(segments), len(responses))
+	return len(segments), nil
+}
+
+// segmentsText склеивает тексты сегментов в порядке начала.
+func segmentsText(segments []mlclient.TranscribeSegment) string {
+	ordered := make([]mlclient.TranscribeSegment, len(segments))
+	copy(ordered, segments)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Start < ordered[j].Start })
+
+	texts := make([]string, 0, len(ordered))
+	for _, seg := range ordered {
+		if seg.Text != "" {
+			texts = append(texts, seg.Text)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+// RebuildableTasks возвращает завершённые задачи с сохранёнными ответами;
+// provider (значение transcription_tasks.provider) — необязательный фильтр.
+func (w *Worker) RebuildableTasks(ctx context.Context, provider string) ([]string, error) {
+	rows, err := w.db.QueryContext(ctx,
+		`SELECT DISTINCT r.task_id
+		 FROM provider_responses r
+		 JOIN transcription_tasks t ON t.id = r.task_id
+		 WHERE t.status = 'готово' AND (? = '' OR t.provider = ?)
+		 ORDER BY r.task_id`,
+		provider, provider,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// loadResponses читает сохранённые ответы провайдера в порядке частей.
+func (w *Worker) loadResponses(ctx context.Context, taskID string) ([]providerResponse, error) {
+	rows, err := w.db.QueryContext(ctx,
+		`SELECT kind, chunk_index, start_time, end_time, response
+		 FROM provider_responses WHERE task_id = ? ORDER BY chunk_index`,
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var responses []providerResponse
+	for rows.Next() {
+		var resp providerResponse
+		var startMs, endMs int
+		var body string
+		if err := rows.Scan(&resp.Kind, &resp.Index, &startMs, &endMs, &body); err != nil {
+			return nil, err
+		}
+		resp.Start = float64(startMs) / 1000
+		resp.End = float64(endMs) / 1000
+		resp.Body = []byte(body)
+		responses = append(responses, resp)
+	}
+	return responses, rows.Err()
+}
+
+// speakerNames возвращает имена, назначенные спикерам задачи.
+func speakerNames(tx *sql.Tx, taskID string) (map[string]string, error) {
+	rows, err := tx.Query(
+		`SELECT DISTINCT speaker_id, speaker_name FROM transcription_segments
+		 WHERE task_id = ? AND speaker_id IS NOT NULL AND speaker_name IS NOT NULL`,
+		taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[string]string{}
+	for rows.Next() {
+		var speakerID, name string
+		if err := rows.Scan(&speakerID, &name); err != nil {
+			return nil, err
+		}
+		names[speakerID] = name
+	}
+	return names, rows.Err()
+}
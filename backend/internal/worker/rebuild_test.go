@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/speechkit"
+)
+
+func TestSegmentsFromResponses_SpeechKitChunksWithDiarization(t *testing.T) {
+	parts := []speechKitPart{
+		{Index: 0, Start: 0, End: 2, Result: &speechkit.Result{Text: "добрый день"}},
+		{Index: 1, Start: 2, End: 3, Result: &speechkit.Result{}},
+		{Index: 2, Start: 3, End: 4, Result: &speechkit.Result{Text: "здравствуйте"}},
+	}
+	result := timedResult("yandex_speechkit", "добрый день здравствуйте", nil,
+		&mlclient.DiarizationResponse{Segments: []mlclient.DiarizationSegment{
+			{Speaker: "SPEAKER_00", Start: 0, End: 2.5},
+			{Speaker: "SPEAKER_01", Start: 2.5, End: 4},
+		}},
+		speechKitResponses(parts),
+	)
+	require.Len(t, result.Responses, 4)
+	assert.NotEmpty(t, result.SpeakerData)
+
+	segments, err := segmentsFromResponses("yandex_speechkit", result.Responses)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.Equal(t, "SPEAKER_00", segments[0].Speaker)
+	assert.Equal(t, "добрый день", segments[0].Text)
+	assert.Equal(t, "SPEAKER_01", segments[1].Speaker)
+	assert.Equal(t, 3.0, segments[1].Start)
+}
+
+func TestSegmentsFromResponses_WhisperRealignsWords(t *testing.T) {
+	resp := newResponse(responseTranscription, 0, 0, 0, mlclient.TranscribeFullResponse{
+		Segments: []mlclient.TranscribeSegment{
+			{Speaker: "SPEAKER_00", Start: 0, End: 1, Text: "да", Words: []mlclient.WordTimestamp{{Word: "да", Start: 0, End: 0.4}}},
+			{Speaker: "SPEAKER_00", Start: 1, End: 2, Text: "конечно", Words: []mlclient.WordTimestamp{{Word: "конечно", Start: 1, End: 1.6}}},
+		},
+	})
+
+	segments, err := segmentsFromResponses("faster_whisper", []providerResponse{resp})
+	require.NoError(t, err)
+	// Подряд идущие слова одного спикера объединяются в сегмент
+	require.Len(t, segments, 1)
+	assert.Equal(t, "да конечно", segments[0].Text)
+	assert.Len(t, segments[0].Words, 2)
+}
+
+func TestSegmentsFromResponses_Errors(t *testing.T) {
+	_, err := segmentsFromResponses("openai", nil)
+	assert.Error(t, err)
+
+	_, err = segmentsFromResponses("unknown", []providerResponse{{Kind: responseTranscription, Body: []byte(`{}`)}})
+	assert.Error(t, err)
+}
+
+func TestRebuildSegments_KeepsSpeakerNames(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectQuery("SELECT t.provider, t.status, p.glossary").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "status", "glossary"}).
+			AddRow("openai", "готово", `{"replacements":[{"from":"привет","to":"Здравствуйте"}]}`))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM transcription_segments").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM provider_responses WHERE task_id = \\?").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "chunk_index", "start_time", "end_time", "response"}).
+			AddRow("transcription", 0, 0, 0, `{"Segments":[{"Start":0,"End":1,"Text":"привет","Words":[{"Word":"привет","Start":0,"End":1}]}]}`).
+			AddRow("diarization", 0, 0, 0, `{"segments":[{"speaker":"SPEAKER_00","start":0,"end":1}]}`))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT status FROM transcription_tasks WHERE id = \\? FOR UPDATE").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("готово"))
+	mock.ExpectQuery("SELECT DISTINCT speaker_id, speaker_name").
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"speaker_id", "speaker_name"}).AddRow("SPEAKER_00", "Анна"))
+	mock.ExpectExec("DELETE FROM transcription_words").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM transcription_segments").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transcription_segments").
+		// Замены применяются по текущему словарю проекта
+		WithArgs(sqlmock.AnyArg(), "task-1", "SPEAKER_00", 0, 1000, "Здравствуйте", nil, false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO transcription_words").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transcription_tasks SET transcript_text = \\?").
+		WithArgs("Здравствуйте", "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("JOIN speaker_merges m").
+		WithArgs("task-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE transcription_segments SET speaker_name = \\?").
+		WithArgs("Анна", "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := w.RebuildSegments(context.Background(), "task-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuildSegments_SkipsCorrectedTask(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectQuery("SELECT t.provider, t.status, p.glossary").
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "status", "glossary"}).AddRow("openai", "готово", nil))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM transcription_segments").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	_, err := w.RebuildSegments(context.Background(), "task-1", false)
+	assert.ErrorIs(t, err, ErrHasCorrections)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRebuildSegments_TaskNotReady(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectQuery("SELECT t.provider, t.status, p.glossary").
+		WillReturnRows(sqlmock.NewRows([]string{"provider", "status", "glossary"}).AddRow("openai", "в процессе", nil))
+
+	_, err := w.RebuildSegments(context.Background(), "task-1", true)
+	assert.ErrorIs(t, err, ErrTaskNotReady)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,191 @@
+package worker
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/openaistt"
+	"loopa/backend/internal/speechkit"
+	"loopa/backend/internal/whispercpp"
+)
+
+// Виды сохранённых ответов (provider_responses.kind).
+const (
+	responseTranscription = "transcription"
+	responseDiarization   = "diarization"
+)
+
+// providerResponse — ответ одного вызова провайдера до выравнивания по спикерам.
+// По сохранённым ответам сегменты можно построить заново без повторного распознавания.
+type providerResponse struct {
+	Kind  string
+	Index int     // порядковый номер вызова: часть длинного аудио SpeechKit
+	Start float64 // границы части в секундах; для ответа на весь файл — нули
+	End   float64
+	Body  []byte
+}
+
+// newResponse сериализует разобранный ответ провайдера.
+func newResponse(kind string, index int, start, end float64, v interface{}) providerResponse {
+	body, _ := json.Marshal(v)
+	return providerResponse{Kind: kind, Index: index, Start: start, End: end, Body: body}
+}
+
+// speechKitPart — результат распознавания SpeechKit для части аудио.
+// Синхронный API возвращает только текст, асинхронный — фразы с таймкодами.
+type speechKitPart struct {
+	Index  int
+	Start  float64
+	End    float64
+	Result *speechkit.Result
+}
+
+// speechKitPieces превращает части SpeechKit в части транскрипта; пустые пропускаются.
+func speechKitPieces(parts []speechKitPart) []timedText {
+	var pieces []timedText
+	for _, part := range parts {
+		if len(part.Result.Utterances) > 0 {
+			pieces = append(pieces, resultToPieces(part.Result)...)
+			continue
+		}
+		if part.Result.Text == "" {
+			continue
+		}
+		pieces = append(pieces, timedText{
+			Start: part.Start,
+			End:   part.End,
+			Text:  part.Result.Text,
+			Words: estimateWordTimings(part.Result.Text, part.Start, part.End),
+		})
+	}
+	return pieces
+}
+
+func speechKitResponses(parts []speechKitPart) []providerResponse {
+	responses := make([]providerResponse, 0, len(parts))
+	for _, part := range parts {
+		responses = append(responses, newResponse(responseTranscription, part.Index, part.Start, part.End, part.Result))
+	}
+	return responses
+}
+
+// timedResult собирает результат провайдера с таймкодами слов (SpeechKit,
+// whisper.cpp, OpenAI): speaker_data — результат диаризации, он же
+// сохраняется вместе с ответами провайдера.
+func timedResult(provider, text string, segments []mlclient.TranscribeSegment,
+	diarization *mlclient.DiarizationResponse, responses []providerResponse) transcriptResult {
+	result := transcriptResult{
+		Provider:  provider,
+		Text:      text,
+		Segments:  segments,
+		Responses: responses,
+	}
+	if diarization != nil {
+		resp := newResponse(responseDiarization, 0, 0, 0, diarization)
+		result.SpeakerData = resp.Body
+		result.Responses = append(result.Responses, resp)
+	}
+	return result
+}
+
+// insertResponses сохраняет ответы провайдера задачи.
+func insertResponses(tx *sql.Tx, taskID, provider string, responses []providerResponse) error {
+	now := time.Now().UTC()
+	rows := make([][]interface{}, 0, len(responses))
+	for _, resp := range responses {
+		rows = append(rows, []interface{}{
+			uuid.New().String(), taskID, provider, resp.Kind, resp.Index,
+			int(resp.Start * 1000), int(resp.End * 1000), string(resp.Body), now,
+		})
+	}
+	if err := insertBatches(tx,
+		`INSERT INTO provider_responses
+		 (id, task_id, provider, kind, chunk_index, start_time, end_time, response, created_at) VALUES `,
+		rows,
+	); err != nil {
+		return fmt.Errorf("save responses: %w", err)
+	}
+	return nil
+}
+
+// segmentsFromResponses строит сегменты по сохранённым ответам провайдера
+// текущим алгоритмом выравнивания. Ответы — в порядке chunk_index.
+func segmentsFromResponses(provider string, responses []providerResponse) ([]mlclient.TranscribeSegment, error) {
+	var transcripts []providerResponse
+	var diarization *mlclient.DiarizationResponse
+	for _, resp := range responses {
+		switch resp.Kind {
+		case responseTranscription:
+			transcripts = append(transcripts, resp)
+		case responseDiarization:
+			diarization = &mlclient.DiarizationResponse{}
+			if err := json.Unmarshal(resp.Body, diarization); err != nil {
+				return nil, fmt.Errorf("parse diarization: %w", err)
+			}
+		}
+	}
+	if len(transcripts) == 0 {
+		return nil, fmt.Errorf("no stored responses")
+	}
+
+	var pieces []timedText
+	switch provider {
+	case "faster_whisper", "mock":
+		// ML-сервис возвращает уже размеченные сегменты: реплики спикеров
+		// восстанавливаются по их границам
+		var resp mlclient.TranscribeFullResponse
+		if err := json.Unmarshal(transcripts[0].Body, &resp); err != nil {
+			return nil, fmt.Errorf("parse response: %w", err)
+		}
+		var words []mlclient.WordTimestamp
+		var turns []mlclient.DiarizationSegment
+		for _, seg := range resp.Segments {
+			words = append(words, seg.Words...)
+			if seg.Speaker != "" {
+				turns = append(turns, mlclient.DiarizationSegment{Speaker: seg.Speaker, Start: seg.Start, End: seg.End})
+			}
+		}
+		if len(words) == 0 {
+			return resp.Segments, nil
+		}
+		return alignWords(words, turns), nil
+	case "yandex_speechkit":
+		parts := make([]speechKitPart, 0, len(transcripts))
+		for _, resp := range transcripts {
+			part := speechKitPart{Index: resp.Index, Start: resp.Start, End: resp.End, Result: &speechkit.Result{}}
+			if err := json.Unmarshal(resp.Body, part.Result); err != nil {
+				return nil, fmt.Errorf("parse chunk %d: %w", resp.Index, err)
+			}
+			parts = append(parts, part)
+		}
+		pieces = speechKitPieces(parts)
+	case "whisper_cpp":
+		var result whispercpp.Result
+		if err := json.Unmarshal(transcripts[0].Body, &result); err != nil {
+			return nil, fmt.Errorf("parse response: %w", err)
+		}
+		pieces = whisperCppPieces(&result)
+	case "openai":
+		var result openaistt.Result
+		if err := json.Unmarshal(transcripts[0].Body, &result); err != nil {
+			return nil, fmt.Errorf("parse response: %w", err)
+		}
+		pieces = openAIPieces(&result)
+	default:
+		return nil, fmt.Errorf("unknown provider %q", provider)
+	}
+
+	var words []mlclient.WordTimestamp
+	for _, piece := range pieces {
+		words = append(words, piece.Words...)
+	}
+	if diarization != nil && len(diarization.Segments) > 0 && len(words) > 0 {
+		return alignWords(words, diarization.Segments), nil
+	}
+	return piecesToSegments(pieces), nil
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/voiceprint"
+)
+
+// taskSpeaker — эмбеддинг голоса спикера задачи и найденный для него профиль проекта.
+type taskSpeaker struct {
+	SpeakerID  string
+	Embedding  []float64
+	DurationMs int
+	Match      *voiceprint.Match // nil — голос не узнан
+}
+
+// identifySpeakers считает эмбеддинги голосов спикеров задачи проекта и ищет
+// их среди голосовых профилей проекта. Ошибки не прерывают задачу: спикеры
+// остаются безымянными, как без профилей.
+func (w *Worker) identifySpeakers(ctx context.Context, task TaskRow, segments []mlclient.TranscribeSegment) []taskSpeaker {
+	if !w.speakerIdentification || w.mlClient == nil || task.ProjectID == "" {
+		return nil
+	}
+	turns := speakerTurns(segments)
+	if len(turns) == 0 {
+		return nil
+	}
+
+	embeddings, err := w.mlClient.SpeakerEmbeddings(ctx, task.StoragePath, turns)
+	if err != nil {
+		log.Printf("task %s: speaker embeddings failed (non-fatal): %v", task.ID, err)
+		return nil
+	}
+
+	profiles, err := w.loadSpeakerProfiles(task.ProjectID)
+	if err != nil {
+		log.Printf("task %s: failed to load speaker profiles (non-fatal): %v", task.ID, err)
+	}
+
+	speakers := make([]taskSpeaker, 0, len(embeddings))
+	candidates := make([]voiceprint.Speaker, 0, len(embeddings))
+	for _, e := range embeddings {
+		speakers = append(speakers, taskSpeaker{
+			SpeakerID:  e.Speaker,
+			Embedding:  e.Embedding,
+			DurationMs: int(e.Duration * 1000),
+		})
+		candidates = append(candidates, voiceprint.Speaker{ID: e.Speaker, Embedding: e.Embedding})
+	}
+
+	matches := voiceprint.MatchSpeakers(candidates, profiles, w.speakerThreshold)
+	for i := range matches {
+		for j := range speakers {
+			if speakers[j].SpeakerID == matches[i].SpeakerID {
+				speakers[j].Match = &matches[i]
+			}
+		}
+	}
+	log.Printf("task %s: %d of %d speakers matched to project voice profiles", task.ID, len(matches), len(speakers))
+	return speakers
+}
+
+// speakerTurns — реплики сегментов со спикером для расчёта эмбеддингов.
+func speakerTurns(segments []mlclient.TranscribeSegment) []mlclient.SpeakerTurn {
+	var turns []mlclient.SpeakerTurn
+	for _, seg := range segments {
+		if seg.Speaker == "" || seg.End <= seg.Start {
+			continue
+		}
+		turns = append(turns, mlclient.SpeakerTurn{Speaker: seg.Speaker, Start: seg.Start, End: seg.End})
+	}
+	return turns
+}
+
+// loadSpeakerProfiles читает голосовые профили проекта; профили без записи
+// голоса или с повреждённым эмбеддингом пропускаются.
+func (w *Worker) loadSpeakerProfiles(projectID string) ([]voiceprint.Profile, error) {
+	rows, err := w.db.Query(
+		`SELECT id, name, embedding FROM speaker_profiles
+		 WHERE project_id = ? AND embedding IS NOT NULL`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []voiceprint.Profile
+	for rows.Next() {
+		var p voiceprint.Profile
+		var embedding string
+		if err := rows.Scan(&p.ID, &p.Name, &embedding); err != nil {
+			return nil, err
+		}
+		if p.Embedding, err = voiceprint.Decode(embedding); err != nil {
+			log.Printf("speaker profile %s: %v", p.ID, err)
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles, rows.Err()
+}
+
+// insertTaskSpeakers сохраняет эмбеддинги спикеров задачи и подставляет
+// имена узнанных спикеров в сегменты.
+func insertTaskSpeakers(tx *sql.Tx, taskID string, speakers []taskSpeaker) error {
+	rows := make([][]interface{}, 0, len(speakers))
+	for _, sp := range speakers {
+		var profileID, similarity interface{}
+		if sp.Match != nil {
+			profileID = sp.Match.Profile.ID
+			similarity = sp.Match.Similarity
+		}
+		rows = append(rows, []interface{}{
+			taskID, sp.SpeakerID, voiceprint.Encode(sp.Embedding), sp.DurationMs, profileID, similarity,
+		})
+	}
+	if err := insertBatches(tx,
+		`INSERT INTO task_speakers (task_id, speaker_id, embedding, duration_ms, profile_id, similarity) VALUES `,
+		rows,
+	); err != nil {
+		return fmt.Errorf("save speakers: %w", err)
+	}
+
+	for _, sp := range speakers {
+		if sp.Match == nil {
+			continue
+		}
+		if _, err := tx.Exec(
+			`UPDATE transcription_segments SET speaker_name = ? WHERE task_id = ? AND speaker_id = ?`,
+			sp.Match.Profile.Name, taskID, sp.SpeakerID,
+		); err != nil {
+			return fmt.Errorf("save speaker names: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/voiceprint"
+)
+
+func TestIdentifySpeakers_MatchesProjectProfiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/speaker-embeddings", r.URL.Path)
+		w.Write([]byte(`{"speakers":[
+			{"speaker":"SPEAKER_00","embedding":[0.98,0.2],"duration":12.5},
+			{"speaker":"SPEAKER_01","embedding":[0,1],"duration":3}
+		]}`))
+	}))
+	defer srv.Close()
+
+	w, mock := newTestWorker(t, Config{})
+	w.mlClient = mlclient.New(srv.URL)
+	w.speakerIdentification = true
+	w.speakerThreshold = voiceprint.DefaultThreshold
+
+	mock.ExpectQuery("SELECT id, name, embedding FROM speaker_profiles\\s+WHERE project_id = \\? AND embedding IS NOT NULL").
+		WithArgs("proj-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "embedding"}).
+			AddRow("p-anna", "Анна", "[1,0]").
+			AddRow("p-broken", "Борис", "oops"))
+
+	speakers := w.identifySpeakers(context.Background(), TaskRow{ID: "task-1", ProjectID: "proj-1", StoragePath: writeTempAudio(t, "audio.ogg")},
+		[]mlclient.TranscribeSegment{
+			{Speaker: "SPEAKER_00", Start: 0, End: 12.5},
+			{Speaker: "SPEAKER_01", Start: 12.5, End: 15.5},
+		})
+
+	require.Len(t, speakers, 2)
+	require.NotNil(t, speakers[0].Match)
+	assert.Equal(t, "Анна", speakers[0].Match.Profile.Name)
+	assert.Equal(t, 12500, speakers[0].DurationMs)
+	assert.Nil(t, speakers[1].Match)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdentifySpeakers_SkipsTaskOutsideProject(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+	w.mlClient = mlclient.New("http://127.0.0.1:1")
+	w.speakerIdentification = true
+
+	speakers := w.identifySpeakers(context.Background(), TaskRow{ID: "task-1"},
+		[]mlclient.TranscribeSegment{{Speaker: "SPEAKER_00", Start: 0, End: 5}})
+	assert.Nil(t, speakers)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveResult_SavesSpeakersAndNames(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO task_speakers").
+		WithArgs("task-1", "SPEAKER_00", "[1,0]", 5000, "p-anna", 0.95,
+			"task-1", "SPEAKER_01", "[0,1]", 2000, nil, nil).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE transcription_segments SET speaker_name = \\?").
+		WithArgs("Анна", "task-1", "SPEAKER_00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово'").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, w.saveResult("task-1", time.Now(), transcriptResult{
+		Provider: "openai",
+		Segments: []mlclient.TranscribeSegment{
+			{Speaker: "SPEAKER_00", Start: 0, End: 5, Text: "добрый день"},
+			{Speaker: "SPEAKER_01", Start: 5, End: 7, Text: "привет"},
+		},
+		Speakers: []taskSpeaker{
+			{SpeakerID: "SPEAKER_00", Embedding: []float64{1, 0}, DurationMs: 5000, Match: &voiceprint.Match{
+				SpeakerID: "SPEAKER_00", Profile: voiceprint.Profile{ID: "p-anna", Name: "Анна"}, Similarity: 0.95,
+			}},
+			{SpeakerID: "SPEAKER_01", Embedding: []float64{0, 1}, DurationMs: 2000},
+		},
+	}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,292 @@
+package worker
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/media"
+	"loopa/backend/internal/speechkit"
+	"loopa/backend/internal/speechkit/speechkittest"
+)
+
+// speechKitConfig настраивает SpeechKit и Object Storage на фейковый сервер.
+func speechKitConfig(srv *speechkittest.Server, apiVersion string) Config {
+	return Config{
+		Provider:             "speechkit",
+		SpeechKitAPIKey:      "test-key",
+		S3:                   &S3Config{AccessKey: "key", SecretKey: "secret", Bucket: "bucket", Endpoint: srv.URL},
+		SpeechKitConcurrency: 2,
+		SpeechKitAPIVersion:  apiVersion,
+		SpeechKitEndpoints:   srv.Endpoints(),
+		SpeechKitOptions:     speechkit.RecognitionOptions{Language: "ru-RU"},
+	}
+}
+
+func expectSaveOperation(mock sqlmock.Sqlmock, opID, api, key string) {
+	mock.ExpectExec("UPDATE transcription_tasks SET speechkit_operation_id = \\?").
+		WithArgs(opID, api, key, "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectClearOperation(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("UPDATE transcription_tasks SET speechkit_operation_id = NULL").
+		WithArgs("task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func writeTempAudio(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte("OggS fake audio"), 0o644))
+	return path
+}
+
+func TestRecognizeLongAudioAsync_V2(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetPendingPolls(2)
+	srv.SetV2Result(`{"chunks":[{"alternatives":[{"text":"добрый день","words":[{"startTime":"1s","endTime":"1.5s","word":"добрый"},{"startTime":"1.5s","endTime":"2s","word":"день"}]}]}]}`)
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v2"))
+	expectSaveOperation(mock, "op-1", "v2", "audio/task-1_a.ogg")
+	expectClearOperation(mock)
+
+	parts, err := w.recognizeLongAudioAsync(context.Background(), TaskRow{ID: "task-1"}, writeTempAudio(t, "a.ogg"))
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	pieces := speechKitPieces(parts)
+
+	require.Len(t, pieces, 1)
+	assert.Equal(t, "добрый день", pieces[0].Text)
+	assert.Equal(t, 1.0, pieces[0].Start)
+	assert.Equal(t, 2.0, pieces[0].End)
+	require.Len(t, pieces[0].Words, 2)
+	assert.Equal(t, 1.5, pieces[0].Words[1].Start)
+
+	// Файл загружен в хранилище и удалён после распознавания
+	assert.Equal(t, 1, countRequests(srv, http.MethodPut))
+	assert.Equal(t, 1, countRequests(srv, http.MethodDelete))
+	_, ok := srv.Object("/bucket/audio/task-1_a.ogg")
+	assert.False(t, ok)
+	assert.Equal(t, 1, srv.Count("/speech/stt/v2/longRunningRecognize"))
+}
+
+func TestRecognizeLongAudioAsync_V3(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetV3Stream(`{"result":{"audioCursors":{"finalIndex":"0"},"final":{"alternatives":[{"text":"три тысячи","startTimeMs":"0","endTimeMs":"1000"}]}}}`)
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v3"))
+	expectSaveOperation(mock, "op-1", "v3", "audio/task-1_a.ogg")
+	expectClearOperation(mock)
+
+	parts, err := w.recognizeLongAudioAsync(context.Background(), TaskRow{ID: "task-1"}, writeTempAudio(t, "a.ogg"))
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	pieces := speechKitPieces(parts)
+
+	require.Len(t, pieces, 1)
+	assert.Equal(t, "три тысячи", pieces[0].Text)
+	// Без слов в ответе таймкоды оцениваются по длине слов
+	require.Len(t, pieces[0].Words, 2)
+	assert.Equal(t, 1, srv.Count("/stt/v3/recognizeFileAsync"))
+	assert.Equal(t, 1, srv.Count("/stt/v3/getRecognition"))
+}
+
+func TestRecognizeLongAudioAsync_RecognitionFailed(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetOperationError("bad audio")
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v2"))
+	expectSaveOperation(mock, "op-1", "v2", "audio/task-1_a.ogg")
+	expectClearOperation(mock)
+
+	_, err := w.recognizeLongAudioAsync(context.Background(), TaskRow{ID: "task-1"}, writeTempAudio(t, "a.ogg"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad audio")
+	// Объект удаляется и при ошибке
+	assert.Equal(t, 1, countRequests(srv, http.MethodDelete))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecognizeLongAudioAsync_ResumesSavedOperation(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetV2Result(`{"chunks":[{"alternatives":[{"text":"продолжение"}]}]}`)
+
+	// Операция запущена до перезапуска worker'а
+	opID, err := srv.Client().StartLongAudio(context.Background(), "uri", speechkit.RecognitionOptions{})
+	require.NoError(t, err)
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v2"))
+	expectClearOperation(mock)
+
+	task := TaskRow{ID: "task-1", OperationID: opID, OperationAPI: "v2", ObjectKey: "audio/task-1_a.ogg"}
+	parts, err := w.recognizeLongAudioAsync(context.Background(), task, writeTempAudio(t, "a.ogg"))
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	pieces := speechKitPieces(parts)
+
+	require.Len(t, pieces, 1)
+	assert.Equal(t, "продолжение", pieces[0].Text)
+	// Файл не загружается и распознавание не запускается повторно
+	assert.Equal(t, 0, countRequests(srv, http.MethodPut))
+	assert.Equal(t, 1, srv.Count("/speech/stt/v2/longRunningRecognize"))
+	assert.Equal(t, 1, countRequests(srv, http.MethodDelete))
+}
+
+func TestRecognizeLongAudioAsync_ExpiredOperationStartsAgain(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v2"))
+	expectClearOperation(mock)
+	expectSaveOperation(mock, "op-1", "v2", "audio/task-1_a.ogg")
+	expectClearOperation(mock)
+
+	task := TaskRow{ID: "task-1", OperationID: "expired", OperationAPI: "v2", ObjectKey: "audio/old.ogg"}
+	_, err := w.recognizeLongAudioAsync(context.Background(), task, writeTempAudio(t, "a.ogg"))
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 1, countRequests(srv, http.MethodPut))
+	assert.Equal(t, 1, srv.Count("/speech/stt/v2/longRunningRecognize"))
+}
+
+func TestRecognizeLongAudioAsync_ShutdownKeepsOperation(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.SetPendingPolls(1000)
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v2"))
+	expectSaveOperation(mock, "op-1", "v2", "audio/task-1_a.ogg")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := w.recognizeLongAudioAsync(ctx, TaskRow{ID: "task-1"}, writeTempAudio(t, "a.ogg"))
+	require.Error(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// Объект остаётся в хранилище до продолжения после перезапуска
+	assert.Equal(t, 0, countRequests(srv, http.MethodDelete))
+	_, ok := srv.Object("/bucket/audio/task-1_a.ogg")
+	assert.True(t, ok)
+}
+
+func TestRecognizeLongAudioAsync_TransientPollFailureKeepsOperation(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	for i := 0; i < 10; i++ {
+		srv.ScriptOperations(speechkittest.Reply{Status: http.StatusBadGateway, Body: `{}`})
+	}
+
+	w, mock := newTestWorker(t, speechKitConfig(srv, "v2"))
+	expectSaveOperation(mock, "op-1", "v2", "audio/task-1_a.ogg")
+
+	_, err := w.recognizeLongAudioAsync(context.Background(), TaskRow{ID: "task-1"}, writeTempAudio(t, "a.ogg"))
+	require.Error(t, err)
+	assert.True(t, speechkit.IsTransient(err))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// Задача вернётся в очередь и продолжит опрос той же операции
+	assert.Equal(t, 0, countRequests(srv, http.MethodDelete))
+	_, ok := srv.Object("/bucket/audio/task-1_a.ogg")
+	assert.True(t, ok)
+}
+
+func TestRecoverTasks(t *testing.T) {
+	w, mock := newTestWorker(t, Config{Provider: "speechkit"})
+	mock.ExpectExec("UPDATE transcription_tasks SET status = 'ожидает' WHERE status = 'в процессе'").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, w.recoverTasks())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecognizeChunks_KeepsOrderAndOffsets(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	// Первая часть отвечает дольше остальных — порядок не должен сбиться
+	srv.ScriptRecognize(
+		speechkittest.Reply{Body: `{"result":"один"}`, Delay: 30 * time.Millisecond},
+		speechkittest.Reply{Body: `{"result":"два"}`},
+		speechkittest.Reply{Body: `{"result":""}`},
+	)
+
+	w, _ := newTestWorker(t, speechKitConfig(srv, "v2"))
+	w.chunkConcurrency = 1
+	paths := []string{writeTempAudio(t, "0.ogg"), writeTempAudio(t, "1.ogg"), writeTempAudio(t, "2.ogg")}
+	chunks := []media.Chunk{{Start: 0, End: 29}, {Start: 29, End: 50}, {Start: 50, End: 60}}
+
+	parts, err := w.recognizeChunks(context.Background(), "task-1", paths, chunks)
+	require.NoError(t, err)
+	// Ответ каждой части сохраняется, в том числе пустой
+	require.Len(t, parts, 3)
+	assert.Equal(t, 2, parts[2].Index)
+
+	// Пустая часть пропускается
+	pieces := speechKitPieces(parts)
+	require.Len(t, pieces, 2)
+	assert.Equal(t, "один", pieces[0].Text)
+	assert.Equal(t, "два", pieces[1].Text)
+	assert.Equal(t, 29.0, pieces[1].Start)
+	assert.Equal(t, 50.0, pieces[1].End)
+	assert.Equal(t, "один два", joinTimedText(pieces))
+}
+
+func TestRecognizeChunks_CancelInterruptsRequest(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.ScriptRecognize(speechkittest.Reply{Body: `{"result":"поздно"}`, Delay: 500 * time.Millisecond})
+
+	w, _ := newTestWorker(t, speechKitConfig(srv, "v2"))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	started := time.Now()
+	_, err := w.recognizeChunks(ctx, "task-1", []string{writeTempAudio(t, "0.ogg")}, []media.Chunk{{Start: 0, End: 29}})
+	assert.ErrorIs(t, err, context.Canceled)
+	// Запрос прерван, а не дождался ответа
+	assert.Less(t, time.Since(started), 400*time.Millisecond)
+}
+
+func TestRecognizeChunks_StopsOnError(t *testing.T) {
+	srv := speechkittest.NewServer()
+	defer srv.Close()
+	srv.ScriptRecognize(speechkittest.Reply{Status: http.StatusTooManyRequests, Body: `{"code":8,"message":"quota exceeded"}`})
+
+	w, _ := newTestWorker(t, speechKitConfig(srv, "v2"))
+	w.chunkConcurrency = 1
+	paths := make([]string, 5)
+	chunks := make([]media.Chunk, 5)
+	for i := range paths {
+		paths[i] = writeTempAudio(t, "chunk.ogg")
+		chunks[i] = media.Chunk{Start: float64(i * 29), End: float64((i + 1) * 29)}
+	}
+
+	_, err := w.recognizeChunks(context.Background(), "task-1", paths, chunks)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chunk 1/5")
+	assert.Contains(t, err.Error(), "quota exceeded")
+	assert.Less(t, srv.Count("/speech/v1/stt:recognize"), 5)
+}
+
+func countRequests(srv *speechkittest.Server, method string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Method == method {
+			n++
+		}
+	}
+	return n
+}
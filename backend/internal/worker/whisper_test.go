@@ -0,0 +1,173 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/mlclient"
+)
+
+// newMLJobServer — ML-сервис, у которого есть только задача job-1:
+// первый опрос возвращает половину прогресса, второй — завершение.
+func newMLJobServer(t *testing.T, submits *int32) *httptest.Server {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs/transcribe-full", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(submits, 1)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(mlclient.JobStatus{JobID: "job-1", Status: mlclient.JobQueued})
+	})
+	mux.HandleFunc("/jobs/job-1", func(w http.ResponseWriter, r *http.Request) {
+		status := mlclient.JobStatus{JobID: "job-1", Status: mlclient.JobRunning, Progress: 0.5}
+		if atomic.AddInt32(&polls, 1) > 1 {
+			status = mlclient.JobStatus{JobID: "job-1", Status: mlclient.JobDone, Progress: 1}
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+	mux.HandleFunc("/jobs/job-1/result", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(mlclient.TranscribeFullResponse{
+			FullText:    "добрый день",
+			NumSpeakers: 1,
+			Segments:    []mlclient.TranscribeSegment{{Speaker: "SPEAKER_00", Start: 0, End: 1.2, Text: "добрый день"}},
+		})
+	})
+	mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail":"Задача не найдена"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func expectWhisperResult(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("UPDATE transcription_tasks SET progress = \\?").
+		WithArgs(50, "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transcription_tasks SET progress = \\?").
+		WithArgs(100, "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE transcription_tasks SET speaker_data").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectReplaceSegments(mock, "task-1")
+	mock.ExpectExec("INSERT INTO transcription_segments").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO provider_responses").
+		WithArgs(sqlmock.AnyArg(), "task-1", "faster_whisper", "transcription", 0, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'готово'.*ml_job_id = NULL").
+		WithArgs("добрый день", "faster_whisper", sqlmock.AnyArg(), sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestProcessTaskWhisper_SubmitsJob(t *testing.T) {
+	var submits int32
+	srv := newMLJobServer(t, &submits)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = \\?").
+		WithArgs("job-1", "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWhisperResult(mock)
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.processTaskWhisper(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int32(1), submits)
+}
+
+func TestProcessTaskWhisper_ReattachesToJob(t *testing.T) {
+	var submits int32
+	srv := newMLJobServer(t, &submits)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	expectWhisperResult(mock)
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg"), MLJobID: "job-1"}
+	require.NoError(t, w.processTaskWhisper(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int32(0), submits)
+}
+
+func TestProcessTaskWhisper_LostJobIsSubmittedAgain(t *testing.T) {
+	var submits int32
+	srv := newMLJobServer(t, &submits)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = \\?").
+		WithArgs("job-1", "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectWhisperResult(mock)
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg"), MLJobID: "lost-after-restart"}
+	require.NoError(t, w.processTaskWhisper(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int32(1), submits)
+}
+
+func TestProcessTaskWhisper_JobLostAfterSubmitRequeues(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs/transcribe-full", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(mlclient.JobStatus{JobID: "job-2", Status: mlclient.JobQueued})
+	})
+	// ML-сервис перезапустился и не знает задачу
+	mux.HandleFunc("/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"detail":"Задача не найдена"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = \\?").
+		WithArgs("job-2", "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = NULL").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO provider_health").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SET status = 'ожидает', started_at = NULL").
+		WithArgs("task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	require.NoError(t, w.processTaskWhisper(context.Background(), task, time.Now()))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProcessTaskWhisper_PermanentErrorCancelsJob(t *testing.T) {
+	var cancels int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs/transcribe-full", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(mlclient.JobStatus{JobID: "job-3", Status: mlclient.JobQueued})
+	})
+	mux.HandleFunc("/jobs/job-3", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			atomic.AddInt32(&cancels, 1)
+			json.NewEncoder(w).Encode(mlclient.JobStatus{JobID: "job-3", Status: mlclient.JobCancelled})
+			return
+		}
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"detail":"Неверный ID задачи"}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	w, mock := newTestWorker(t, Config{Provider: "whisper", MLServiceURL: srv.URL})
+
+	mock.ExpectExec("UPDATE transcription_tasks SET ml_job_id = \\?").
+		WithArgs("job-3", "task-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	task := TaskRow{ID: "task-1", StoragePath: writeTempAudio(t, "a.ogg")}
+	err := w.processTaskWhisper(context.Background(), task, time.Now())
+	var failure *providerFailure
+	require.ErrorAs(t, err, &failure)
+	// Следующий провайдер получит задачу, а задача ML-сервиса отменена
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cancels))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"loopa/backend/internal/media"
+	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/whispercpp"
+)
+
+// processTaskWhisperCpp — pipeline через локальный whisper.cpp, без ML-сервиса.
+// Если ML-сервис всё же настроен, он используется для диаризации и поиска
+// слов-паразитов, как в pipeline SpeechKit.
+func (w *Worker) processTaskWhisperCpp(ctx context.Context, task TaskRow, startTime time.Time) error {
+	if w.whisperCpp == nil {
+		return &providerFailure{provider: "whispercpp", message: "whisper.cpp не настроен"}
+	}
+
+	wavPath, err := media.PreprocessWAV(task.StoragePath, w.uploadDir, task.Preprocessing)
+	if err != nil {
+		return &providerFailure{provider: "whispercpp", message: "Ошибка конвертации аудио: " + err.Error()}
+	}
+	defer os.Remove(wavPath)
+
+	log.Printf("task %s: starting whisper.cpp transcription", task.ID)
+
+	lastProgress := -1
+	result, err := w.whisperCpp.Transcribe(ctx, wavPath, task.Glossary.Prompt(), func(progress int) {
+		if progress == lastProgress {
+			return
+		}
+		lastProgress = progress
+		if _, err := w.db.Exec(
+			`UPDATE transcription_tasks SET progress = ? WHERE id = ?`,
+			progress, task.ID,
+		); err != nil {
+			log.Printf("task %s: failed to save progress: %v", task.ID, err)
+		}
+	})
+	if err != nil && ctx.Err() != nil {
+		// Процесс остановлен вместе с worker'ом; задача начнётся заново после перезапуска
+		log.Printf("task %s: interrupted by shutdown", task.ID)
+		return nil
+	}
+	if err != nil {
+		return &providerFailure{provider: "whispercpp", message: "Ошибка распознавания: " + err.Error()}
+	}
+	w.recordProviderSuccess(serviceWhisperCpp)
+
+	log.Printf("task %s: whisper.cpp done — %d segments, lang=%s", task.ID, len(result.Segments), result.Language)
+
+	segments, diarization := w.buildTimedSegments(ctx, task.ID, wavPath, whisperCppPieces(result))
+	return w.finishTask(ctx, task, startTime, timedResult(
+		"whisper_cpp", result.Text, segments, diarization,
+		[]providerResponse{newResponse(responseTranscription, 0, 0, 0, result)},
+	))
+}
+
+// whisperCppPieces переводит фразы whisper.cpp в части с таймкодами слов.
+func whisperCppPieces(result *whispercpp.Result) []timedText {
+	pieces := make([]timedText, 0, len(result.Segments))
+	for _, seg := range result.Segments {
+		piece := timedText{Start: seg.Start, End: seg.End, Text: seg.Text}
+		for _, word := range seg.Words {
+			piece.Words = append(piece.Words, mlclient.WordTimestamp{Word: word.Text, Start: word.Start, End: word.End})
+		}
+		if len(piece.Words) == 0 {
+			piece.Words = estimateWordTimings(seg.Text, seg.Start, seg.End)
+		}
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
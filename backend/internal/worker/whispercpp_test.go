@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/whispercpp"
+)
+
+func TestWhisperCppPieces(t *testing.T) {
+	pieces := whisperCppPieces(&whispercpp.Result{Segments: []whispercpp.Segment{
+		{Start: 0, End: 1.5, Text: "добрый день", Words: []whispercpp.Word{
+			{Text: "добрый", Start: 0, End: 0.6},
+			{Text: "день", Start: 0.7, End: 1.5},
+		}},
+		// Без токенов таймкоды слов оцениваются по длине
+		{Start: 2, End: 3, Text: "как дела"},
+	}})
+
+	require.Len(t, pieces, 2)
+	assert.Equal(t, "день", pieces[0].Words[1].Word)
+	assert.Equal(t, 0.7, pieces[0].Words[1].Start)
+	require.Len(t, pieces[1].Words, 2)
+	assert.Equal(t, 2.0, pieces[1].Words[0].Start)
+	assert.InDelta(t, 3.0, pieces[1].Words[1].End, 0.2)
+}
+
+func TestProcessTaskWhisperCpp_NotConfigured(t *testing.T) {
+	w, _ := newTestWorker(t, Config{Provider: "whisper"})
+
+	err := w.processTaskWhisperCpp(context.Background(), TaskRow{ID: "task-1"}, time.Now())
+	var failure *providerFailure
+	require.True(t, errors.As(err, &failure))
+	assert.Equal(t, "whispercpp", failure.provider)
+}
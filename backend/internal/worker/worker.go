@@ -4,31 +4,52 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
-
+	"loopa/backend/internal/glossary"
 	"loopa/backend/internal/media"
 	"loopa/backend/internal/mlclient"
+	"loopa/backend/internal/openaistt"
 	"loopa/backend/internal/speechkit"
 	"loopa/backend/internal/storage"
+	"loopa/backend/internal/voiceprint"
+	"loopa/backend/internal/whispercpp"
 )
 
 const (
 	// Максимальная длительность для синхронного API (секунды)
 	maxSyncDuration = 30.0
-	// Длительность одной части при разбиении (секунды)
-	chunkDuration = 29
+	// Максимальная длительность одной части при разбиении (секунды)
+	maxChunkDuration = 29.5
+	// Параметры поиска пауз для разрезания длинного аудио
+	silenceThresholdDB = -35.0
+	minSilenceDuration = 0.3
+	// Длина колонки transcription_tasks.error_message
+	maxErrorMessageLen = 512
 )
 
 type TaskRow struct {
-	ID          string
-	StoragePath string
+	ID            string
+	StoragePath   string
+	Duration      float64 // секунды; 0 — неизвестна
+	Preprocessing media.PreprocessOptions
+	// Незавершённая операция SpeechKit, запущенная до перезапуска worker'а.
+	OperationID  string
+	OperationAPI string
+	ObjectKey    string
+	// MLJobID — фоновая задача ML-сервиса, к которой можно переподключиться.
+	MLJobID string
+	// ProjectID — проект файла; пусто — файл вне проекта, спикеры не узнаются.
+	ProjectID string
+	// Glossary — словарь проекта: подсказка провайдеру и замены в сегментах.
+	Glossary glossary.Glossary
 }
 
 // S3Config содержит настройки Yandex Object Storage для async API.
@@ -36,34 +57,123 @@ type S3Config struct {
 	AccessKey string
 	SecretKey string
 	Bucket    string
+	Endpoint  string // пустая строка — Yandex Object Storage
+}
+
+// Config содержит параметры worker'а.
+type Config struct {
+	Provider string // "whisper" или "speechkit"
+	// Fallback — провайдеры, которые пробуются по порядку после постоянной ошибки Provider.
+	Fallback          []string
+	SpeechKitAPIKey   string
+	SpeechKitFolderID string
+	UploadDir         string
+	MLServiceURL      string
+	S3                *S3Config
+	// KeepOriginalVideo — не удалять исходное видео после извлечения аудио.
+	KeepOriginalVideo bool
+	// Preprocessing — фильтры по умолчанию, если у задачи и проекта они не заданы.
+	Preprocessing media.PreprocessOptions
+	// SpeechKitConcurrency — сколько частей длинного аудио распознаётся одновременно.
+	SpeechKitConcurrency int
+	// SpeechKitRPS — лимит запросов к синхронному API SpeechKit в секунду.
+	SpeechKitRPS float64
+	// SpeechKitAPIVersion — "v3" включает recognizeFileAsync, иначе longRunningRecognize (v2).
+	SpeechKitAPIVersion string
+	SpeechKitOptions    speechkit.RecognitionOptions
+	// SpeechKitEndpoints — адреса API SpeechKit; пустые поля — Yandex Cloud.
+	SpeechKitEndpoints speechkit.Endpoints
+	// SpeechKitPollInterval — первая пауза опроса асинхронной операции (по умолчанию 1s).
+	SpeechKitPollInterval time.Duration
+	// MLTimeouts — ограничения времени запросов к ML-сервису по эндпоинтам.
+	MLTimeouts mlclient.Timeouts
+	// MLJobPollInterval — как часто опрашивать задачу ML-сервиса (по умолчанию 5s).
+	MLJobPollInterval time.Duration
+	// ProviderFailureThreshold — после скольких ошибок подряд провайдер считается недоступным (по умолчанию 3).
+	ProviderFailureThreshold int
+	// ProviderCooldown — пауза перед повторной проверкой недоступного провайдера (по умолчанию 1m).
+	ProviderCooldown time.Duration
+	// HealthCheckInterval — как часто проверять доступный провайдер (по умолчанию 30s).
+	HealthCheckInterval time.Duration
+	// WhisperCpp — локальный whisper.cpp для провайдера "whispercpp".
+	WhisperCpp whispercpp.Options
+	// OpenAI — OpenAI-совместимый API распознавания для провайдера "openai".
+	OpenAI openaistt.Options
+	// MockDelay — искусственная задержка провайдера "mock".
+	MockDelay time.Duration
+	// MockFixturesDir — каталог fixture по sha256 файла (<hash>.json) для "mock".
+	MockFixturesDir string
+	// MockFail — "permanent" или "transient": ошибка mock для каждой задачи.
+	MockFail string
+	// SpeakerIdentification — узнавать спикеров задач проекта по голосовым профилям.
+	SpeakerIdentification bool
+	// SpeakerMatchThreshold — минимальная близость голоса к профилю (по умолчанию voiceprint.DefaultThreshold).
+	SpeakerMatchThreshold float64
 }
 
 type Worker struct {
-	db           *sql.DB
-	speechKit    *speechkit.Client
-	mlClient     *mlclient.Client
-	s3Client     *storage.S3Client
-	uploadDir    string
-	provider     string // "whisper" или "speechkit"
-	pollInterval time.Duration
+	db                *sql.DB
+	speechKit         *speechkit.Client
+	mlClient          *mlclient.Client
+	whisperCpp        *whispercpp.Client
+	openAI            *openaistt.Client
+	s3Client          *storage.S3Client
+	uploadDir         string
+	chain             []string // провайдеры в порядке попыток: "whisper", "speechkit", "whispercpp", "openai", "mock"
+	keepOriginalVideo bool
+	preprocessing     media.PreprocessOptions
+	chunkConcurrency  int
+	speechKitLimiter  *rateLimiter
+	pollInterval      time.Duration
+
+	speechKitAPIVersion string
+	speechKitOptions    speechkit.RecognitionOptions
+	mlJobPollInterval   time.Duration
+
+	providers      []*providerMonitor
+	healthInterval time.Duration
+
+	mockDelay       time.Duration
+	mockFixturesDir string
+	mockFail        string
+	mockMu          sync.Mutex
+	mockAttempts    map[string]int // попытки задач для mockstt.Failure.Times
+
+	speakerIdentification bool
+	speakerThreshold      float64
 }
 
 // New создаёт worker.
-func New(db *sql.DB, provider string, apiKey string, folderId string, uploadDir string, mlServiceURL string, s3cfg *S3Config) *Worker {
+func New(db *sql.DB, cfg Config) *Worker {
 	var ml *mlclient.Client
-	if mlServiceURL != "" {
-		ml = mlclient.New(mlServiceURL)
+	if cfg.MLServiceURL != "" {
+		ml = mlclient.NewWithTimeouts(cfg.MLServiceURL, cfg.MLTimeouts)
 	}
 
+	chain := append([]string{cfg.Provider}, cfg.Fallback...)
+
 	var sk *speechkit.Client
-	if provider == "speechkit" {
-		sk = speechkit.NewClient(apiKey, folderId)
+	if containsProvider(chain, "speechkit") {
+		sk = speechkit.NewClientWithOptions(cfg.SpeechKitAPIKey, cfg.SpeechKitFolderID, speechkit.Options{
+			Endpoints:    cfg.SpeechKitEndpoints,
+			PollInterval: cfg.SpeechKitPollInterval,
+		})
+	}
+
+	var wcpp *whispercpp.Client
+	if containsProvider(chain, "whispercpp") {
+		wcpp = whispercpp.New(cfg.WhisperCpp)
+	}
+
+	var oai *openaistt.Client
+	if containsProvider(chain, "openai") {
+		oai = openaistt.New(cfg.OpenAI)
 	}
 
 	var s3c *storage.S3Client
-	if s3cfg != nil {
+	if cfg.S3 != nil {
 		var err error
-		s3c, err = storage.NewS3Client(s3cfg.AccessKey, s3cfg.SecretKey, s3cfg.Bucket)
+		s3c, err = storage.NewS3Client(cfg.S3.AccessKey, cfg.S3.SecretKey, cfg.S3.Bucket, cfg.S3.Endpoint)
 		if err != nil {
 			log.Printf("S3 client init failed, falling back to chunked mode: %v", err)
 		} else {
@@ -71,38 +181,161 @@ func New(db *sql.DB, provider string, apiKey string, folderId string, uploadDir
 		}
 	}
 
+	mlJobPollInterval := cfg.MLJobPollInterval
+	if mlJobPollInterval <= 0 {
+		mlJobPollInterval = 5 * time.Second
+	}
+
+	concurrency := cfg.SpeechKitConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	threshold := cfg.ProviderFailureThreshold
+	if threshold <= 0 {
+		threshold = 3
+	}
+	cooldown := cfg.ProviderCooldown
+	if cooldown <= 0 {
+		cooldown = time.Minute
+	}
+	healthInterval := cfg.HealthCheckInterval
+	if healthInterval <= 0 {
+		healthInterval = 30 * time.Second
+	}
+	speakerThreshold := cfg.SpeakerMatchThreshold
+	if speakerThreshold <= 0 {
+		speakerThreshold = voiceprint.DefaultThreshold
+	}
+
+	// Whisper без ML-сервиса не работает; для SpeechKit он нужен только для
+	// диаризации, поэтому его недоступность не останавливает очередь.
+	var providers []*providerMonitor
+	if ml != nil {
+		providers = append(providers, &providerMonitor{
+			name:     serviceML,
+			check:    ml.Health,
+			breaker:  newCircuitBreaker(threshold, cooldown),
+			required: containsProvider(chain, "whisper"),
+		})
+	}
+	if sk != nil {
+		providers = append(providers, &providerMonitor{
+			name:     serviceSpeechKit,
+			check:    sk.Health,
+			breaker:  newCircuitBreaker(threshold, cooldown),
+			required: true,
+		})
+	}
+	if wcpp != nil {
+		providers = append(providers, &providerMonitor{
+			name:     serviceWhisperCpp,
+			check:    wcpp.Check,
+			breaker:  newCircuitBreaker(threshold, cooldown),
+			required: true,
+		})
+	}
+	if oai != nil {
+		providers = append(providers, &providerMonitor{
+			name:     serviceOpenAI,
+			check:    oai.Health,
+			breaker:  newCircuitBreaker(threshold, cooldown),
+			required: true,
+		})
+	}
+
 	return &Worker{
-		db:           db,
-		speechKit:    sk,
-		mlClient:     ml,
-		s3Client:     s3c,
-		uploadDir:    uploadDir,
-		provider:     provider,
-		pollInterval: 2 * time.Second,
+		db:                db,
+		speechKit:         sk,
+		mlClient:          ml,
+		whisperCpp:        wcpp,
+		openAI:            oai,
+		s3Client:          s3c,
+		uploadDir:         cfg.UploadDir,
+		chain:             chain,
+		keepOriginalVideo: cfg.KeepOriginalVideo,
+		preprocessing:     cfg.Preprocessing,
+		chunkConcurrency:  concurrency,
+		speechKitLimiter:  newRateLimiter(cfg.SpeechKitRPS),
+		pollInterval:      2 * time.Second,
+
+		speechKitAPIVersion: cfg.SpeechKitAPIVersion,
+		speechKitOptions:    cfg.SpeechKitOptions,
+		mlJobPollInterval:   mlJobPollInterval,
+
+		providers:      providers,
+		healthInterval: healthInterval,
+
+		mockDelay:       cfg.MockDelay,
+		mockFixturesDir: cfg.MockFixturesDir,
+		mockFail:        cfg.MockFail,
+
+		speakerIdentification: cfg.SpeakerIdentification,
+		speakerThreshold:      speakerThreshold,
 	}
 }
 
+// Run обрабатывает очередь до закрытия stop. Закрытие stop отменяет текущее
+// ожидание SpeechKit; такая задача продолжится при следующем запуске.
 func (w *Worker) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	if err := w.recoverTasks(); err != nil {
+		log.Printf("worker error: failed to recover tasks: %v", err)
+	}
+
 	ticker := time.NewTicker(w.pollInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-stop:
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := w.processBatch(); err != nil {
+			if err := w.processBatch(ctx); err != nil {
 				log.Printf("worker error: %v", err)
 			}
 		}
 	}
 }
 
-func (w *Worker) processBatch() error {
+// recoverTasks возвращает в очередь задачи, прерванные остановкой worker'а.
+// Рассчитано на один экземпляр worker'а: чужие задачи «в процессе» тоже будут сброшены.
+func (w *Worker) recoverTasks() error {
+	res, err := w.db.Exec(
+		`UPDATE transcription_tasks SET status = 'ожидает' WHERE status = 'в процессе'`,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		log.Printf("requeued %d interrupted tasks", n)
+	}
+	return nil
+}
+
+func (w *Worker) processBatch(ctx context.Context) error {
+	if err := w.processExtractions(); err != nil {
+		log.Printf("audio extraction error: %v", err)
+	}
+
+	// Пока провайдер недоступен, задачи остаются в очереди, а не завершаются ошибкой
+	if !w.providersReady(ctx) {
+		return nil
+	}
+
 	rows, err := w.db.Query(
-		`SELECT t.id, f.storage_path
+		`SELECT t.id, f.storage_path, f.duration_ms, COALESCE(t.preprocessing, p.preprocessing),
+		        t.speechkit_operation_id, t.speechkit_api, t.speechkit_object_key, t.ml_job_id,
+		        f.project_id, p.glossary
 		 FROM transcription_tasks t
 		 JOIN files f ON f.id = t.file_id
+		 LEFT JOIN projects p ON p.id = f.project_id
 		 WHERE t.status = 'ожидает'
 		 ORDER BY t.created_at
 		 LIMIT 5`,
@@ -115,26 +348,167 @@ func (w *Worker) processBatch() error {
 	var tasks []TaskRow
 	for rows.Next() {
 		var t TaskRow
-		if err := rows.Scan(&t.ID, &t.StoragePath); err != nil {
+		var durationMs sql.NullInt64
+		var preprocessing, operationID, operationAPI, objectKey, mlJobID, projectID, vocabulary sql.NullString
+		if err := rows.Scan(&t.ID, &t.StoragePath, &durationMs, &preprocessing,
+			&operationID, &operationAPI, &objectKey, &mlJobID, &projectID, &vocabulary); err != nil {
 			return err
 		}
+		t.ProjectID = projectID.String
+		t.MLJobID = mlJobID.String
+		t.OperationID = operationID.String
+		t.OperationAPI = operationAPI.String
+		t.ObjectKey = objectKey.String
+		t.Duration = float64(durationMs.Int64) / 1000
+		t.Preprocessing = w.preprocessing
+		if preprocessing.Valid {
+			if err := json.Unmarshal([]byte(preprocessing.String), &t.Preprocessing); err != nil {
+				log.Printf("task %s: invalid preprocessing options, using defaults: %v", t.ID, err)
+				t.Preprocessing = w.preprocessing
+			}
+		}
+		if vocabulary.Valid {
+			if err := json.Unmarshal([]byte(vocabulary.String), &t.Glossary); err != nil {
+				log.Printf("task %s: invalid project glossary, ignoring: %v", t.ID, err)
+				t.Glossary = glossary.Glossary{}
+			}
+		}
 		tasks = append(tasks, t)
 	}
 
 	for _, task := range tasks {
-		if err := w.processTask(task); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := w.processTask(ctx, task); err != nil {
 			log.Printf("task %s failed: %v", task.ID, err)
 		}
 	}
 	return nil
 }
 
-func (w *Worker) processTask(task TaskRow) error {
+// extractionRow — видеофайл, из которого нужно извлечь аудиодорожку.
+type extractionRow struct {
+	TaskID      string
+	FileID      string
+	StoragePath string
+}
+
+// processExtractions извлекает аудио из загруженных видео.
+// После извлечения задача встаёт в обычную очередь распознавания.
+func (w *Worker) processExtractions() error {
+	rows, err := w.db.Query(
+		`SELECT t.id, f.id, f.storage_path
+		 FROM transcription_tasks t
+		 JOIN files f ON f.id = t.file_id
+		 WHERE t.status = 'извлечение аудио'
+		 ORDER BY t.created_at
+		 LIMIT 5`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var jobs []extractionRow
+	for rows.Next() {
+		var job extractionRow
+		if err := rows.Scan(&job.TaskID, &job.FileID, &job.StoragePath); err != nil {
+			return err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+
+	for _, job := range jobs {
+		if err := w.extractAudio(job); err != nil {
+			log.Printf("task %s: audio extraction failed: %v", job.TaskID, err)
+		}
+	}
+	return nil
+}
+
+func (w *Worker) extractAudio(job extractionRow) error {
+	log.Printf("task %s: extracting audio from video", job.TaskID)
+
+	audioPath, err := media.ExtractAudio(job.StoragePath, w.uploadDir)
+	if err != nil {
+		return w.failTask(job.TaskID, "Ошибка извлечения аудио: "+err.Error())
+	}
+	// Файл задачи теперь аудио: его параметры заменяют параметры видео
+	info, err := media.Probe(audioPath)
+	var stat os.FileInfo
+	if err == nil {
+		stat, err = os.Stat(audioPath)
+	}
+	if err != nil {
+		os.Remove(audioPath)
+		return w.failTask(job.TaskID, "Ошибка извлечения аудио: "+err.Error())
+	}
+
+	var originalPath interface{}
+	if w.keepOriginalVideo {
+		originalPath = job.StoragePath
+	}
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		os.Remove(audioPath)
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE transcription_tasks SET status = 'ожидает'
+		 WHERE id = ? AND status = 'извлечение аудио'`,
+		job.TaskID,
+	)
+	if err != nil {
+		os.Remove(audioPath)
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		// Задачу удалили или её уже обработал другой worker
+		os.Remove(audioPath)
+		return err
+	}
+
+	if err := updateExtractedFile(tx, job.FileID, audioPath, originalPath, stat.Size(), info); err != nil {
+		os.Remove(audioPath)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		os.Remove(audioPath)
+		return err
+	}
+
+	if !w.keepOriginalVideo {
+		os.Remove(job.StoragePath)
+	}
+	return nil
+}
+
+// updateExtractedFile переключает файл задачи на извлечённое аудио (OGG Opus)
+// и сохраняет его параметры вместо параметров видео.
+func updateExtractedFile(tx *sql.Tx, fileID, audioPath string, originalPath interface{}, size int64, info *media.MediaInfo) error {
+	_, err := tx.Exec(
+		`UPDATE files SET storage_path = ?, original_path = ?, file_size = ?, mime_type = 'audio/ogg',
+		                  duration_ms = ?, format_name = ?, audio_codec = ?, sample_rate = ?, channels = ?,
+		                  bit_rate = ?, has_video = 0
+		 WHERE id = ?`,
+		audioPath, originalPath, size, int64(info.Duration*1000), info.FormatName, info.AudioCodec,
+		info.SampleRate, info.Channels, info.BitRate, fileID,
+	)
+	return err
+}
+
+func (w *Worker) processTask(ctx context.Context, task TaskRow) error {
 	startTime := time.Now()
 	now := startTime.UTC()
 	res, err := w.db.Exec(
 		`UPDATE transcription_tasks
-		 SET status = 'в процессе', started_at = ?
+		 SET status = 'в процессе', started_at = ?, provider_attempts = NULL
 		 WHERE id = ? AND status = 'ожидает'`,
 		now, task.ID,
 	)
@@ -146,261 +520,501 @@ func (w *Worker) processTask(task TaskRow) error {
 		return nil
 	}
 
-	if w.provider == "whisper" {
-		return w.processTaskWhisper(task, startTime)
-	}
-	return w.processTaskSpeechKit(task, startTime)
+	return w.transcribe(ctx, task, startTime)
 }
 
-// processTaskWhisper — pipeline через Faster-Whisper (ML-сервис /transcribe-full).
-func (w *Worker) processTaskWhisper(task TaskRow, startTime time.Time) error {
+// processTaskWhisper — pipeline через Faster-Whisper (фоновая задача ML-сервиса).
+// ID задачи сохраняется, чтобы после обрыва связи или перезапуска worker'а
+// дождаться её результата, а не начинать распознавание заново.
+func (w *Worker) processTaskWhisper(ctx context.Context, task TaskRow, startTime time.Time) error {
 	inputPath := task.StoragePath
 
 	if w.mlClient == nil {
-		return w.failTask(task.ID, "ML-сервис не настроен для Whisper провайдера")
+		return &providerFailure{provider: "whisper", message: "ML-сервис не настроен для Whisper провайдера"}
 	}
 
-	log.Printf("task %s: starting Whisper transcription", task.ID)
+	var resp *mlclient.TranscribeFullResponse
+	var err error
+	jobID := task.MLJobID
+	if task.MLJobID != "" {
+		log.Printf("task %s: reattaching to ML job %s", task.ID, task.MLJobID)
+		resp, err = w.waitMLJob(ctx, task.ID, task.MLJobID)
+		if errors.Is(err, mlclient.ErrJobNotFound) {
+			log.Printf("task %s: ML job %s is gone, submitting again", task.ID, task.MLJobID)
+			task.MLJobID = ""
+		}
+	}
+
+	if task.MLJobID == "" {
+		if !task.Preprocessing.IsZero() {
+			log.Printf("task %s: preprocessing audio %+v", task.ID, task.Preprocessing)
+			processedPath, err := media.Preprocess(inputPath, w.uploadDir, task.Preprocessing)
+			if err != nil {
+				return &providerFailure{provider: "whisper", message: "Ошибка предобработки аудио: " + err.Error()}
+			}
+			defer os.Remove(processedPath)
+			inputPath = processedPath
+		}
+
+		log.Printf("task %s: starting Whisper transcription", task.ID)
 
-	resp, err := w.mlClient.TranscribeFull(inputPath, "", nil, true)
+		jobID, err = w.mlClient.SubmitTranscribeFull(ctx, inputPath, "", nil, true, task.Glossary.Prompt())
+		if err == nil {
+			if _, dbErr := w.db.Exec(
+				`UPDATE transcription_tasks SET ml_job_id = ?, progress = 0 WHERE id = ?`,
+				jobID, task.ID,
+			); dbErr != nil {
+				log.Printf("task %s: failed to save ML job id (non-fatal): %v", task.ID, dbErr)
+			}
+			resp, err = w.waitMLJob(ctx, task.ID, jobID)
+		}
+	}
+
+	if err != nil && ctx.Err() != nil {
+		log.Printf("task %s: interrupted by shutdown", task.ID)
+		return nil
+	}
+	if errors.Is(err, mlclient.ErrJobNotFound) {
+		// ML-сервис перезапустился во время распознавания: задача возвращается
+		// в очередь без ID задачи ML-сервиса и будет отправлена заново
+		if _, dbErr := w.db.Exec(
+			`UPDATE transcription_tasks SET ml_job_id = NULL WHERE id = ?`, task.ID,
+		); dbErr != nil {
+			log.Printf("task %s: failed to clear ML job id: %v", task.ID, dbErr)
+		}
+		return w.requeueTask(task.ID, serviceML, err)
+	}
+	if err != nil && mlclient.IsTransient(err) {
+		return w.requeueTask(task.ID, serviceML, err)
+	}
 	if err != nil {
-		return w.failTask(task.ID, "Ошибка транскрибации: "+err.Error())
+		if jobID != "" && !errors.Is(err, mlclient.ErrJobFailed) && !errors.Is(err, mlclient.ErrJobCancelled) {
+			// Задача ML-сервиса может ещё выполняться, а её результат уже не нужен
+			w.cancelMLJob(task.ID, jobID)
+		}
+		return &providerFailure{provider: "whisper", message: "Ошибка транскрибации: " + err.Error()}
 	}
+	w.recordProviderSuccess(serviceML)
 
 	log.Printf("task %s: transcription done — %d segments, %d speakers, lang=%s (%.1fs)",
 		task.ID, len(resp.Segments), resp.NumSpeakers, resp.Language, resp.ProcessingTimeSeconds)
 
-	// Сохраняем данные о спикерах
-	speakerJSON, _ := json.Marshal(resp)
-	w.db.Exec(
-		`UPDATE transcription_tasks SET speaker_data = ? WHERE id = ?`,
-		string(speakerJSON), task.ID,
-	)
-
-	// Сохраняем сегменты с точным word-level alignment
-	now := time.Now().UTC()
-	for _, seg := range resp.Segments {
-		segID := uuid.New().String()
-		startMs := int(seg.Start * 1000)
-		endMs := int(seg.End * 1000)
+	// transcribe-full отмечает паразитов, но не возвращает текст без них
+	w.detectFillers(ctx, task.ID, resp.Segments)
+
+	// Данные о спикерах и сегменты с точным word-level alignment
+	response := newResponse(responseTranscription, 0, 0, 0, resp)
+	return w.finishTask(ctx, task, startTime, transcriptResult{
+		Provider:    "faster_whisper",
+		Text:        resp.FullText,
+		SpeakerData: response.Body,
+		Segments:    resp.Segments,
+		Responses:   []providerResponse{response},
+	})
+}
 
-		w.db.Exec(
-			`INSERT INTO transcription_segments
-			 (id, task_id, speaker_id, start_time, end_time, text, has_fillers, created_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			segID, task.ID, seg.Speaker, startMs, endMs, seg.Text, seg.HasFillers, now,
-		)
+// cancelMLJob отменяет задачу ML-сервиса, чтобы она не занимала GPU.
+// Ошибки только логируются: задачу ML-сервис со временем удалит сам.
+func (w *Worker) cancelMLJob(taskID, jobID string) {
+	err := w.mlClient.CancelJob(context.Background(), jobID)
+	if err != nil && !errors.Is(err, mlclient.ErrJobNotFound) {
+		log.Printf("task %s: failed to cancel ML job %s: %v", taskID, jobID, err)
 	}
+}
 
-	processingTime := int(time.Since(startTime).Seconds())
-
-	_, err = w.db.Exec(
-		`UPDATE transcription_tasks
-		 SET status = 'готово', transcript_text = ?, provider = 'faster_whisper',
-		     processing_time = ?, completed_at = ?
-		 WHERE id = ?`,
-		resp.FullText, processingTime, time.Now().UTC(), task.ID,
-	)
-	return err
+// waitMLJob дожидается задачи ML-сервиса, сохраняя прогресс в задаче.
+func (w *Worker) waitMLJob(ctx context.Context, taskID, jobID string) (*mlclient.TranscribeFullResponse, error) {
+	lastProgress := -1
+	return w.mlClient.WaitJob(ctx, jobID, w.mlJobPollInterval, func(status *mlclient.JobStatus) {
+		progress := int(status.Progress * 100)
+		if progress == lastProgress {
+			return
+		}
+		lastProgress = progress
+		if _, err := w.db.Exec(
+			`UPDATE transcription_tasks SET progress = ? WHERE id = ?`,
+			progress, taskID,
+		); err != nil {
+			log.Printf("task %s: failed to save progress: %v", taskID, err)
+		}
+	})
 }
 
 // processTaskSpeechKit — pipeline через Yandex SpeechKit (legacy fallback).
-func (w *Worker) processTaskSpeechKit(task TaskRow, startTime time.Time) error {
+func (w *Worker) processTaskSpeechKit(ctx context.Context, task TaskRow, startTime time.Time) error {
 	inputPath := task.StoragePath
 
-	// Определяем длительность аудио
-	duration, err := media.GetDuration(inputPath)
-	if err != nil {
-		log.Printf("task %s: failed to get duration, using async mode: %v", task.ID, err)
-		duration = maxSyncDuration + 1
+	if w.speechKit == nil {
+		return &providerFailure{provider: "speechkit", message: "SpeechKit не настроен"}
+	}
+
+	// Длительность известна с момента загрузки; для старых файлов определяем заново
+	duration := task.Duration
+	if duration <= 0 {
+		var err error
+		duration, err = media.GetDuration(inputPath)
+		if err != nil {
+			log.Printf("task %s: failed to get duration, using async mode: %v", task.ID, err)
+			duration = maxSyncDuration + 1
+		}
 	}
 
-	// Конвертируем аудио в OGG Opus для SpeechKit
-	oggPath, err := media.ExtractAudio(inputPath, w.uploadDir)
+	// Конвертируем аудио в OGG Opus для SpeechKit, применяя предобработку
+	oggPath, err := media.Preprocess(inputPath, w.uploadDir, task.Preprocessing)
 	if err != nil {
-		return w.failTask(task.ID, "Ошибка конвертации аудио: "+err.Error())
+		return &providerFailure{provider: "speechkit", message: "Ошибка конвертации аудио: " + err.Error()}
 	}
 	defer os.Remove(oggPath)
 
 	// Транскрибация через SpeechKit
-	var text string
+	var parts []speechKitPart
 	if duration <= maxSyncDuration {
-		text, err = w.speechKit.RecognizeFile(oggPath, "ru-RU")
+		var text string
+		text, err = w.speechKit.RecognizeFileContext(ctx, oggPath, "ru-RU")
+		parts = []speechKitPart{{Start: 0, End: duration, Result: &speechkit.Result{Text: text}}}
 	} else if w.s3Client != nil {
-		text, err = w.recognizeLongAudioAsync(task.ID, oggPath)
+		parts, err = w.recognizeLongAudioAsync(ctx, task, oggPath)
 	} else {
-		text, err = w.recognizeLongAudio(task.ID, oggPath)
+		parts, err = w.recognizeLongAudio(ctx, task.ID, oggPath)
 	}
 
+	if err != nil && ctx.Err() != nil {
+		// Worker останавливается: задача останется «в процессе» и продолжится после перезапуска
+		log.Printf("task %s: interrupted by shutdown", task.ID)
+		return nil
+	}
+	if err != nil && speechkit.IsTransient(err) {
+		return w.requeueTask(task.ID, serviceSpeechKit, err)
+	}
 	if err != nil {
-		return w.failTask(task.ID, "Ошибка распознавания: "+err.Error())
+		return &providerFailure{provider: "speechkit", message: "Ошибка распознавания: " + err.Error()}
 	}
+	w.recordProviderSuccess(serviceSpeechKit)
+
+	pieces := speechKitPieces(parts)
+	segments, diarization := w.buildTimedSegments(ctx, task.ID, oggPath, pieces)
+	return w.finishTask(ctx, task, startTime, timedResult(
+		"yandex_speechkit", joinTimedText(pieces), segments, diarization, speechKitResponses(parts),
+	))
+}
 
-	// Диаризация через ML-сервис (если доступен)
-	if w.mlClient != nil {
-		w.diarizeAndSaveSegments(task.ID, oggPath, text)
+// buildTimedSegments строит сегменты по результату SpeechKit, whisper.cpp
+// или OpenAI-совместимого API. Если ML-сервис доступен, слова распределяются
+// по репликам диаризации по таймкодам, а результат диаризации возвращается
+// для speaker_data; иначе каждая часть распознавания становится сегментом без спикера.
+func (w *Worker) buildTimedSegments(ctx context.Context, taskID, audioPath string, pieces []timedText) ([]mlclient.TranscribeSegment, *mlclient.DiarizationResponse) {
+	var words []mlclient.WordTimestamp
+	for _, piece := range pieces {
+		words = append(words, piece.Words...)
 	}
 
-	processingTime := int(time.Since(startTime).Seconds())
+	segments := piecesToSegments(pieces)
+	var diarization *mlclient.DiarizationResponse
+	if w.mlClient != nil && len(words) > 0 {
+		log.Printf("task %s: starting diarization", taskID)
 
-	_, err = w.db.Exec(
-		`UPDATE transcription_tasks
-		 SET status = 'готово', transcript_text = ?, provider = 'yandex_speechkit',
-		     processing_time = ?, completed_at = ?
-		 WHERE id = ?`,
-		text, processingTime, time.Now().UTC(), task.ID,
-	)
-	return err
+		var err error
+		diarization, err = w.mlClient.Diarize(ctx, audioPath)
+		if err != nil {
+			log.Printf("task %s: diarization failed (non-fatal): %v", taskID, err)
+			diarization = nil
+		} else {
+			log.Printf("task %s: diarization found %d speakers, %d segments",
+				taskID, diarization.NumSpeakers, len(diarization.Segments))
+
+			if len(diarization.Segments) > 0 {
+				segments = alignWords(words, diarization.Segments)
+			}
+		}
+	}
+
+	w.detectFillers(ctx, taskID, segments)
+	return segments, diarization
 }
 
-// diarizeAndSaveSegments выполняет диаризацию и сохраняет сегменты (для SpeechKit pipeline).
-func (w *Worker) diarizeAndSaveSegments(taskID, audioPath, transcriptText string) {
-	log.Printf("task %s: starting diarization", taskID)
+// piecesToSegments превращает части распознавания в сегменты без спикера.
+func piecesToSegments(pieces []timedText) []mlclient.TranscribeSegment {
+	segments := make([]mlclient.TranscribeSegment, 0, len(pieces))
+	for _, piece := range pieces {
+		segments = append(segments, mlclient.TranscribeSegment{
+			Start: piece.Start,
+			End:   piece.End,
+			Text:  piece.Text,
+			Words: piece.Words,
+		})
+	}
+	return segments
+}
 
-	diarization, err := w.mlClient.Diarize(audioPath)
-	if err != nil {
-		log.Printf("task %s: diarization failed (non-fatal): %v", taskID, err)
-		w.saveSingleSegment(taskID, transcriptText)
+// detectFillers отмечает слова-паразиты в каждом сегменте и получает текст
+// без них одним запросом к ML-сервису.
+func (w *Worker) detectFillers(ctx context.Context, taskID string, segments []mlclient.TranscribeSegment) {
+	if w.mlClient == nil || len(segments) == 0 {
 		return
 	}
 
-	log.Printf("task %s: diarization found %d speakers, %d segments",
-		taskID, diarization.NumSpeakers, len(diarization.Segments))
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
 
-	speakerJSON, _ := json.Marshal(diarization)
-	w.db.Exec(
-		`UPDATE transcription_tasks SET speaker_data = ? WHERE id = ?`,
-		string(speakerJSON), taskID,
-	)
+	resp, err := w.mlClient.ProcessSegments(ctx, texts, true, true)
+	if err != nil {
+		log.Printf("task %s: text processing failed (non-fatal): %v", taskID, err)
+		return
+	}
+	for i := range segments {
+		segments[i].HasFillers = resp.Segments[i].HasFillers
+		segments[i].FillersFound = resp.Segments[i].FillersFound
+		cleaned := resp.Segments[i].CleanedText
+		segments[i].CleanedText = &cleaned
+	}
+}
 
-	var textProcessed *mlclient.TextProcessResponse
-	if w.mlClient != nil {
-		textProcessed, err = w.mlClient.ProcessText(transcriptText, true, false)
-		if err != nil {
-			log.Printf("task %s: text processing failed (non-fatal): %v", taskID, err)
+// recognizeLongAudioAsync загружает файл в S3 и использует async SpeechKit API.
+// Результат — одна часть с фразами и реальными таймкодами слов.
+// ID операции сохраняется в задаче: после перезапуска или временной ошибки
+// опроса продолжается без повторной загрузки и оплаты распознавания.
+func (w *Worker) recognizeLongAudioAsync(ctx context.Context, task TaskRow, oggPath string) ([]speechKitPart, error) {
+	if task.OperationID != "" {
+		log.Printf("task %s: resuming SpeechKit operation %s", task.ID, task.OperationID)
+
+		result, err := w.waitRecognition(ctx, task.OperationAPI, task.OperationID)
+		if !errors.Is(err, speechkit.ErrOperationNotFound) {
+			if !keepOperation(ctx, err) {
+				w.finishOperation(task.ID, task.ObjectKey)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("async recognition failed: %w", err)
+			}
+			return []speechKitPart{{Result: result}}, nil
 		}
-	}
 
-	now := time.Now().UTC()
-	words := strings.Fields(transcriptText)
-	totalWords := len(words)
-	numSegments := len(diarization.Segments)
+		log.Printf("task %s: operation %s expired, starting recognition again", task.ID, task.OperationID)
+		w.finishOperation(task.ID, task.ObjectKey)
+	}
 
-	for i, seg := range diarization.Segments {
-		segID := uuid.New().String()
-		startMs := int(seg.Start * 1000)
-		endMs := int(seg.End * 1000)
+	log.Printf("task %s: uploading to S3 for async recognition", task.ID)
 
-		// Распределяем слова пропорционально по сегментам
-		segStart := i * totalWords / numSegments
-		segEnd := (i + 1) * totalWords / numSegments
-		if segEnd > totalWords {
-			segEnd = totalWords
-		}
-		segText := ""
-		if segStart < segEnd {
-			segText = strings.Join(words[segStart:segEnd], " ")
-		}
+	key := storage.GenerateKey("audio", fmt.Sprintf("%s_%s", task.ID, filepath.Base(oggPath)))
 
-		hasFillers := false
-		if textProcessed != nil && len(textProcessed.Segments) > 0 {
-			hasFillers = textProcessed.Segments[0].HasFillers
-		}
+	s3URI, err := w.s3Client.Upload(ctx, oggPath, key)
+	if err != nil {
+		return nil, fmt.Errorf("S3 upload failed: %w", err)
+	}
 
-		w.db.Exec(
-			`INSERT INTO transcription_segments
-			 (id, task_id, speaker_id, start_time, end_time, text, has_fillers, created_at)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			segID, taskID, seg.Speaker, startMs, endMs, segText, hasFillers, now,
-		)
+	api := "v2"
+	if w.speechKitAPIVersion == "v3" {
+		api = "v3"
 	}
-}
 
-// saveSingleSegment сохраняет весь текст как один сегмент (fallback без диаризации).
-func (w *Worker) saveSingleSegment(taskID, text string) {
-	now := time.Now().UTC()
-	segID := uuid.New().String()
+	log.Printf("task %s: starting async %s recognition (URI: %s)", task.ID, api, s3URI)
 
-	hasFillers := false
-	if w.mlClient != nil {
-		resp, err := w.mlClient.ProcessText(text, true, false)
-		if err == nil && resp.TotalFillers > 0 {
-			hasFillers = true
-		}
+	var opID string
+	if api == "v3" {
+		opID, err = w.speechKit.StartFileAsync(ctx, s3URI, w.speechKitOptions)
+	} else {
+		opID, err = w.speechKit.StartLongAudio(ctx, s3URI, w.speechKitOptions)
+	}
+	if err != nil {
+		w.finishOperation(task.ID, key)
+		return nil, fmt.Errorf("async recognition failed: %w", err)
 	}
 
-	w.db.Exec(
-		`INSERT INTO transcription_segments
-		 (id, task_id, start_time, end_time, text, has_fillers, created_at)
-		 VALUES (?, ?, 0, 0, ?, ?, ?)`,
-		segID, taskID, text, hasFillers, now,
-	)
+	if _, err := w.db.Exec(
+		`UPDATE transcription_tasks
+		 SET speechkit_operation_id = ?, speechkit_api = ?, speechkit_object_key = ?
+		 WHERE id = ?`,
+		opID, api, key, task.ID,
+	); err != nil {
+		log.Printf("task %s: failed to save operation id (non-fatal): %v", task.ID, err)
+	}
+
+	result, err := w.waitRecognition(ctx, api, opID)
+	if !keepOperation(ctx, err) {
+		w.finishOperation(task.ID, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("async recognition failed: %w", err)
+	}
+	return []speechKitPart{{Result: result}}, nil
 }
 
-// recognizeLongAudioAsync загружает файл в S3 и использует async SpeechKit API.
-func (w *Worker) recognizeLongAudioAsync(taskID, oggPath string) (string, error) {
-	log.Printf("task %s: uploading to S3 for async recognition", taskID)
+// keepOperation сообщает, что операцию нужно сохранить: worker останавливается
+// или опрос не удался временно. Задача вернётся в очередь и продолжит ожидание
+// той же операции. Завершённая, упавшая или просроченная операция забывается.
+func keepOperation(ctx context.Context, err error) bool {
+	return ctx.Err() != nil || speechkit.IsTransient(err)
+}
 
-	key := storage.GenerateKey("audio", fmt.Sprintf("%s_%s", taskID, filepath.Base(oggPath)))
-	ctx := context.Background()
+func (w *Worker) waitRecognition(ctx context.Context, api, opID string) (*speechkit.Result, error) {
+	if api == "v3" {
+		return w.speechKit.WaitFileAsync(ctx, opID)
+	}
+	return w.speechKit.WaitLongAudio(ctx, opID)
+}
 
-	s3URI, err := w.s3Client.Upload(ctx, oggPath, key)
-	if err != nil {
-		return "", fmt.Errorf("S3 upload failed: %w", err)
+// finishOperation удаляет загруженный файл и забывает операцию задачи.
+func (w *Worker) finishOperation(taskID, key string) {
+	if key != "" {
+		if err := w.s3Client.Delete(context.Background(), key); err != nil {
+			log.Printf("task %s: failed to delete S3 object: %v", taskID, err)
+		}
 	}
+	if _, err := w.db.Exec(
+		`UPDATE transcription_tasks
+		 SET speechkit_operation_id = NULL, speechkit_api = NULL, speechkit_object_key = NULL
+		 WHERE id = ?`,
+		taskID,
+	); err != nil {
+		log.Printf("task %s: failed to clear operation id: %v", taskID, err)
+	}
+}
 
-	defer func() {
-		if delErr := w.s3Client.Delete(ctx, key); delErr != nil {
-			log.Printf("task %s: failed to delete S3 object: %v", taskID, delErr)
+// resultToPieces превращает фразы асинхронного распознавания в части транскрипта.
+func resultToPieces(result *speechkit.Result) []timedText {
+	pieces := make([]timedText, 0, len(result.Utterances))
+	for _, utt := range result.Utterances {
+		piece := timedText{Start: utt.Start, End: utt.End, Text: utt.Text}
+		for _, word := range utt.Words {
+			piece.Words = append(piece.Words, mlclient.WordTimestamp{Word: word.Text, Start: word.Start, End: word.End})
 		}
-	}()
+		if len(piece.Words) == 0 {
+			piece.Words = estimateWordTimings(piece.Text, piece.Start, piece.End)
+		}
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
 
-	log.Printf("task %s: starting async recognition (URI: %s)", taskID, s3URI)
+// timedText — распознанный текст части аудио с границами в секундах.
+// Words — таймкоды слов: точные от API или оценённые по длине слов.
+type timedText struct {
+	Start float64
+	End   float64
+	Text  string
+	Words []mlclient.WordTimestamp
+}
 
-	text, err := w.speechKit.RecognizeLongAudio(s3URI, "ru-RU")
+// recognizeLongAudio режет аудио по паузам на части до 30 секунд
+// и распознаёт их синхронным API, сохраняя смещение каждой части.
+func (w *Worker) recognizeLongAudio(ctx context.Context, taskID, inputPath string) ([]speechKitPart, error) {
+	duration, err := media.GetDuration(inputPath)
 	if err != nil {
-		return "", fmt.Errorf("async recognition failed: %w", err)
+		return nil, err
 	}
 
-	return text, nil
-}
+	silences, err := media.DetectSilences(inputPath, silenceThresholdDB, minSilenceDuration)
+	if err != nil {
+		log.Printf("task %s: silence detection failed, using fixed chunks: %v", taskID, err)
+	}
+	chunks := media.PlanChunks(duration, silences, maxChunkDuration)
 
-func (w *Worker) recognizeLongAudio(taskID, inputPath string) (string, error) {
-	log.Printf("task %s: splitting long audio into chunks", taskID)
+	log.Printf("task %s: splitting long audio into %d chunks (%d pauses found)", taskID, len(chunks), len(silences))
 
-	chunks, err := media.SplitAudio(inputPath, w.uploadDir, chunkDuration)
+	paths, err := media.SplitAudio(inputPath, w.uploadDir, chunks)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	defer func() {
-		for _, chunk := range chunks {
-			os.Remove(chunk)
+		for _, path := range paths {
+			os.Remove(path)
 		}
 	}()
+	if len(paths) == len(chunks)-1 && len(paths) > 0 {
+		// Аудиопоток кончился раньше длительности контейнера: последней части нет
+		log.Printf("task %s: last chunk %.3f-%.3f not produced, skipping it",
+			taskID, chunks[len(paths)].Start, chunks[len(paths)].End)
+		chunks = chunks[:len(paths)]
+	}
+	if len(paths) < len(chunks) {
+		return nil, fmt.Errorf("split produced %d of %d chunks", len(paths), len(chunks))
+	}
 
-	log.Printf("task %s: processing %d chunks", taskID, len(chunks))
+	return w.recognizeChunks(ctx, taskID, paths, chunks)
+}
 
-	var results []string
-	for i, chunk := range chunks {
-		log.Printf("task %s: recognizing chunk %d/%d", taskID, i+1, len(chunks))
-		text, err := w.speechKit.RecognizeFile(chunk, "ru-RU")
-		if err != nil {
-			return "", err
+// recognizeChunks распознаёт готовые части параллельно (не больше chunkConcurrency)
+// с общим лимитом запросов. Первая ошибка отменяет оставшиеся части.
+func (w *Worker) recognizeChunks(parent context.Context, taskID string, paths []string, chunks []media.Chunk) ([]speechKitPart, error) {
+	log.Printf("task %s: recognizing %d chunks (concurrency %d)", taskID, len(paths), w.chunkConcurrency)
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	texts := make([]string, len(paths))
+	errs := make([]error, len(paths))
+	sem := make(chan struct{}, w.chunkConcurrency)
+	var wg sync.WaitGroup
+
+	for i, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		i, path := i, path
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := w.speechKitLimiter.Wait(ctx); err != nil {
+				errs[i] = err
+				return
+			}
+			text, err := w.speechKit.RecognizeFileContext(ctx, path, "ru-RU")
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %d/%d: %w", i+1, len(paths), err)
+				cancel()
+				return
+			}
+			texts[i] = text
+		}()
+	}
+	wg.Wait()
+
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		// Ошибки отмены вторичны — возвращаем исходную
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, err
 		}
-		if text != "" {
-			results = append(results, text)
+	}
+
+	parts := make([]speechKitPart, len(texts))
+	for i, text := range texts {
+		parts[i] = speechKitPart{
+			Index:  i,
+			Start:  chunks[i].Start,
+			End:    chunks[i].End,
+			Result: &speechkit.Result{Text: text},
 		}
 	}
 
-	return strings.Join(results, " "), nil
+	return parts, nil
+}
+
+// joinTimedText склеивает тексты частей в полный транскрипт.
+func joinTimedText(pieces []timedText) string {
+	texts := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		texts = append(texts, piece.Text)
+	}
+	return strings.Join(texts, " ")
 }
 
 func (w *Worker) failTask(taskID string, errMsg string) error {
 	log.Printf("task %s error: %s", taskID, errMsg)
+	// error_message — VARCHAR(512): длинный текст ошибки не должен сорвать сам UPDATE
+	if runes := []rune(errMsg); len(runes) > maxErrorMessageLen {
+		errMsg = string(runes[:maxErrorMessageLen-1]) + "…"
+	}
 	_, err := w.db.Exec(
 		`UPDATE transcription_tasks
-		 SET status = 'ошибка', error_message = ?, completed_at = ?
+		 SET status = 'ошибка', error_message = ?, completed_at = ?, ml_job_id = NULL
 		 WHERE id = ?`,
 		errMsg, time.Now().UTC(), taskID,
 	)
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"loopa/backend/internal/media"
+)
+
+// newTestWorker создаёт Worker поверх sqlmock. Не заданные в cfg каталог
+// загрузок и интервалы опроса ML-сервиса и SpeechKit заменяются временным
+// каталогом и миллисекундой, чтобы тесты не ждали.
+func newTestWorker(t *testing.T, cfg Config) (*Worker, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	if cfg.UploadDir == "" {
+		cfg.UploadDir = t.TempDir()
+	}
+	if cfg.MLJobPollInterval == 0 {
+		cfg.MLJobPollInterval = time.Millisecond
+	}
+	if cfg.SpeechKitPollInterval == 0 {
+		cfg.SpeechKitPollInterval = time.Millisecond
+	}
+	return New(db, cfg), mock
+}
+
+func TestUpdateExtractedFile_ReplacesVideoMetadata(t *testing.T) {
+	w, mock := newTestWorker(t, Config{})
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files SET storage_path = \\?, original_path = \\?, file_size = \\?, mime_type = 'audio/ogg'").
+		WithArgs("/uploads/a.ogg", nil, int64(48000), int64(12500), "ogg", "opus", 48000, 1, int64(64000), "file-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := w.db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, updateExtractedFile(tx, "file-1", "/uploads/a.ogg", nil, 48000, &media.MediaInfo{
+		FormatName: "ogg", Duration: 12.5, BitRate: 64000, AudioCodec: "opus", SampleRate: 48000, Channels: 1, HasAudio: true,
+	}))
+	require.NoError(t, tx.Commit())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,6 @@
+-- Извлечение аудио из видео выполняется воркером отдельной стадией
+ALTER TABLE transcription_tasks MODIFY COLUMN status
+  ENUM('извлечение аудио','ожидает','в процессе','готово','ошибка') NOT NULL DEFAULT 'ожидает';
+
+-- Исходное видео, сохранённое для последующего воспроизведения (NULL — не сохранялось)
+ALTER TABLE files ADD COLUMN original_path VARCHAR(512) NULL AFTER storage_path;
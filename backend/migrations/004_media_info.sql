@@ -0,0 +1,8 @@
+-- Характеристики медиафайла (ffprobe) — для показа длительности и тарификации по минутам
+ALTER TABLE files ADD COLUMN duration_ms BIGINT NULL AFTER mime_type;
+ALTER TABLE files ADD COLUMN format_name VARCHAR(128) NULL AFTER duration_ms;
+ALTER TABLE files ADD COLUMN audio_codec VARCHAR(64) NULL AFTER format_name;
+ALTER TABLE files ADD COLUMN sample_rate INT NULL AFTER audio_codec;
+ALTER TABLE files ADD COLUMN channels SMALLINT NULL AFTER sample_rate;
+ALTER TABLE files ADD COLUMN bit_rate BIGINT NULL AFTER channels;
+ALTER TABLE files ADD COLUMN has_video TINYINT(1) NOT NULL DEFAULT 0 AFTER bit_rate;
@@ -0,0 +1,4 @@
+-- Настройки предобработки аудио (media.PreprocessOptions в JSON).
+-- Настройка задачи приоритетнее настройки проекта.
+ALTER TABLE projects ADD COLUMN preprocessing JSON NULL AFTER status;
+ALTER TABLE transcription_tasks ADD COLUMN preprocessing JSON NULL AFTER language;
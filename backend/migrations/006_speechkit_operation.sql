@@ -0,0 +1,6 @@
+-- Операция асинхронного распознавания SpeechKit, запущенная для задачи.
+-- После перезапуска worker продолжает опрос вместо повторной (платной) отправки файла.
+ALTER TABLE transcription_tasks
+  ADD COLUMN speechkit_operation_id VARCHAR(64) NULL AFTER provider,
+  ADD COLUMN speechkit_api VARCHAR(8) NULL AFTER speechkit_operation_id,
+  ADD COLUMN speechkit_object_key VARCHAR(512) NULL AFTER speechkit_api;
@@ -0,0 +1,5 @@
+-- Фоновая задача ML-сервиса (Whisper) и прогресс её выполнения в процентах.
+-- После перезапуска worker снова подключается к задаче по ml_job_id.
+ALTER TABLE transcription_tasks
+  ADD COLUMN ml_job_id VARCHAR(64) NULL AFTER speechkit_object_key,
+  ADD COLUMN progress TINYINT UNSIGNED NULL AFTER ml_job_id;
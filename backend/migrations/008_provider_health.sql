@@ -0,0 +1,10 @@
+-- Доступность внешних провайдеров распознавания по проверкам worker'а.
+-- Читается эндпоинтом /api/health.
+CREATE TABLE IF NOT EXISTS provider_health (
+  provider VARCHAR(32) PRIMARY KEY,
+  status VARCHAR(16) NOT NULL,
+  consecutive_failures INT NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+  checked_at DATETIME NOT NULL,
+  last_success_at DATETIME NULL
+);
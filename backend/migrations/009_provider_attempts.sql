@@ -0,0 +1,3 @@
+-- Неудачные попытки провайдеров из цепочки fallback: [{"provider", "error"}].
+-- Провайдер, давший итоговый результат, хранится в provider.
+ALTER TABLE transcription_tasks ADD COLUMN provider_attempts JSON NULL AFTER provider;
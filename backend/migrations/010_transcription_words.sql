@@ -0,0 +1,14 @@
+-- Слова сегментов с таймкодами (word-level alignment).
+CREATE TABLE IF NOT EXISTS transcription_words (
+  segment_id CHAR(36) NOT NULL,
+  word_index INT NOT NULL,
+  task_id CHAR(36) NOT NULL,
+  word VARCHAR(255) NOT NULL,
+  start_time INT NOT NULL COMMENT 'начало в миллисекундах',
+  end_time INT NOT NULL COMMENT 'конец в миллисекундах',
+  PRIMARY KEY (segment_id, word_index),
+  INDEX idx_words_task (task_id),
+  CONSTRAINT fk_words_segment
+    FOREIGN KEY (segment_id) REFERENCES transcription_segments(id)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+-- Ответы провайдеров распознавания до выравнивания по спикерам: по ним
+-- сегменты перестраиваются без повторного распознавания (admin rebuild-segments).
+-- Для SpeechKit по частям — строка на каждую часть; диаризация — kind = 'diarization'.
+-- Заменяет transcription_tasks.raw_response, где помещался бы только один ответ.
+CREATE TABLE IF NOT EXISTS provider_responses (
+  id CHAR(36) PRIMARY KEY,
+  task_id CHAR(36) NOT NULL,
+  provider VARCHAR(64) NOT NULL,
+  kind VARCHAR(32) NOT NULL,
+  chunk_index INT NOT NULL DEFAULT 0,
+  start_time INT NOT NULL DEFAULT 0 COMMENT 'начало части в миллисекундах',
+  end_time INT NOT NULL DEFAULT 0 COMMENT 'конец части в миллисекундах',
+  response LONGTEXT NOT NULL,
+  created_at DATETIME NOT NULL,
+  INDEX idx_responses_task (task_id, chunk_index),
+  CONSTRAINT fk_responses_task
+    FOREIGN KEY (task_id) REFERENCES transcription_tasks(id)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- Текст сегмента без слов-паразитов (от ML-сервиса); NULL — не определён
+-- или сегмент исправлен вручную, тогда при экспорте берётся text.
+ALTER TABLE transcription_segments ADD COLUMN cleaned_text TEXT NULL AFTER text;
+
+-- Найденные слова-паразиты сегмента с числом повторов.
+CREATE TABLE IF NOT EXISTS segment_fillers (
+  segment_id CHAR(36) NOT NULL,
+  filler VARCHAR(64) NOT NULL,
+  task_id CHAR(36) NOT NULL,
+  occurrences INT NOT NULL,
+  PRIMARY KEY (segment_id, filler),
+  INDEX idx_fillers_task (task_id),
+  CONSTRAINT fk_fillers_segment
+    FOREIGN KEY (segment_id) REFERENCES transcription_segments(id)
+    ON DELETE CASCADE
+);
@@ -0,0 +1,34 @@
+-- Голосовые профили спикеров проекта: worker сравнивает с ними спикеров
+-- новых записей и подставляет имя. embedding — JSON-массив, среднее
+-- L2-нормированных эмбеддингов samples записей.
+CREATE TABLE IF NOT EXISTS speaker_profiles (
+  id CHAR(36) PRIMARY KEY,
+  project_id CHAR(36) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  embedding MEDIUMTEXT NOT NULL,
+  samples INT NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL,
+  UNIQUE KEY uq_profiles_project_name (project_id, name),
+  CONSTRAINT fk_profiles_project
+    FOREIGN KEY (project_id) REFERENCES projects(id)
+    ON DELETE CASCADE
+);
+
+-- Эмбеддинги спикеров задачи и найденный профиль. По эмбеддингу профиль
+-- создаётся или уточняется, когда пользователь называет спикера.
+CREATE TABLE IF NOT EXISTS task_speakers (
+  task_id CHAR(36) NOT NULL,
+  speaker_id VARCHAR(50) NOT NULL,
+  embedding MEDIUMTEXT NOT NULL,
+  duration_ms INT NOT NULL DEFAULT 0,
+  profile_id CHAR(36) NULL,
+  similarity DOUBLE NULL COMMENT 'косинусная близость к профилю',
+  PRIMARY KEY (task_id, speaker_id),
+  CONSTRAINT fk_task_speakers_task
+    FOREIGN KEY (task_id) REFERENCES transcription_tasks(id)
+    ON DELETE CASCADE,
+  CONSTRAINT fk_task_speakers_profile
+    FOREIGN KEY (profile_id) REFERENCES speaker_profiles(id)
+    ON DELETE SET NULL
+);
@@ -0,0 +1,20 @@
+-- Справочник спикеров проекта: профиль — человек проекта, task_speakers —
+-- какой спикер задачи им является. Человека можно назвать и без записи голоса,
+-- поэтому эмбеддинги необязательны.
+ALTER TABLE speaker_profiles
+  MODIFY embedding MEDIUMTEXT NULL,
+  MODIFY samples INT NOT NULL DEFAULT 0;
+ALTER TABLE task_speakers MODIFY embedding MEDIUMTEXT NULL;
+
+-- Объединённые спикеры задачи: сегменты from_speaker отнесены к into_speaker.
+-- Применяются заново при перестроении сегментов (admin rebuild-segments).
+CREATE TABLE IF NOT EXISTS speaker_merges (
+  task_id CHAR(36) NOT NULL,
+  from_speaker VARCHAR(50) NOT NULL,
+  into_speaker VARCHAR(50) NOT NULL,
+  created_at DATETIME NOT NULL,
+  PRIMARY KEY (task_id, from_speaker),
+  CONSTRAINT fk_speaker_merges_task
+    FOREIGN KEY (task_id) REFERENCES transcription_tasks(id)
+    ON DELETE CASCADE
+);
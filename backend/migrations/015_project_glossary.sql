@@ -0,0 +1,3 @@
+-- Словарь проекта (glossary.Glossary в JSON): термины для подсказки провайдерам
+-- и замены в тексте сегментов новых задач.
+ALTER TABLE projects ADD COLUMN glossary JSON NULL AFTER preprocessing;
@@ -1,4 +1,4 @@
-import type { Segment, Project } from "./types";
+import type { Segment, Project, Glossary } from "./types";
 
 const API_BASE = import.meta.env.VITE_API_URL ?? "http://localhost:8080/api";
 
@@ -12,6 +12,89 @@ export type TaskResponse = {
   completedAt?: string;
   segments?: Segment[];
   numSpeakers?: number;
+  media?: MediaInfo;
+  progress?: number;
+  provider?: string;
+  providerAttempts?: ProviderAttempt[];
+};
+
+export type ProviderAttempt = {
+  provider: string;
+  error: string;
+};
+
+export type MediaInfo = {
+  durationSeconds: number;
+  billableMinutes: number;
+  format?: string;
+  audioCodec?: string;
+  sampleRate?: number;
+  channels?: number;
+  bitRate?: number;
+  hasVideo: boolean;
+};
+
+export type FillerCount = {
+  filler: string;
+  count: number;
+};
+
+export type SpeakerAnalytics = {
+  speakerId: string;
+  speakerName?: string;
+  talkTimeMs: number;
+  talkShare: number;
+  turns: number;
+  avgTurnMs: number;
+  longestMonologueMs: number;
+  words: number;
+  wordsPerMinute: number;
+  interruptions: number;
+  fillers: number;
+  fillerRate: number;
+  fillersPerMinute: number;
+  topFillers: FillerCount[];
+};
+
+export type AnalyticsReport = {
+  durationMs: number;
+  talkTimeMs: number;
+  turns: number;
+  interruptions: number;
+  words: number;
+  fillers: number;
+  fillerRate: number;
+  topFillers: FillerCount[];
+  speakers: SpeakerAnalytics[];
+};
+
+export type TaskAnalytics = AnalyticsReport & {
+  taskId: string;
+};
+
+export type ProjectAnalytics = AnalyticsReport & {
+  projectId: string;
+  tasks: number;
+};
+
+export type SpeakerProfile = {
+  id: string;
+  name: string;
+  samples: number;
+  createdAt: string;
+  updatedAt: string;
+};
+
+export type ProjectSpeaker = {
+  taskId: string;
+  originalName: string;
+  speakerId: string;
+  speakerName?: string;
+  segments: number;
+  talkTimeMs: number;
+  profileId?: string;
+  profileName?: string;
+  similarity?: number;
 };
 
 export type HistoryItem = {
@@ -19,6 +102,7 @@ export type HistoryItem = {
   originalName: string;
   status: string;
   uploadedAt: string;
+  durationSeconds?: number;
 };
 
 export type ProjectFileItem = {
@@ -104,6 +188,23 @@ export async function updateSpeaker(
   }
 }
 
+export async function mergeSpeakers(
+  taskId: string,
+  from: string,
+  into: string
+): Promise<void> {
+  const res = await fetch(`${API_BASE}/tasks/${taskId}/speakers/merge`, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ from, into }),
+    credentials: "include",
+  });
+  if (!res.ok) {
+    const data = await safeJson(res);
+    throw new Error(data?.error ?? "Failed to merge speakers");
+  }
+}
+
 export function getAudioUrl(taskId: string): string {
   return `${API_BASE}/tasks/${taskId}/audio`;
 }
@@ -128,8 +229,36 @@ export async function deleteTask(taskId: string): Promise<void> {
   }
 }
 
-export async function downloadExport(taskId: string, format: "txt" | "docx") {
-  const res = await fetch(`${API_BASE}/tasks/${taskId}/export?format=${format}`, {
+export async function fetchAnalytics(taskId: string): Promise<TaskAnalytics> {
+  const res = await fetch(`${API_BASE}/tasks/${taskId}/analytics`, {
+    credentials: "include",
+  });
+  if (!res.ok) {
+    throw new Error("Failed to load analytics");
+  }
+  return (await res.json()) as TaskAnalytics;
+}
+
+export async function fetchProjectAnalytics(
+  projectId: string
+): Promise<ProjectAnalytics> {
+  const res = await fetch(`${API_BASE}/projects/${projectId}/analytics`, {
+    credentials: "include",
+  });
+  if (!res.ok) {
+    throw new Error("Failed to load analytics");
+  }
+  return (await res.json()) as ProjectAnalytics;
+}
+
+export async function downloadExport(
+  taskId: string,
+  format: "txt" | "docx" | "json",
+  removeFillers = false
+) {
+  const params = new URLSearchParams({ format });
+  if (removeFillers) params.set("removeFillers", "true");
+  const res = await fetch(`${API_BASE}/tasks/${taskId}/export?${params}`, {
     credentials: "include",
   });
   if (!res.ok) {
@@ -198,6 +327,100 @@ export async function deleteProject(projectId: string): Promise<void> {
   }
 }
 
+export async function updateProjectGlossary(
+  projectId: string,
+  glossary: Glossary
+): Promise<Glossary> {
+  const res = await fetch(`${API_BASE}/projects/${projectId}/glossary`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(glossary),
+    credentials: "include",
+  });
+  if (!res.ok) {
+    const data = await safeJson(res);
+    throw new Error(data?.error ?? "Failed to update glossary");
+  }
+  return (await res.json()) as Glossary;
+}
+
+export async function fetchSpeakerProfiles(
+  projectId: string
+): Promise<SpeakerProfile[]> {
+  const res = await fetch(`${API_BASE}/projects/${projectId}/speaker-profiles`, {
+    credentials: "include",
+  });
+  if (!res.ok) {
+    throw new Error("Failed to load speaker profiles");
+  }
+  return (await res.json()) as SpeakerProfile[];
+}
+
+export async function renameSpeakerProfile(
+  projectId: string,
+  profileId: string,
+  name: string
+): Promise<void> {
+  const res = await fetch(
+    `${API_BASE}/projects/${projectId}/speaker-profiles/${profileId}`,
+    {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ name }),
+      credentials: "include",
+    }
+  );
+  if (!res.ok) {
+    const data = await safeJson(res);
+    throw new Error(data?.error ?? "Failed to rename speaker profile");
+  }
+}
+
+export async function deleteSpeakerProfile(
+  projectId: string,
+  profileId: string
+): Promise<void> {
+  const res = await fetch(
+    `${API_BASE}/projects/${projectId}/speaker-profiles/${profileId}`,
+    {
+      method: "DELETE",
+      credentials: "include",
+    }
+  );
+  if (!res.ok) {
+    throw new Error("Failed to delete speaker profile");
+  }
+}
+
+export async function fetchProjectSpeakers(
+  projectId: string
+): Promise<ProjectSpeaker[]> {
+  const res = await fetch(`${API_BASE}/projects/${projectId}/speakers`, {
+    credentials: "include",
+  });
+  if (!res.ok) {
+    throw new Error("Failed to load project speakers");
+  }
+  return (await res.json()) as ProjectSpeaker[];
+}
+
+export async function renameProjectSpeaker(
+  projectId: string,
+  from: string,
+  to: string
+): Promise<void> {
+  const res = await fetch(`${API_BASE}/projects/${projectId}/speakers/rename`, {
+    method: "PUT",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify({ from, to }),
+    credentials: "include",
+  });
+  if (!res.ok) {
+    const data = await safeJson(res);
+    throw new Error(data?.error ?? "Failed to rename speaker");
+  }
+}
+
 async function safeJson(res: Response) {
   try {
     return await res.json();
@@ -1,6 +1,7 @@
 import { Tag } from "antd";
 
 const statusConfig: Record<string, { color: string; label: string }> = {
+  "извлечение аудио": { color: "processing", label: "Извлечение аудио" },
   "ожидает": { color: "default", label: "Ожидает" },
   "в процессе": { color: "processing", label: "В процессе" },
   "готово": { color: "success", label: "Готово" },
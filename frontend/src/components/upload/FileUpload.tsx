@@ -16,7 +16,7 @@ export default function FileUpload({ onUploadStart, uploading }: FileUploadProps
     <Card title="Загрузка медиафайла">
       <Dragger
         name="file"
-        accept=".mp3,.wav,.mp4,.mov,audio/*,video/*"
+        accept=".mp3,.wav,.m4a,.aac,.amr,.ogg,.opus,.flac,.mp4,.mov,.webm,.mkv,.3gp,audio/*,video/*"
         multiple={false}
         showUploadList={false}
         beforeUpload={(f) => {
@@ -36,7 +36,7 @@ export default function FileUpload({ onUploadStart, uploading }: FileUploadProps
           Нажмите или перетащите файл для загрузки
         </p>
         <p className="ant-upload-hint">
-          MP3, WAV, MP4, MOV до 1 ГБ
+          MP3, WAV, M4A, AAC, AMR, OGG, OPUS, FLAC, MP4, MOV, WEBM, MKV до 1 ГБ
         </p>
       </Dragger>
 
@@ -276,6 +276,22 @@ describe('TaskPage', () => {
       });
     });
 
+    it('shows progress reported by the worker', async () => {
+      vi.mocked(api.fetchTask).mockResolvedValue({
+        id: 'task-123',
+        status: 'в процессе',
+        originalName: 'test.mp3',
+        createdAt: '2024-01-01T00:00:00Z',
+        progress: 42,
+      });
+
+      renderTaskPage();
+
+      await waitFor(() => {
+        expect(screen.getByText('42%')).toBeInTheDocument();
+      });
+    });
+
     it('shows processing message for pending task', async () => {
       vi.mocked(api.fetchTask).mockResolvedValue({
         id: 'task-123',
@@ -1,6 +1,6 @@
 import { useCallback, useEffect, useState } from "react";
 import { useNavigate, useParams } from "react-router-dom";
-import { Button, Space, Spin, Alert, Typography, Card } from "antd";
+import { Button, Space, Spin, Alert, Typography, Card, Progress } from "antd";
 import {
   ArrowLeftOutlined,
   DownloadOutlined,
@@ -237,13 +237,21 @@ export default function TaskPage() {
             </>
           )}
 
-          {(task.status === "ожидает" || task.status === "в процессе") && (
+          {(task.status === "извлечение аудио" ||
+            task.status === "ожидает" ||
+            task.status === "в процессе") && (
             <Card>
               <div style={{ textAlign: "center", padding: 32 }}>
                 <Spin size="large" />
                 <Title level={5} style={{ marginTop: 16 }}>
                   Идёт обработка...
                 </Title>
+                {task.progress !== undefined && (
+                  <Progress
+                    percent={task.progress}
+                    style={{ maxWidth: 320, margin: "0 auto" }}
+                  />
+                )}
                 <Paragraph type="secondary">
                   Транскрибация может занять несколько минут
                 </Paragraph>
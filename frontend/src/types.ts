@@ -16,4 +16,17 @@ export type Project = {
   status: string;
   createdAt: string;
   fileCount: number;
+  glossary?: Glossary;
+};
+
+export type GlossaryReplacement = {
+  from: string;
+  to: string;
+  caseSensitive?: boolean;
+  partialWords?: boolean;
+};
+
+export type Glossary = {
+  terms?: string[];
+  replacements?: GlossaryReplacement[];
 };
@@ -6,6 +6,7 @@ class Settings(BaseSettings):
     HF_TOKEN: Optional[str] = None
     DEVICE: str = "cpu"
     DIARIZATION_MODEL: str = "pyannote/speaker-diarization-3.1"
+    EMBEDDING_MODEL: str = "pyannote/wespeaker-voxceleb-resnet34-LM"
     HOST: str = "0.0.0.0"
     PORT: int = 8001
     WHISPER_MODEL: str = "large-v3"
@@ -0,0 +1,78 @@
+import logging
+from collections import defaultdict
+
+import numpy as np
+import torch
+
+from .config import settings
+
+logger = logging.getLogger(__name__)
+
+# Реплики короче этого порога дают шумный эмбеддинг
+MIN_TURN_SECONDS = 1.0
+# Сколько самых длинных реплик спикера усредняется
+MAX_TURNS_PER_SPEAKER = 20
+
+_inference = None
+
+
+def _get_inference():
+    global _inference
+    if _inference is not None:
+        return _inference
+
+    from pyannote.audio import Inference, Model
+
+    logger.info("Загрузка модели эмбеддингов %s...", settings.EMBEDDING_MODEL)
+    model = Model.from_pretrained(settings.EMBEDDING_MODEL, use_auth_token=settings.HF_TOKEN)
+    _inference = Inference(model, window="whole")
+    _inference.to(torch.device(settings.DEVICE))
+    logger.info("Модель эмбеддингов загружена на %s", settings.DEVICE)
+    return _inference
+
+
+def speaker_embeddings(audio_path: str, turns: list[dict]) -> list[dict]:
+    """
+    Эмбеддинги голоса спикеров по их репликам.
+
+    Args:
+        audio_path: путь к аудиофайлу
+        turns: реплики [{"speaker", "start", "end"}] в секундах
+
+    Returns:
+        [{"speaker", "embedding", "duration"}] — средний L2-нормированный
+        эмбеддинг спикера, взвешенный по длительности реплик. Спикеры,
+        у которых нет достаточно длинных реплик, пропускаются.
+    """
+    from pyannote.core import Segment
+
+    by_speaker = defaultdict(list)
+    for turn in turns:
+        duration = turn["end"] - turn["start"]
+        if turn["speaker"] and duration >= MIN_TURN_SECONDS:
+            by_speaker[turn["speaker"]].append((turn["start"], turn["end"]))
+
+    inference = _get_inference()
+    result = []
+    for speaker, speaker_turns in by_speaker.items():
+        speaker_turns.sort(key=lambda t: t[1] - t[0], reverse=True)
+        total = np.zeros(0)
+        weight = 0.0
+        for start, end in speaker_turns[:MAX_TURNS_PER_SPEAKER]:
+            vector = np.asarray(inference.crop(audio_path, Segment(start, end)), dtype=np.float64)
+            if not np.all(np.isfinite(vector)):
+                continue
+            total = vector * (end - start) if total.size == 0 else total + vector * (end - start)
+            weight += end - start
+        if weight == 0:
+            continue
+        norm = np.linalg.norm(total)
+        if norm == 0:
+            continue
+        result.append({
+            "speaker": speaker,
+            "embedding": (total / norm).round(6).tolist(),
+            "duration": round(weight, 3),
+        })
+
+    return result
@@ -0,0 +1,119 @@
+"""Фоновые задачи транскрибации.
+
+Задача живёт в памяти процесса: при перезапуске сервиса она теряется,
+и клиент получает 404 — в этом случае файл нужно отправить заново.
+"""
+import asyncio
+import logging
+import os
+import threading
+import time
+import uuid
+from dataclasses import dataclass, field
+from typing import Callable, Optional
+
+logger = logging.getLogger(__name__)
+
+# Сколько хранить результат завершённой задачи, секунды
+JOB_TTL_SECONDS = 3600
+
+QUEUED = "queued"
+RUNNING = "running"
+DONE = "done"
+FAILED = "failed"
+CANCELLED = "cancelled"
+
+
+class JobCancelled(Exception):
+    """Задача отменена клиентом — выполнение прерывается между шагами."""
+
+
+@dataclass
+class Job:
+    id: str
+    audio_path: str
+    status: str = QUEUED
+    stage: str = ""
+    progress: float = 0.0
+    error: Optional[str] = None
+    result: Optional[dict] = None
+    created_at: float = field(default_factory=time.time)
+    finished_at: Optional[float] = None
+    cancelled: threading.Event = field(default_factory=threading.Event)
+
+    def report(self, stage: str, progress: float) -> None:
+        """Колбэк прогресса для pipeline; прерывает отменённую задачу."""
+        if self.cancelled.is_set():
+            raise JobCancelled()
+        self.stage = stage
+        self.progress = progress
+
+    def to_status(self) -> dict:
+        return {
+            "job_id": self.id,
+            "status": self.status,
+            "stage": self.stage,
+            "progress": round(self.progress, 3),
+            "error": self.error,
+        }
+
+
+class JobStore:
+    def __init__(self, lock: asyncio.Semaphore):
+        self._jobs: dict[str, Job] = {}
+        self._lock = lock
+
+    def submit(self, audio_path: str, run: Callable[[Job], dict]) -> Job:
+        self._cleanup()
+        job = Job(id=uuid.uuid4().hex, audio_path=audio_path)
+        self._jobs[job.id] = job
+        asyncio.get_event_loop().create_task(self._run(job, run))
+        return job
+
+    def get(self, job_id: str) -> Optional[Job]:
+        self._cleanup()
+        return self._jobs.get(job_id)
+
+    def cancel(self, job_id: str) -> Optional[Job]:
+        job = self._jobs.get(job_id)
+        if job is None:
+            return None
+        job.cancelled.set()
+        if job.status in (QUEUED, RUNNING):
+            job.status = CANCELLED
+            job.finished_at = time.time()
+        return job
+
+    async def _run(self, job: Job, run: Callable[[Job], dict]) -> None:
+        try:
+            async with self._lock:
+                if job.cancelled.is_set():
+                    return
+                job.status = RUNNING
+                loop = asyncio.get_event_loop()
+                result = await loop.run_in_executor(None, run, job)
+            if not job.cancelled.is_set():
+                job.result = result
+                job.status = DONE
+                job.progress = 1.0
+        except JobCancelled:
+            logger.info("Задача %s отменена", job.id)
+        except Exception as e:
+            logger.exception("Ошибка задачи %s", job.id)
+            job.status = FAILED
+            job.error = f"Ошибка транскрибации: {str(e)}"
+        finally:
+            if job.finished_at is None:
+                job.finished_at = time.time()
+            if os.path.exists(job.audio_path):
+                os.unlink(job.audio_path)
+
+    def _cleanup(self) -> None:
+        now = time.time()
+        expired = [
+            job_id
+            for job_id, job in self._jobs.items()
+            if job.finished_at is not None and now - job.finished_at > JOB_TTL_SECONDS
+        ]
+        for job_id in expired:
+            del self._jobs[job_id]
@@ -1,19 +1,26 @@
 import asyncio
+import json
 import logging
 import os
 import tempfile
 import time
 from typing import Optional
 
-from fastapi import FastAPI, File, UploadFile, HTTPException, Query
+from fastapi import FastAPI, File, Form, UploadFile, HTTPException, Query
+from pydantic import TypeAdapter, ValidationError
 from fastapi.middleware.cors import CORSMiddleware
 
 from .config import settings
 from .diarization import diarize
+from .embeddings import speaker_embeddings
 from .alignment import align_words_to_speakers
 from .transcription import transcribe
+from .jobs import DONE, JobStore
 from .models import (
     DiarizationResponse,
+    JobStatusResponse,
+    SpeakerEmbeddingsResponse,
+    SpeakerTurn,
     TextProcessRequest,
     TextProcessResponse,
     TranscribeFullResponse,
@@ -70,9 +77,58 @@ async def diarize_audio(
             os.unlink(tmp_path)
 
 
+@app.post("/speaker-embeddings", response_model=SpeakerEmbeddingsResponse)
+async def speaker_embeddings_endpoint(
+    audio: UploadFile = File(...),
+    turns: str = Form(..., description='JSON: [{"speaker", "start", "end"}] в секундах'),
+):
+    """Эмбеддинги голоса спикеров по репликам диаризации — для поиска знакомых голосов."""
+    try:
+        parsed = TypeAdapter(list[SpeakerTurn]).validate_python(json.loads(turns))
+    except (ValueError, ValidationError) as e:
+        raise HTTPException(status_code=422, detail=f"Некорректные реплики: {str(e)}")
+
+    suffix = os.path.splitext(audio.filename or ".ogg")[1]
+    try:
+        with tempfile.NamedTemporaryFile(suffix=suffix, delete=False) as tmp:
+            content = await audio.read()
+            tmp.write(content)
+            tmp_path = tmp.name
+
+        loop = asyncio.get_event_loop()
+        speakers = await loop.run_in_executor(
+            None,
+            speaker_embeddings,
+            tmp_path,
+            [t.model_dump() for t in parsed],
+        )
+        return SpeakerEmbeddingsResponse(speakers=speakers)
+    except Exception as e:
+        logger.exception("Ошибка расчёта эмбеддингов")
+        raise HTTPException(status_code=500, detail=f"Ошибка расчёта эмбеддингов: {str(e)}")
+    finally:
+        if "tmp_path" in locals():
+            os.unlink(tmp_path)
+
+
 @app.post("/process-text", response_model=TextProcessResponse)
 async def process_text_endpoint(request: TextProcessRequest):
     """Обработка текста: определение и удаление слов-паразитов."""
+    if request.texts is not None:
+        # Сегменты уже заданы вызывающей стороной — ответ 1:1 с запросом
+        results = [
+            process_text(
+                text,
+                should_detect=request.detect_fillers,
+                should_remove=request.remove_fillers,
+            )
+            for text in request.texts
+        ]
+        return TextProcessResponse(
+            segments=results,
+            total_fillers=sum(len(r["fillers_found"]) for r in results),
+        )
+
     # Разбиваем текст на предложения для посегментной обработки
     import re
     sentences = re.split(r"(?<=[.!?])\s+", request.text)
@@ -107,14 +163,23 @@ def _do_transcribe_full(
     language: Optional[str],
     num_speakers: Optional[int],
     detect_fillers: bool,
+    hotwords: Optional[str] = None,
+    report=None,
 ) -> dict:
-    """Синхронный pipeline: Whisper → PyAnnote → alignment → fillers."""
+    """Синхронный pipeline: Whisper → PyAnnote → alignment → fillers.
+
+    report(stage, progress) вызывается перед каждым шагом (для фоновых задач).
+    """
+    if report is None:
+        report = lambda stage, progress: None
     start_time = time.time()
 
     # Шаг 1: Транскрибация через Faster-Whisper
-    whisper_result = transcribe(audio_path, language=language)
+    report("transcription", 0.05)
+    whisper_result = transcribe(audio_path, language=language, hotwords=hotwords)
 
     # Шаг 2: Диаризация через PyAnnote
+    report("diarization", 0.6)
     try:
         diarization_segments = diarize(audio_path, num_speakers=num_speakers)
     except Exception as e:
@@ -122,9 +187,11 @@ def _do_transcribe_full(
         diarization_segments = []
 
     # Шаг 3: Alignment слов к спикерам
+    report("alignment", 0.9)
     aligned = align_words_to_speakers(whisper_result["words"], diarization_segments)
 
     # Шаг 4: Детектор паразитов по сегментам
+    report("fillers", 0.95)
     for seg in aligned:
         if detect_fillers:
             result = process_text(seg["text"], should_detect=True, should_remove=False)
@@ -151,6 +218,7 @@ async def transcribe_full_endpoint(
     language: Optional[str] = Query(None, description="Код языка (ru, en, ...) или пусто для автодетекта"),
     num_speakers: Optional[int] = Query(None, ge=1, le=20, description="Ожидаемое количество спикеров"),
     detect_fillers: bool = Query(True, description="Определять слова-паразиты"),
+    hotwords: Optional[str] = Form(None, description="Термины словаря проекта через запятую"),
 ):
     """Полный pipeline: транскрибация + диаризация + alignment + детектор паразитов."""
     suffix = os.path.splitext(audio.filename or ".wav")[1]
@@ -169,6 +237,7 @@ async def transcribe_full_endpoint(
                 language,
                 num_speakers,
                 detect_fillers,
+                hotwords,
             )
 
         return TranscribeFullResponse(**result)
@@ -180,6 +249,63 @@ async def transcribe_full_endpoint(
             os.unlink(tmp_path)
 
 
+_jobs = JobStore(_transcribe_lock)
+
+
+@app.post("/jobs/transcribe-full", response_model=JobStatusResponse, status_code=202)
+async def submit_transcribe_full_job(
+    audio: UploadFile = File(...),
+    language: Optional[str] = Query(None, description="Код языка (ru, en, ...) или пусто для автодетекта"),
+    num_speakers: Optional[int] = Query(None, ge=1, le=20, description="Ожидаемое количество спикеров"),
+    detect_fillers: bool = Query(True, description="Определять слова-паразиты"),
+    hotwords: Optional[str] = Form(None, description="Термины словаря проекта через запятую"),
+):
+    """Ставит полный pipeline в очередь и сразу возвращает ID задачи.
+
+    В отличие от /transcribe-full обрыв соединения не теряет работу:
+    клиент опрашивает GET /jobs/{id} и забирает GET /jobs/{id}/result.
+    """
+    suffix = os.path.splitext(audio.filename or ".wav")[1]
+    with tempfile.NamedTemporaryFile(suffix=suffix, delete=False) as tmp:
+        while chunk := await audio.read(1 << 20):
+            tmp.write(chunk)
+        tmp_path = tmp.name
+
+    job = _jobs.submit(
+        tmp_path,
+        lambda job: _do_transcribe_full(
+            job.audio_path, language, num_speakers, detect_fillers, hotwords, report=job.report
+        ),
+    )
+    return JobStatusResponse(**job.to_status())
+
+
+@app.get("/jobs/{job_id}", response_model=JobStatusResponse)
+async def get_job(job_id: str):
+    job = _jobs.get(job_id)
+    if job is None:
+        raise HTTPException(status_code=404, detail="Задача не найдена")
+    return JobStatusResponse(**job.to_status())
+
+
+@app.get("/jobs/{job_id}/result", response_model=TranscribeFullResponse)
+async def get_job_result(job_id: str):
+    job = _jobs.get(job_id)
+    if job is None:
+        raise HTTPException(status_code=404, detail="Задача не найдена")
+    if job.status != DONE:
+        raise HTTPException(status_code=409, detail=f"Задача не завершена: {job.status}")
+    return TranscribeFullResponse(**job.result)
+
+
+@app.delete("/jobs/{job_id}", response_model=JobStatusResponse)
+async def cancel_job(job_id: str):
+    job = _jobs.cancel(job_id)
+    if job is None:
+        raise HTTPException(status_code=404, detail="Задача не найдена")
+    return JobStatusResponse(**job.to_status())
+
+
 if __name__ == "__main__":
     import uvicorn
     uvicorn.run(app, host=settings.HOST, port=settings.PORT)
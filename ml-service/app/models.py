@@ -14,8 +14,27 @@ class DiarizationResponse(BaseModel):
     num_speakers: int
 
 
+class SpeakerTurn(BaseModel):
+    speaker: str
+    start: float
+    end: float
+
+
+class SpeakerEmbedding(BaseModel):
+    speaker: str
+    embedding: list[float]
+    # Суммарная длительность реплик, по которым посчитан эмбеддинг (секунды)
+    duration: float
+
+
+class SpeakerEmbeddingsResponse(BaseModel):
+    speakers: list[SpeakerEmbedding]
+
+
 class TextProcessRequest(BaseModel):
-    text: str
+    text: str = ""
+    # Готовые сегменты: обрабатываются по отдельности, без разбиения на предложения
+    texts: Optional[list[str]] = None
     detect_fillers: bool = True
     remove_fillers: bool = False
 
@@ -54,3 +73,12 @@ class TranscribeFullResponse(BaseModel):
     segments: list[TranscribeSegment]
     num_speakers: int
     processing_time_seconds: float
+
+
+class JobStatusResponse(BaseModel):
+    job_id: str
+    # queued, running, done, failed, cancelled
+    status: str
+    stage: str = ""
+    progress: float = 0.0
+    error: Optional[str] = None
@@ -31,13 +31,19 @@ def _get_model():
     return _model
 
 
-def transcribe(audio_path: str, language: str | None = None) -> dict:
-    """Транскрибирует аудиофайл через Faster-Whisper с word-level timestamps."""
+def transcribe(audio_path: str, language: str | None = None, hotwords: str | None = None) -> dict:
+    """Транскрибирует аудиофайл через Faster-Whisper с word-level timestamps.
+
+    hotwords — термины словаря проекта; подставляются в промпт каждого окна,
+    чтобы Whisper писал имена и названия продуктов правильно.
+    """
     model = _get_model()
 
     kwargs = {"word_timestamps": True, "beam_size": 5}
     if language:
         kwargs["language"] = language
+    if hotwords:
+        kwargs["hotwords"] = hotwords
 
     segments, info = model.transcribe(audio_path, **kwargs)
 